@@ -0,0 +1,138 @@
+package sup
+
+import (
+	"context"
+	"errors"
+)
+
+// Actor is a task which owns an inbox, and handles each message sent to it
+// in turn, in a loop (see RunSteps), with a handler which may send to the
+// actor's outbox.  It's the inbox-outbox-and-a-select-loop pattern, without
+// the wiring.
+//
+// Others send to the actor with the SenderChannel Inbox returns, and read
+// what it sends with the ReceiverChannel Outbox returns; or the outbox can
+// be another actor's inbox, with SetOutbox.
+//
+// The actor runs until its inbox is closed, or the handler returns
+// ErrLoopDone, or it's soft stopped: those are a clean finish, and it
+// closes its outbox (so, the next actor along finishes too, if the outbox is
+// its inbox), and returns nil.  It also stops if its context is done (then
+// it returns the context's error), or the handler returns an error (then it
+// returns that), unless ActorOnError says otherwise.  Then, the outbox is
+// left open, since whoever reads it is presumably stopping anyway.
+//
+// However the actor stops, its inbox is closed by the time Run returns, so
+// sending to it returns an ErrChannelClosed, rather than waiting for an actor
+// which is gone.  (So, an actor can only be run once.)
+type Actor[In, Out any] struct {
+	name     string
+	handle   func(Context, In, SenderChannel[Out]) error
+	onError  func(Context, error) error
+	inboxTx  SenderChannel[In]
+	inboxRx  ReceiverChannel[In]
+	outboxTx SenderChannel[Out]
+	outboxRx ReceiverChannel[Out]
+}
+
+// ActorOption configures an Actor.
+type ActorOption func(*actorConfig)
+
+type actorConfig struct {
+	inboxCap  int
+	outboxCap int
+	onError   func(Context, error) error
+}
+
+// NewActor returns an Actor with the given name, which calls handle for
+// each message sent to its inbox.  The inbox and outbox are channels named
+// name+".inbox" and name+".outbox", unbuffered unless the options say
+// otherwise.
+func NewActor[In, Out any](name string, handle func(Context, In, SenderChannel[Out]) error, opts ...ActorOption) *Actor[In, Out] {
+	var cfg actorConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	a := &Actor[In, Out]{name: name, handle: handle, onError: cfg.onError}
+	a.inboxTx, a.inboxRx = NewChannel[In](name+".inbox", cfg.inboxCap)
+	a.outboxTx, a.outboxRx = NewChannel[Out](name+".outbox", cfg.outboxCap)
+	return a
+}
+
+// ActorInboxCapacity sets the buffer capacity of an actor's inbox.
+func ActorInboxCapacity(n int) ActorOption {
+	return func(cfg *actorConfig) {
+		cfg.inboxCap = n
+	}
+}
+
+// ActorOutboxCapacity sets the buffer capacity of an actor's outbox.
+func ActorOutboxCapacity(n int) ActorOption {
+	return func(cfg *actorConfig) {
+		cfg.outboxCap = n
+	}
+}
+
+// ActorOnError sets a function which decides what happens when the handler
+// returns an error (other than ErrLoopDone): if it returns nil, the actor
+// carries on with the next message; if it returns an error, the actor stops
+// with that error.  It's called on the actor's own goroutine.
+func ActorOnError(onError func(ctx Context, err error) error) ActorOption {
+	return func(cfg *actorConfig) {
+		cfg.onError = onError
+	}
+}
+
+// ActorLogErrors makes an actor log the handler's errors (with Logger, at
+// warning level), and carry on with the next message, rather than stop.
+func ActorLogErrors() ActorOption {
+	return ActorOnError(func(ctx Context, err error) error {
+		Logger(ctx).Warn("actor failed to handle a message", "error", err)
+		return nil
+	})
+}
+
+func (a *Actor[In, Out]) Name() string {
+	return a.name
+}
+
+// Inbox returns the channel for sending messages to the actor.
+func (a *Actor[In, Out]) Inbox() SenderChannel[In] {
+	return a.inboxTx
+}
+
+// Outbox returns the channel on which the actor's messages arrive (unless
+// SetOutbox has sent them elsewhere).
+func (a *Actor[In, Out]) Outbox() ReceiverChannel[Out] {
+	return a.outboxRx
+}
+
+// SetOutbox makes the actor send its messages to the given channel, rather
+// than to its own outbox, and close it when the actor finishes cleanly.
+// It's for wiring actors together: set one's outbox to another's inbox.
+// Call it before the actor is run.
+func (a *Actor[In, Out]) SetOutbox(out SenderChannel[Out]) {
+	a.outboxTx = out
+}
+
+func (a *Actor[In, Out]) Run(ctx context.Context) error {
+	defer a.inboxTx.Close()
+	recv := a.inboxRx.RecvOrClosed(func(msg In, ok bool) error {
+		if !ok {
+			return ErrLoopDone
+		}
+		err := a.handle(ctx, msg, a.outboxTx)
+		if err != nil && a.onError != nil && !errors.Is(err, ErrLoopDone) {
+			err = a.onError(ctx, err)
+		}
+		return err
+	})
+	stop := softStopCase(ctx)
+	if err := RunSteps(ctx, func(ctx Context) error {
+		return Select(ctx, recv, stop)
+	}); err != nil {
+		return err
+	}
+	a.outboxTx.Close()
+	return nil
+}
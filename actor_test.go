@@ -0,0 +1,80 @@
+package sup_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/warpfork/go-sup"
+)
+
+func TestActor(t *testing.T) {
+	t.Run("messages should be handled in turn, and the outbox closed when the inbox is", func(t *testing.T) {
+		doubler := sup.NewActor("doubler", func(ctx sup.Context, n int, out sup.SenderChannel[int]) error {
+			return sup.Select(ctx, out.SendAndThen(n*2, nil))
+		}, sup.ActorInboxCapacity(3), sup.ActorOutboxCapacity(3))
+		for i := 1; i <= 3; i++ {
+			doubler.Inbox().TrySend(i)
+		}
+		doubler.Inbox().Close()
+		shouldEqual(t, doubler.Run(context.Background()), nil)
+		var got []int
+		for n := range doubler.Outbox().Chan {
+			got = append(got, n)
+		}
+		shouldEqual(t, fmt.Sprint(got), "[2 4 6]")
+	})
+	t.Run("a handler's error should stop the actor, and leave the outbox open", func(t *testing.T) {
+		a := sup.NewActor("fails", func(sup.Context, int, sup.SenderChannel[int]) error {
+			return errors.New("bad message")
+		}, sup.ActorInboxCapacity(1))
+		a.Inbox().TrySend(1)
+		shouldEqual(t, fmt.Sprint(a.Run(context.Background())), "bad message")
+		select {
+		case <-a.Outbox().Chan:
+			t.Error("outbox should be open, and empty")
+		default:
+		}
+		var closed sup.ErrChannelClosed
+		shouldEqual(t, errors.As(sup.Select(context.Background(), a.Inbox().SendAndThen(2, nil)), &closed), true)
+	})
+	t.Run("a done context should stop the actor, and close its inbox", func(t *testing.T) {
+		a := sup.NewActor("idle", func(sup.Context, int, sup.SenderChannel[int]) error { return nil })
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		shouldEqual(t, a.Run(ctx), context.Canceled)
+		shouldEqual(t, a.Inbox().Closed(), true)
+	})
+	t.Run("with ActorLogErrors, errors should be logged, and the actor carry on", func(t *testing.T) {
+		var buf bytes.Buffer
+		handled := 0
+		a := sup.NewActor("shrugs", func(sup.Context, int, sup.SenderChannel[int]) error {
+			handled++
+			return errors.New("bad message")
+		}, sup.ActorInboxCapacity(2), sup.ActorLogErrors())
+		a.Inbox().TrySend(1)
+		a.Inbox().TrySend(2)
+		a.Inbox().Close()
+		ctx := sup.CtxWithLogger(context.Background(), textLogger(&buf))
+		shouldEqual(t, a.Run(ctx), nil)
+		shouldEqual(t, handled, 2)
+		shouldEqual(t, strings.Count(buf.String(), "bad message"), 2)
+	})
+	t.Run("a soft stop should stop an idle actor cleanly", func(t *testing.T) {
+		a := sup.NewActor("idle", func(sup.Context, int, sup.SenderChannel[int]) error { return nil })
+		svr := sup.SuperviseForkJoin("main", []sup.Task{a})
+		go func() {
+			for svr.Phase() != sup.Phase_collecting {
+				time.Sleep(time.Millisecond)
+			}
+			svr.SoftStop()
+		}()
+		shouldEqual(t, sup.SuperviseRoot(context.Background(), svr), nil)
+		_, ok := <-a.Outbox().Chan
+		shouldEqual(t, ok, false)
+	})
+}
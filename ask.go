@@ -0,0 +1,65 @@
+package sup
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+// Envelope carries a request to a server task, along with the way back:
+// the server calls Reply, exactly once, to answer it.  Envelopes are made
+// by Ask; the server just receives them from its inbox.
+type Envelope[Req, Resp any] struct {
+	Request Req
+	reply   *envelopeReply[Resp]
+}
+
+type envelopeReply[Resp any] struct {
+	ch      chan askResult[Resp] // buffered, so Reply never blocks, even if the asker gave up.
+	replied atomic.Bool
+}
+
+type askResult[Resp any] struct {
+	resp Resp
+	err  error
+}
+
+// Reply sends the response (and error, which may be nil) back to the task
+// that's waiting in Ask; Ask returns them.  It never blocks: if the asker
+// has given up already, the reply is simply discarded.
+//
+// Replying more than once to the same Envelope is a usage error, and panics.
+func (e Envelope[Req, Resp]) Reply(resp Resp, err error) {
+	if e.reply == nil {
+		panic("usage: Envelope.Reply called on an Envelope not made by Ask")
+	}
+	if e.reply.replied.Swap(true) {
+		panic("usage: Envelope.Reply called more than once")
+	}
+	e.reply.ch <- askResult[Resp]{resp, err}
+}
+
+// Ask sends a request to a server task, via the server's inbox, and waits
+// for the reply: the result of the server calling Reply on the Envelope.
+// Both the send and the wait honor the context: if it's done first, Ask
+// returns the context's error, and the server's eventual reply (if any) is
+// discarded without blocking it.  Errors from sending (such as the inbox
+// being closed) are returned as from Select.
+//
+// A server which drops an Envelope without replying leaves Ask waiting
+// until the context is done, so servers should reply to every request,
+// even if only with an error.
+func Ask[Req, Resp any](ctx context.Context, outbox SenderChannel[Envelope[Req, Resp]], req Req) (Resp, error) {
+	if StrictSupervision != StrictMode_off {
+		checkSupervised(ctx, "sup.Ask")
+	}
+	env := Envelope[Req, Resp]{req, &envelopeReply[Resp]{ch: make(chan askResult[Resp], 1)}}
+	if err := (sendCase[Envelope[Req, Resp]]{outbox, env, nil}).selectAlone(ctx); err != nil {
+		var zero Resp
+		return zero, err
+	}
+	r, _, err := recv(ctx, env.reply.ch)
+	if err != nil {
+		return r.resp, err
+	}
+	return r.resp, r.err
+}
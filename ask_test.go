@@ -0,0 +1,39 @@
+package sup_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/warpfork/go-sup"
+)
+
+func TestAsk(t *testing.T) {
+	t.Run("giving up should not block the server's reply", func(t *testing.T) {
+		inboxTx, inboxRx := sup.NewChannel[sup.Envelope[int, int]]("server", 1)
+		ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+		defer cancel()
+		_, err := sup.Ask(ctx, inboxTx, 1)
+		shouldEqual(t, errors.Is(err, context.DeadlineExceeded), true)
+		env, err := inboxRx.TryRecv()
+		mustEqual(t, err, nil)
+		env.Reply(2, nil) // would hang the test, if it blocked.
+	})
+	t.Run("a closed inbox should be an error", func(t *testing.T) {
+		inboxTx, _ := sup.NewChannel[sup.Envelope[int, int]]("server", 1)
+		inboxTx.Close()
+		_, err := sup.Ask(context.Background(), inboxTx, 1)
+		shouldEqual(t, errors.Is(err, sup.ErrClosed), true)
+	})
+	t.Run("replying twice should panic", func(t *testing.T) {
+		inboxTx, inboxRx := sup.NewChannel[sup.Envelope[int, int]]("server", 1)
+		go sup.Ask(context.Background(), inboxTx, 1)
+		env, _, _ := sup.Recv(context.Background(), inboxRx.Chan)
+		env.Reply(1, nil)
+		defer func() {
+			shouldEqual(t, recover(), "usage: Envelope.Reply called more than once")
+		}()
+		env.Reply(2, nil)
+	})
+}
@@ -0,0 +1,102 @@
+package sup
+
+import (
+	"context"
+	"sync"
+)
+
+// BroadcastPolicy says what a Broadcaster does about a subscriber that
+// isn't ready to receive a message (meaning its buffer, if it has one,
+// is full).
+type BroadcastPolicy uint8
+
+const (
+	BroadcastPolicy_block = BroadcastPolicy(0) // Publish waits until the subscriber takes the message (or the subscriber goes away, or Publish's context is done).
+	BroadcastPolicy_drop  = BroadcastPolicy(1) // the subscriber just doesn't get that message; Publish moves on immediately.
+)
+
+// Broadcaster delivers each published message to every current subscriber.
+// It's for repeated messages, like config updates, going from one task to
+// many; for a one-off signal, closing a channel is simpler.
+//
+// Each subscriber gets its own channel, with the buffer size the
+// broadcaster was made with.  Messages arrive at each subscriber in the
+// order they were published (minus any dropped, under BroadcastPolicy_drop).
+type Broadcaster[T any] struct {
+	name   string
+	policy BroadcastPolicy
+	buffer int
+
+	mu   sync.Mutex // held for all of Publish, so publishes are delivered in order.
+	subs map[*subscriber[T]]struct{}
+}
+
+type subscriber[T any] struct {
+	ctx context.Context
+	ch  chan T
+}
+
+// NewBroadcaster makes a Broadcaster.  The name is given to subscribers'
+// channels.  Buffer is the capacity of each subscriber's channel; the
+// policy says what happens when it's full.
+func NewBroadcaster[T any](name string, policy BroadcastPolicy, buffer int) *Broadcaster[T] {
+	return &Broadcaster[T]{
+		name:   name,
+		policy: policy,
+		buffer: buffer,
+		subs:   make(map[*subscriber[T]]struct{}),
+	}
+}
+
+// Subscribe returns a channel which will receive every message published
+// from now on, until the given context is done.  Then, the subscription is
+// removed, and the channel is closed.
+func (b *Broadcaster[T]) Subscribe(ctx context.Context) ReceiverChannel[T] {
+	sub := &subscriber[T]{ctx, make(chan T, b.buffer)}
+	b.mu.Lock()
+	b.subs[sub] = struct{}{}
+	b.mu.Unlock()
+	context.AfterFunc(ctx, func() {
+		b.mu.Lock() // waits out any Publish in progress (which notices ctx is done, if it's blocked on us).
+		defer b.mu.Unlock()
+		delete(b.subs, sub)
+		close(sub.ch)
+	})
+	return ReceiverChannel[T]{sub.ch, &channelMeta{name: b.name}}
+}
+
+// Subscribers returns how many subscriptions are currently active.
+func (b *Broadcaster[T]) Subscribers() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.subs)
+}
+
+// Publish delivers the value to every current subscriber, according to the
+// broadcaster's policy.  Under BroadcastPolicy_block, it can wait on slow
+// subscribers; if its context is done while it's waiting, it returns the
+// context's error, and the subscribers it hadn't got to yet miss the message.
+// A subscriber whose own context is done is skipped.
+//
+// Publish calls are delivered one at a time, in order.
+func (b *Broadcaster[T]) Publish(ctx context.Context, v T) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for sub := range b.subs {
+		switch b.policy {
+		case BroadcastPolicy_drop:
+			select {
+			case sub.ch <- v:
+			default:
+			}
+		default:
+			select {
+			case sub.ch <- v:
+			case <-sub.ctx.Done():
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+	}
+	return nil
+}
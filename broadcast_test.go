@@ -0,0 +1,86 @@
+package sup_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/warpfork/go-sup"
+)
+
+func TestBroadcaster(t *testing.T) {
+	t.Run("every subscriber should get every message", func(t *testing.T) {
+		b := sup.NewBroadcaster[int]("config", sup.BroadcastPolicy_block, 2)
+		subs := []sup.ReceiverChannel[int]{b.Subscribe(context.Background()), b.Subscribe(context.Background())}
+		mustEqual(t, b.Publish(context.Background(), 1), nil)
+		mustEqual(t, b.Publish(context.Background(), 2), nil)
+		for _, sub := range subs {
+			shouldEqual(t, sub.Name(), "config")
+			shouldEqual(t, <-sub.Chan, 1)
+			shouldEqual(t, <-sub.Chan, 2)
+		}
+	})
+	t.Run("drop policy should skip slow subscribers", func(t *testing.T) {
+		b := sup.NewBroadcaster[int]("config", sup.BroadcastPolicy_drop, 1)
+		sub := b.Subscribe(context.Background())
+		mustEqual(t, b.Publish(context.Background(), 1), nil)
+		mustEqual(t, b.Publish(context.Background(), 2), nil) // buffer's full; dropped.
+		shouldEqual(t, <-sub.Chan, 1)
+		_, err := sub.TryRecv()
+		shouldEqual(t, err, sup.Nonblock)
+	})
+	t.Run("block policy should wait, unless publish is cancelled", func(t *testing.T) {
+		b := sup.NewBroadcaster[int]("config", sup.BroadcastPolicy_block, 0)
+		sub := b.Subscribe(context.Background())
+		ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+		defer cancel()
+		shouldEqual(t, b.Publish(ctx, 1), context.DeadlineExceeded)
+		go b.Publish(context.Background(), 2)
+		shouldEqual(t, <-sub.Chan, 2)
+	})
+	t.Run("subscriptions should end with their context", func(t *testing.T) {
+		b := sup.NewBroadcaster[int]("config", sup.BroadcastPolicy_block, 0)
+		ctx, cancel := context.WithCancel(context.Background())
+		sub := b.Subscribe(ctx)
+		shouldEqual(t, b.Subscribers(), 1)
+		cancel()
+		_, ok := <-sub.Chan // closed.
+		shouldEqual(t, ok, false)
+		shouldEqual(t, b.Subscribers(), 0)
+		shouldEqual(t, b.Publish(context.Background(), 1), nil) // nobody to block on.
+	})
+	t.Run("subscribing and unsubscribing should race safely with publishing", func(t *testing.T) {
+		b := sup.NewBroadcaster[int]("config", sup.BroadcastPolicy_block, 0)
+		stop := make(chan struct{})
+		var wg sync.WaitGroup
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for {
+					select {
+					case <-stop:
+						return
+					default:
+					}
+					ctx, cancel := context.WithCancel(context.Background())
+					sub := b.Subscribe(ctx)
+					// receive a little, maybe, then leave, then drain until closed.
+					select {
+					case <-sub.Chan:
+					case <-time.After(time.Microsecond):
+					}
+					cancel()
+					for range sub.Chan {
+					}
+				}
+			}()
+		}
+		for i := 0; i < 1000; i++ {
+			mustEqual(t, b.Publish(context.Background(), i), nil)
+		}
+		close(stop)
+		wg.Wait()
+	})
+}
@@ -0,0 +1,100 @@
+package sup
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// ErrSendTimeout is the error returned when a send with a time limit
+// (see SenderChannel.SendWithin and SendTimeout) gives up.
+//
+// It is not context.DeadlineExceeded, and doesn't match it with errors.Is:
+// that's reserved for the context being done, so the two can be told apart.
+type ErrSendTimeout struct {
+	// ChannelName is the name of the channel, or a description of it if it
+	// has no name, just as for ErrChannelClosed.  (It's empty for
+	// SendTimeout, which only has a bare channel to go on.)
+	ChannelName string
+
+	// Waited is how long the send waited before giving up.
+	Waited time.Duration
+}
+
+func (e ErrSendTimeout) Error() string {
+	if e.ChannelName == "" {
+		return fmt.Sprintf("send timed out after %v", e.Waited)
+	}
+	return fmt.Sprintf("send on channel %q timed out after %v", e.ChannelName, e.Waited)
+}
+
+// SendWithin returns a Selectable which sends the value, like SendAndThen
+// (but with no callback), unless the given time passes first: then, the
+// send is abandoned, and Select returns an ErrSendTimeout.  (Other cases in
+// the same Select still proceed as usual, if they're ready first.)
+//
+// Unlike SetOverdueReaction, which only notices slow sends, this gives up
+// on them: use it where there's a fallback, like dropping a message that
+// couldn't be delivered promptly.
+//
+// As with After, the clock starts when Select is called, so one SendWithin
+// Selectable can be reused for every Select in a loop.
+func (c SenderChannel[T]) SendWithin(v T, d time.Duration) Selectable {
+	return sendWithinCase[T]{sendCase[T]{c, v, nil}, d}
+}
+
+type sendWithinCase[T any] struct {
+	sendCase[T]
+	d time.Duration
+}
+
+func (sw sendWithinCase[T]) timeLimit() time.Duration {
+	return sw.d
+}
+
+func (sw sendWithinCase[T]) timeoutErr(waited time.Duration) error {
+	return ErrSendTimeout{sw.Name(), waited}
+}
+
+func (sw sendWithinCase[T]) selectAlone(ctx context.Context) (err error) {
+	if err := sw.c.closedErr(); err != nil {
+		return err
+	}
+	defer recoverClosedSend(&err, sw.Name)
+	return sendTimeout(ctx, sw.c.Chan, sw.v, sw.d, sw.Name)
+}
+
+// timeLimitedSelectable is implemented by cases with a time limit of their
+// own.  Select adds a timer case for each of them, and if a timer wins,
+// returns the timeoutErr of the case it belongs to.
+type timeLimitedSelectable interface {
+	timeLimit() time.Duration
+	timeoutErr(waited time.Duration) error
+}
+
+type timeLimitedCase struct {
+	idx int
+	tls timeLimitedSelectable
+}
+
+// SendTimeout sends the value on the channel, like Send, but gives up if the
+// send hasn't happened within the given time, and returns an ErrSendTimeout.
+// If the context is done first, it returns the context's error.
+func SendTimeout[T any](ctx context.Context, ch chan<- T, v T, d time.Duration) (err error) {
+	defer recoverClosedSend(&err, func() string { return "" })
+	return sendTimeout(ctx, ch, v, d, func() string { return "" })
+}
+
+func sendTimeout[T any](ctx context.Context, ch chan<- T, v T, d time.Duration, channelName func() string) error {
+	start := time.Now()
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case ch <- v:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return ErrSendTimeout{channelName(), time.Since(start)}
+	}
+}
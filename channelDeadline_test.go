@@ -0,0 +1,73 @@
+package sup_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/warpfork/go-sup"
+)
+
+func TestSendWithin(t *testing.T) {
+	ctx := context.Background()
+	t.Run("a ready send should proceed", func(t *testing.T) {
+		tx, rx := sup.NewChannel[int]("out", 1)
+		mustEqual(t, sup.Select(ctx, tx.SendWithin(1, time.Hour)), nil)
+		v, _ := rx.TryRecv()
+		shouldEqual(t, v, 1)
+	})
+	for _, tr := range []struct {
+		name  string
+		extra []sup.Selectable
+	}{
+		{"alone", nil},
+		{"with other cases", []sup.Selectable{sup.After(time.Hour, nil)}},
+	} {
+		t.Run("a blocked send should time out "+tr.name, func(t *testing.T) {
+			tx, _ := sup.NewChannel[int]("out", 0)
+			err := sup.Select(ctx, append([]sup.Selectable{tx.SendWithin(1, 2*time.Millisecond)}, tr.extra...)...)
+			var timeout sup.ErrSendTimeout
+			mustEqual(t, errors.As(err, &timeout), true)
+			shouldEqual(t, timeout.ChannelName, "out")
+			shouldEqual(t, timeout.Waited >= 2*time.Millisecond, true)
+			shouldEqual(t, errors.Is(err, context.DeadlineExceeded), false)
+		})
+		t.Run("cancellation should not look like a timeout "+tr.name, func(t *testing.T) {
+			tx, _ := sup.NewChannel[int]("out", 0)
+			ctx, cancel := context.WithTimeout(ctx, time.Millisecond)
+			defer cancel()
+			err := sup.Select(ctx, append([]sup.Selectable{tx.SendWithin(1, time.Hour)}, tr.extra...)...)
+			shouldEqual(t, errors.Is(err, context.DeadlineExceeded), true)
+			shouldEqual(t, errors.As(err, new(sup.ErrSendTimeout)), false)
+		})
+	}
+	t.Run("another case should still win if ready first", func(t *testing.T) {
+		tx, _ := sup.NewChannel[int]("out", 0)
+		idx, _, _, err := sup.SelectValue(ctx, tx.SendWithin(1, time.Hour), sup.After(time.Millisecond, nil))
+		shouldEqual(t, err, nil)
+		shouldEqual(t, idx, 1)
+	})
+	t.Run("the timeout should be reported with index -1", func(t *testing.T) {
+		tx, _ := sup.NewChannel[int]("out", 0)
+		idx, _, _, err := sup.SelectValue(ctx, tx.SendWithin(1, time.Millisecond), sup.After(time.Hour, nil))
+		shouldEqual(t, errors.As(err, new(sup.ErrSendTimeout)), true)
+		shouldEqual(t, idx, -1)
+	})
+}
+
+func TestSendTimeout(t *testing.T) {
+	ctx := context.Background()
+	ch := make(chan int)
+	err := sup.SendTimeout(ctx, ch, 1, time.Millisecond)
+	shouldEqual(t, errors.As(err, new(sup.ErrSendTimeout)), true)
+
+	ctx, cancel := context.WithCancel(ctx)
+	cancel()
+	err = sup.SendTimeout(ctx, ch, 1, time.Hour)
+	shouldEqual(t, errors.Is(err, context.Canceled), true)
+
+	close(ch)
+	err = sup.SendTimeout(context.Background(), ch, 1, time.Hour)
+	shouldEqual(t, errors.Is(err, sup.ErrClosed), true)
+}
@@ -0,0 +1,60 @@
+package sup
+
+import (
+	"context"
+	"fmt"
+)
+
+// ErrDrainInterrupted is returned by ReceiverChannel.Drain when its context
+// was done before the channel was closed and emptied.
+//
+// It wraps the context's error, so errors.Is(err, context.Canceled) (or
+// context.DeadlineExceeded) works as usual.
+type ErrDrainInterrupted struct {
+	ChannelName string // the channel's name, or a description of it if it has no name.
+	Remaining   int    // how many messages were still in the buffer, left unprocessed.
+	Err         error  // the context's error.
+}
+
+func (e ErrDrainInterrupted) Error() string {
+	return fmt.Sprintf("drain of channel %q interrupted with %d messages left: %v", e.ChannelName, e.Remaining, e.Err)
+}
+
+func (e ErrDrainInterrupted) Unwrap() error {
+	return e.Err
+}
+
+// Drain receives every remaining message from the channel, calling the
+// callback for each (if it's not nil), until the channel is closed and
+// empty.  This is the usual tail of a consumer whose producer has closed
+// its outbox to say "that's all": the buffered messages still get handled.
+//
+// Drain returns nil once the channel is closed and empty, or the callback's
+// error as soon as it returns one.  If the context is done first, Drain
+// stops, and returns an ErrDrainInterrupted saying how many messages were
+// left behind.  Cancellation is checked before every message, so it's
+// honored promptly even if the buffer is full.
+//
+// Note that Drain waits for the channel to be closed: if the producer never
+// closes it, only the context ends the drain.
+func (c ReceiverChannel[T]) Drain(ctx context.Context, cb func(T) error) error {
+	for {
+		if ctx.Err() != nil {
+			return ErrDrainInterrupted{c.label(), len(c.Chan), ctx.Err()}
+		}
+		select {
+		case v, ok := <-c.Chan:
+			if !ok {
+				return nil
+			}
+			if cb == nil {
+				continue
+			}
+			if err := cb(v); err != nil {
+				return err
+			}
+		case <-ctx.Done():
+			return ErrDrainInterrupted{c.label(), len(c.Chan), ctx.Err()}
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package sup_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/warpfork/go-sup"
+)
+
+func TestDrain(t *testing.T) {
+	ctx := context.Background()
+	t.Run("should process everything buffered, then stop at close", func(t *testing.T) {
+		tx, rx := sup.NewChannel[int]("inbox", 5)
+		for i := 0; i < 3; i++ {
+			tx.TrySend(i)
+		}
+		tx.Close()
+		var got []int
+		err := rx.Drain(ctx, func(v int) error { got = append(got, v); return nil })
+		shouldEqual(t, err, nil)
+		shouldEqual(t, fmt.Sprint(got), "[0 1 2]")
+	})
+	t.Run("should stop at the callback's error", func(t *testing.T) {
+		tx, rx := sup.NewChannel[int]("inbox", 5)
+		for i := 0; i < 3; i++ {
+			tx.TrySend(i)
+		}
+		tx.Close()
+		boom := errors.New("boom")
+		err := rx.Drain(ctx, func(v int) error { return boom })
+		shouldEqual(t, err, boom)
+		n, _ := rx.Depth()
+		shouldEqual(t, n, 2)
+	})
+	t.Run("cancellation should report what was left behind", func(t *testing.T) {
+		tx, rx := sup.NewChannel[int]("inbox", 5)
+		for i := 0; i < 4; i++ {
+			tx.TrySend(i)
+		}
+		ctx, cancel := context.WithCancel(ctx)
+		err := rx.Drain(ctx, func(v int) error {
+			if v == 1 {
+				cancel()
+			}
+			return nil
+		})
+		shouldEqual(t, errors.Is(err, context.Canceled), true)
+		var interrupted sup.ErrDrainInterrupted
+		mustEqual(t, errors.As(err, &interrupted), true)
+		shouldEqual(t, interrupted.Remaining, 2)
+		shouldEqual(t, interrupted.ChannelName, "inbox")
+	})
+}
@@ -0,0 +1,107 @@
+package sup
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// SetOverdueReaction returns a Selectable which behaves exactly like the
+// given one, but if a Select it's part of hasn't proceeded within the given
+// duration, the reaction callback is called.  The Select keeps waiting
+// regardless: this is for noticing and logging slow sends and receives,
+// not for giving up on them (use After for that).
+//
+// The reaction is called at most once per Select, on a timer goroutine
+// (not the one running Select), so it should be safe for concurrent use.
+// Deadlines are imprecise: the reactions for several cases in one Select
+// share a timer, and may be called a little late, or (if the Select proceeds
+// just as the deadline passes) even shortly after Select has returned.
+//
+// If the reaction is nil, the default reaction is used: if the context given
+// to Select belongs to a task launched by a supervisor, an overdue warning
+// is sent to that supervisor's warning handler; otherwise, nothing happens.
+func SetOverdueReaction(s Selectable, after time.Duration, react func()) Selectable {
+	return overdueCase{s, after, react}
+}
+
+type overdueCase struct {
+	Selectable
+	after time.Duration
+	react func()
+}
+
+func (oc overdueCase) unwrap() Selectable { return oc.Selectable }
+
+// overdueWatch fires the overdue reactions for one Select.
+type overdueWatch struct {
+	mu        sync.Mutex
+	start     time.Time
+	reactions []overdueCase // sorted by deadline; the ones already fired are sliced off the front.
+	timer     *time.Timer
+	done      bool
+}
+
+// watchOverdue starts the timer for the earliest deadline among the given
+// cases.  The caller should call stop when Select returns.  ctx info is
+// used to build the default reaction.
+func watchOverdue(info ctxInfo, reactions []overdueCase) *overdueWatch {
+	w := &overdueWatch{start: time.Now(), reactions: reactions}
+	for i, r := range reactions {
+		if r.react == nil {
+			reactions[i].react = w.defaultReaction(info, r.Selectable)
+		}
+	}
+	sort.SliceStable(reactions, func(i, j int) bool { return reactions[i].after < reactions[j].after })
+	w.mu.Lock() // so the timer can't fire before we've stored it.
+	defer w.mu.Unlock()
+	w.timer = time.AfterFunc(reactions[0].after, w.fire)
+	return w
+}
+
+func (w *overdueWatch) fire() {
+	w.mu.Lock()
+	if w.done {
+		w.mu.Unlock()
+		return
+	}
+	elapsed := time.Since(w.start)
+	var due []overdueCase
+	for len(w.reactions) > 0 && w.reactions[0].after <= elapsed {
+		due = append(due, w.reactions[0])
+		w.reactions = w.reactions[1:]
+	}
+	if len(w.reactions) > 0 {
+		w.timer.Reset(w.reactions[0].after - elapsed)
+	}
+	w.mu.Unlock()
+	for _, r := range due {
+		r.react()
+	}
+}
+
+func (w *overdueWatch) stop() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.done = true
+	w.timer.Stop()
+}
+
+// defaultReaction makes the reaction for cases that weren't given one,
+// which warns the supervisor (if there is one).  The message is like:
+//
+//	send on channel "pinger→ponger" blocked 2.3s in task main/pinger
+func (w *overdueWatch) defaultReaction(info ctxInfo, s Selectable) func() {
+	if info.cfg == nil {
+		return func() {}
+	}
+	what := fmt.Sprintf("select case %q", s.Name())
+	if op, ok := unwrapSelectable(s).(interface{ selectOp() string }); ok {
+		what = fmt.Sprintf("%s on channel %q", op.selectOp(), s.Name())
+	}
+	return func() {
+		blocked := time.Since(w.start).Round(time.Millisecond)
+		info.warn(WarningKind_overdue, fmt.Sprintf("%s blocked %v in task %s", what, blocked, info.path))
+	}
+}
@@ -0,0 +1,84 @@
+package sup_test
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/warpfork/go-sup"
+)
+
+func TestSetOverdueReaction(t *testing.T) {
+	t.Run("should fire once, and the select should keep waiting", func(t *testing.T) {
+		ch := make(chan int)
+		var mu sync.Mutex
+		var fired []string
+		react := func(what string) func() {
+			return func() {
+				mu.Lock()
+				fired = append(fired, what)
+				mu.Unlock()
+				if what == "slow" {
+					ch <- 1 // unblock the select, now both have fired.
+				}
+			}
+		}
+		rx := sup.ReceiverChannel[int]{Chan: ch}
+		err := sup.Select(context.Background(),
+			sup.SetOverdueReaction(rx.RecvAndThen(nil), 10*time.Millisecond, react("slow")),
+			sup.SetOverdueReaction(sup.ReceiverChannel[int]{}.RecvAndThen(nil), time.Millisecond, react("fast")),
+		)
+		shouldEqual(t, err, nil)
+		mu.Lock()
+		defer mu.Unlock()
+		mustEqual(t, len(fired), 2)
+		shouldEqual(t, fired[0], "fast")
+		shouldEqual(t, fired[1], "slow")
+	})
+	t.Run("should not fire if the select proceeds in time", func(t *testing.T) {
+		ch := make(chan int, 1)
+		ch <- 1
+		err := sup.Select(context.Background(),
+			sup.SetOverdueReaction(sup.ReceiverChannel[int]{Chan: ch}.RecvAndThen(nil), 5*time.Millisecond, func() {
+				t.Errorf("should not fire")
+			}),
+		)
+		shouldEqual(t, err, nil)
+		time.Sleep(10 * time.Millisecond)
+	})
+	t.Run("default reaction should warn via the supervisor", func(t *testing.T) {
+		var mu sync.Mutex
+		var warnings []sup.SupervisionWarning
+		tx, rx := sup.NewChannel[int]("inbox", 0)
+		sup.SuperviseRoot(context.Background(),
+			sup.SuperviseForkJoin("main",
+				[]sup.Task{
+					namedFunc{"sender", func(ctx context.Context) error {
+						return sup.Select(ctx, sup.SetOverdueReaction(tx.SendAndThen(1, nil), time.Millisecond, nil))
+					}},
+					namedFunc{"receiver", func(ctx context.Context) error {
+						time.Sleep(20 * time.Millisecond)
+						_, _, err := sup.Recv(ctx, rx.Chan)
+						return err
+					}},
+				},
+				sup.SetWarningHandler(func(w sup.SupervisionWarning) {
+					mu.Lock()
+					defer mu.Unlock()
+					warnings = append(warnings, w)
+				}),
+			),
+		)
+		mu.Lock()
+		defer mu.Unlock()
+		mustEqual(t, len(warnings), 1)
+		shouldEqual(t, warnings[0].Kind, sup.WarningKind_overdue)
+		shouldEqual(t, warnings[0].TaskPath, "main/sender")
+		shouldEqual(t, warnings[0].SupervisorPath, "main")
+		if msg := warnings[0].Message; !strings.HasPrefix(msg, `send on channel "inbox" blocked `) || !strings.HasSuffix(msg, "ms in task main/sender") {
+			t.Errorf("unexpected message: %q", msg)
+		}
+	})
+}
@@ -0,0 +1,71 @@
+package sup
+
+import (
+	"context"
+	"reflect"
+)
+
+// Prioritized returns a marker Selectable which makes a Select choose among
+// ready cases in argument order, rather than at random: if the first case
+// and the third case are both ready, the first one proceeds.  Pass it as
+// the first argument, by convention; it never proceeds itself, but it does
+// count when SelectValue reports the chosen case's position.
+//
+// Before blocking, Select first checks whether the context is done (which
+// takes precedence over everything), and then tries each case in turn,
+// without waiting.  If none of them is ready, Select waits as usual, and
+// takes whichever becomes ready first.  (If several become ready at the very
+// same moment, the choice among those is random again: priority is only
+// guaranteed among the cases that were already ready when Select was
+// called.)  A Default case is tried last, as you'd expect.
+//
+// Beware of starvation: a high-priority case which is always ready will
+// always win, and the cases after it will never proceed at all.  This is
+// for things like "prefer the control channel over the data channel",
+// where the preferred case is only occasionally ready.
+//
+// Prioritized selects are a little slower, since they make a pass over the
+// cases before the real select.
+func Prioritized() Selectable {
+	return prioritizedCase{}
+}
+
+type prioritizedCase struct{}
+
+func (prioritizedCase) Name() string {
+	return "prioritized"
+}
+
+func (prioritizedCase) selectCase() reflect.SelectCase {
+	return reflect.SelectCase{Dir: reflect.SelectRecv} // zero Chan: reflect.Select ignores the case.
+}
+
+func (prioritizedCase) fire(reflect.Value, bool) error {
+	panic("unreachable: prioritizedCase never proceeds")
+}
+
+// tryInOrder is the first pass of a prioritized select: it tries each case,
+// in argument order, without blocking.  It returns the index of the first
+// which proceeded (len(plan.selectables) meaning the context, if it's
+// already done), or -1 if none could.  Callbacks aren't called.
+func (plan *selectPlan) tryInOrder(ctx context.Context) (int, reflect.Value, bool, error) {
+	n := len(plan.selectables)
+	if ctx.Err() != nil {
+		return n, reflect.Value{}, false, nil
+	}
+	probe := [2]reflect.SelectCase{1: {Dir: reflect.SelectDefault}}
+	for i, c := range plan.cases[:n] {
+		if c.Dir == reflect.SelectDefault || !c.Chan.IsValid() {
+			continue
+		}
+		probe[0] = c
+		chosen, recv, recvOK, err := reflectSelect(probe[:], plan.selectables[i:i+1])
+		if err != nil {
+			return -1, recv, false, err
+		}
+		if chosen == 0 {
+			return i, recv, recvOK, nil
+		}
+	}
+	return -1, reflect.Value{}, false, nil
+}
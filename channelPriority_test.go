@@ -0,0 +1,65 @@
+package sup_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/warpfork/go-sup"
+)
+
+func TestPrioritized(t *testing.T) {
+	ctx := context.Background()
+	t.Run("ready cases should be chosen in argument order", func(t *testing.T) {
+		ctrlTx, ctrlRx := sup.NewChannel[int]("ctrl", 20)
+		dataTx, dataRx := sup.NewChannel[int]("data", 20)
+		for i := 0; i < 20; i++ {
+			ctrlTx.TrySend(i)
+			dataTx.TrySend(i)
+		}
+		var got []string
+		ctrl := ctrlRx.RecvAndThen(func(int) error { got = append(got, "ctrl"); return nil })
+		data := dataRx.RecvAndThen(func(int) error { got = append(got, "data"); return nil })
+		for i := 0; i < 40; i++ {
+			mustEqual(t, sup.Select(ctx, sup.Prioritized(), ctrl, data), nil)
+		}
+		for i, who := range got {
+			if i < 20 {
+				shouldEqual(t, who, "ctrl")
+			} else {
+				shouldEqual(t, who, "data")
+			}
+		}
+	})
+	t.Run("the index should count the marker", func(t *testing.T) {
+		tx, rx := sup.NewChannel[int]("ch", 1)
+		tx.TrySend(1)
+		idx, _, _, err := sup.SelectValue(ctx, sup.Prioritized(), sup.After(time.Hour, nil), rx.RecvAndThen(nil))
+		shouldEqual(t, err, nil)
+		shouldEqual(t, idx, 2)
+	})
+	t.Run("default should only fire when nothing is ready", func(t *testing.T) {
+		tx, rx := sup.NewChannel[int]("ch", 1)
+		var fired string
+		dflt := sup.Default(func() error { fired = "default"; return nil })
+		recv := rx.RecvAndThen(func(int) error { fired = "recv"; return nil })
+		for i := 0; i < 20; i++ {
+			tx.TrySend(1)
+			mustEqual(t, sup.Select(ctx, sup.Prioritized(), dflt, recv), nil)
+			shouldEqual(t, fired, "recv")
+		}
+		mustEqual(t, sup.Select(ctx, sup.Prioritized(), dflt, recv), nil)
+		shouldEqual(t, fired, "default")
+	})
+	t.Run("a done context should take precedence", func(t *testing.T) {
+		tx, rx := sup.NewChannel[int]("ch", 1)
+		tx.TrySend(1)
+		ctx, cancel := context.WithCancel(ctx)
+		cancel()
+		err := sup.Select(ctx, sup.Prioritized(), rx.RecvAndThen(nil))
+		shouldEqual(t, errors.Is(err, context.Canceled), true)
+		n, _ := rx.Depth()
+		shouldEqual(t, n, 1)
+	})
+}
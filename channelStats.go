@@ -0,0 +1,178 @@
+package sup
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ChannelStatsEnabled, if set to true, makes channels created from then on
+// (by NewChannel, WrapSender, or WrapReceiver) record occupancy statistics
+// whenever they're used in a Select: how full the buffer got, and how long
+// Select spent blocked before a send or receive on the channel proceeded.
+// Use ChannelStats to see them.
+//
+// The recording is cheap -- a few atomic adds per operation, and no locks --
+// so it's fine to leave on in production.  It's off by default, though,
+// because every channel made while it's on is remembered for as long as the
+// program runs, which is a leak if you make channels dynamically.
+//
+// Set it before creating the channels you want to watch (e.g. in main).
+// Channels made while it was off are never recorded.
+var ChannelStatsEnabled bool
+
+// ChannelStat is the statistics for one channel name (see ChannelStats).
+type ChannelStat struct {
+	Cap       int           // capacity of the channel.  (If several channels share the name, the largest.)
+	HighWater int           // the most messages seen in the buffer.
+	Ops       uint64        // how many sends and receives have completed.
+	Blocked   time.Duration // total time that Selects spent waiting before those operations proceeded.
+}
+
+// ChannelStats returns the statistics for every channel recorded since
+// ChannelStatsEnabled was set, keyed by channel name.  Channels with the same
+// name are combined.
+func ChannelStats() map[string]ChannelStat {
+	channelStatsRegistry.mu.Lock()
+	defer channelStatsRegistry.mu.Unlock()
+	m := make(map[string]ChannelStat, len(channelStatsRegistry.metas))
+	for _, meta := range channelStatsRegistry.metas {
+		st := m[meta.name]
+		st.Cap = max(st.Cap, meta.stats.cap)
+		st.HighWater = max(st.HighWater, int(meta.stats.highWater.Load()))
+		st.Ops += meta.stats.ops.Load()
+		st.Blocked += time.Duration(meta.stats.blocked.Load())
+		m[meta.name] = st
+	}
+	return m
+}
+
+var channelStatsRegistry struct {
+	mu    sync.Mutex
+	metas []*channelMeta
+}
+
+// channelStats is the recording side; it hangs off channelMeta, and is nil
+// for channels that aren't being recorded.
+type channelStats struct {
+	cap       int
+	highWater atomic.Int64
+	ops       atomic.Uint64
+	blocked   atomic.Int64 // nanoseconds.
+}
+
+// newChannelMeta makes the shared state for a channel's wrappers, and
+// registers it for statistics, if those are enabled.
+func newChannelMeta(name string, capacity int) *channelMeta {
+	meta := &channelMeta{name: name}
+	if ChannelStatsEnabled {
+		meta.stats = &channelStats{cap: capacity}
+		channelStatsRegistry.mu.Lock()
+		channelStatsRegistry.metas = append(channelStatsRegistry.metas, meta)
+		channelStatsRegistry.mu.Unlock()
+	}
+	return meta
+}
+
+func (st *channelStats) record(blocked time.Duration, depth int) {
+	st.ops.Add(1)
+	st.blocked.Add(int64(blocked))
+	for {
+		hw := st.highWater.Load()
+		if int64(depth) <= hw || st.highWater.CompareAndSwap(hw, int64(depth)) {
+			return
+		}
+	}
+}
+
+// sampledSelectable is implemented by Selectables on channels, so that Select
+// can record statistics for them.  stats returns nil if the channel isn't
+// being recorded; depth is how many messages are in the buffer, counting the
+// one just sent or received (call it just after the operation).
+type sampledSelectable interface {
+	stats() *channelStats
+	depth(recvOK bool) int
+}
+
+// Depth returns how many messages are waiting in the channel's buffer,
+// and the buffer's capacity.
+func (c SenderChannel[T]) Depth() (len_, cap_ int) {
+	return len(c.Chan), cap(c.Chan)
+}
+
+// Depth returns how many messages are waiting in the channel's buffer,
+// and the buffer's capacity.
+func (c ReceiverChannel[T]) Depth() (len_, cap_ int) {
+	return len(c.Chan), cap(c.Chan)
+}
+
+func (c SenderChannel[T]) stats() *channelStats {
+	if c.meta == nil {
+		return nil
+	}
+	return c.meta.stats
+}
+
+func (c ReceiverChannel[T]) stats() *channelStats {
+	if c.meta == nil {
+		return nil
+	}
+	return c.meta.stats
+}
+
+func (sc sendCase[T]) stats() *channelStats      { return sc.c.stats() }
+func (sc sendCase[T]) depth(bool) int            { return len(sc.c.Chan) }
+func (rc recvCase[T]) stats() *channelStats      { return rc.c.stats() }
+func (ss *SettableSend[T]) stats() *channelStats { return ss.c.stats() }
+func (ss *SettableSend[T]) depth(bool) int       { return len(ss.c.Chan) }
+
+func (rc recvCase[T]) depth(recvOK bool) int { return recvDepth(rc.c.Chan, recvOK) }
+
+func recvDepth[T any](ch <-chan T, recvOK bool) int {
+	n := len(ch)
+	if recvOK && cap(ch) > 0 {
+		n++ // the one we just took was in the buffer too.
+	}
+	return n
+}
+
+// sampledSend is the lone-case send, recording statistics.  It only reads
+// the clock if the send would block, so the usual case costs only the
+// atomic adds.  (The general path, via reflect.Select, always reads it.)
+func sampledSend[T any](ctx context.Context, st *channelStats, ch chan<- T, v T) error {
+	select {
+	case ch <- v:
+		st.record(0, len(ch))
+		return nil
+	default:
+	}
+	start := time.Now()
+	select {
+	case ch <- v:
+		st.record(time.Since(start), len(ch))
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// sampledRecv is the lone-case receive, recording statistics, just like
+// sampledSend.
+func sampledRecv[T any](ctx context.Context, st *channelStats, ch <-chan T) (T, bool, error) {
+	select {
+	case v, ok := <-ch:
+		st.record(0, recvDepth(ch, ok))
+		return v, ok, nil
+	default:
+	}
+	start := time.Now()
+	select {
+	case v, ok := <-ch:
+		st.record(time.Since(start), recvDepth(ch, ok))
+		return v, ok, nil
+	case <-ctx.Done():
+		var zero T
+		return zero, false, ctx.Err()
+	}
+}
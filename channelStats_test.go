@@ -0,0 +1,93 @@
+package sup_test
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/warpfork/go-sup"
+)
+
+func TestChannelDepth(t *testing.T) {
+	tx, rx := sup.NewChannel[int]("depth", 3)
+	tx.TrySend(1)
+	tx.TrySend(2)
+	n, c := tx.Depth()
+	shouldEqual(t, n, 2)
+	shouldEqual(t, c, 3)
+	rx.TryRecv()
+	n, c = rx.Depth()
+	shouldEqual(t, n, 1)
+	shouldEqual(t, c, 3)
+}
+
+// statsName returns a channel name which no other test run has used.
+// ChannelStats merges channels by name, for the life of the process, so
+// with a fixed name, repeat runs (as with -count) would see each other's
+// counts.
+func statsName(t *testing.T) string {
+	return fmt.Sprintf("%s#%d", t.Name(), statsRuns.Add(1))
+}
+
+var statsRuns atomic.Int64
+
+func TestChannelStats(t *testing.T) {
+	ctx := context.Background()
+	t.Run("channels made while disabled should not be recorded", func(t *testing.T) {
+		name := statsName(t)
+		tx, rx := sup.NewChannel[int](name, 1)
+		mustEqual(t, sup.Select(ctx, tx.SendAndThen(1, nil)), nil)
+		mustEqual(t, sup.Select(ctx, rx.RecvAndThen(nil)), nil)
+		_, exists := sup.ChannelStats()[name]
+		shouldEqual(t, exists, false)
+	})
+	t.Run("operations and high water should be recorded", func(t *testing.T) {
+		name := statsName(t)
+		sup.ChannelStatsEnabled = true
+		defer func() { sup.ChannelStatsEnabled = false }()
+		tx, rx := sup.NewChannel[int](name, 4)
+		for i := 0; i < 3; i++ {
+			mustEqual(t, sup.Select(ctx, tx.SendAndThen(i, nil)), nil)
+		}
+		for i := 0; i < 3; i++ {
+			// The extra case forces the general path, so both get exercised.
+			mustEqual(t, sup.Select(ctx, rx.RecvAndThen(nil), sup.After(time.Hour, nil)), nil)
+		}
+		st := sup.ChannelStats()[name]
+		shouldEqual(t, st.Cap, 4)
+		shouldEqual(t, st.HighWater, 3)
+		shouldEqual(t, st.Ops, uint64(6))
+	})
+	t.Run("blocked time should be recorded", func(t *testing.T) {
+		name := statsName(t)
+		sup.ChannelStatsEnabled = true
+		defer func() { sup.ChannelStatsEnabled = false }()
+		tx, rx := sup.NewChannel[int](name, 0)
+		go func() {
+			time.Sleep(5 * time.Millisecond)
+			sup.Send(ctx, tx.Chan, 1)
+		}()
+		mustEqual(t, sup.Select(ctx, rx.RecvAndThen(nil)), nil)
+		st := sup.ChannelStats()[name]
+		shouldEqual(t, st.Ops, uint64(1))
+		shouldEqual(t, st.HighWater, 0)
+		shouldEqual(t, st.Blocked >= 5*time.Millisecond, true)
+	})
+}
+
+// BenchmarkSelectLoneRecvStats is BenchmarkSelectLoneRecv, with statistics
+// being recorded for the channel; the difference is the cost of recording.
+func BenchmarkSelectLoneRecvStats(b *testing.B) {
+	sup.ChannelStatsEnabled = true
+	defer func() { sup.ChannelStatsEnabled = false }()
+	ctx := context.Background()
+	tx, rx := sup.NewChannel[int]("bench", 1)
+	recv := rx.RecvAndThen(nil)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		tx.Chan <- i
+		sup.Select(ctx, recv)
+	}
+}
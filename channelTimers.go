@@ -0,0 +1,81 @@
+package sup
+
+import (
+	"reflect"
+	"time"
+)
+
+// After returns a Selectable which proceeds once the given duration has
+// passed, and then calls the callback (if it's not nil).  The callback's
+// error is returned from Select, so returning nil lets a loop carry on,
+// and returning an error ends it.
+//
+// The clock starts when Select is called, not when After is called, so one
+// After Selectable can be reused for every Select in a loop, and each Select
+// gets the full duration.  Each Select makes its own timer, and stops it
+// when Select returns, so nothing is left running behind a loop -- unlike
+// using time.After in a native select loop.
+func After(d time.Duration, then func() error) Selectable {
+	return afterCase{d, then}
+}
+
+type afterCase struct {
+	d    time.Duration
+	then func() error
+}
+
+func (ac afterCase) Name() string {
+	return "after " + ac.d.String()
+}
+
+func (ac afterCase) selectCase() reflect.SelectCase {
+	panic("unreachable: afterCase is armed, not selected directly")
+}
+
+func (ac afterCase) arm() (reflect.SelectCase, func()) {
+	timer := time.NewTimer(ac.d)
+	return reflect.SelectCase{
+		Dir:  reflect.SelectRecv,
+		Chan: reflect.ValueOf(timer.C),
+	}, func() { timer.Stop() }
+}
+
+func (ac afterCase) fire(reflect.Value, bool) error {
+	if ac.then == nil {
+		return nil
+	}
+	return ac.then()
+}
+
+// Tick returns a Selectable which proceeds when the ticker ticks, and then
+// calls the callback (if it's not nil).  The callback's error is returned
+// from Select.
+//
+// The ticker is yours: Select never stops it, so you should, when you're
+// done with it.
+func Tick(t *time.Ticker, then func() error) Selectable {
+	return tickCase{t, then}
+}
+
+type tickCase struct {
+	t    *time.Ticker
+	then func() error
+}
+
+func (tc tickCase) Name() string {
+	return "tick"
+}
+
+func (tc tickCase) selectCase() reflect.SelectCase {
+	return reflect.SelectCase{
+		Dir:  reflect.SelectRecv,
+		Chan: reflect.ValueOf(tc.t.C),
+	}
+}
+
+func (tc tickCase) fire(reflect.Value, bool) error {
+	if tc.then == nil {
+		return nil
+	}
+	return tc.then()
+}
@@ -0,0 +1,76 @@
+package sup_test
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/warpfork/go-sup"
+)
+
+func TestAfter(t *testing.T) {
+	t.Run("should fire if nothing else happens", func(t *testing.T) {
+		timeout := errors.New("timeout")
+		err := sup.Select(context.Background(),
+			sup.ReceiverChannel[int]{Chan: make(chan int)}.RecvAndThen(nil),
+			sup.After(time.Millisecond, func() error { return timeout }),
+		)
+		shouldEqual(t, err, timeout)
+	})
+	t.Run("should not fire if something else happens first", func(t *testing.T) {
+		ch := make(chan int, 1)
+		ch <- 1
+		err := sup.Select(context.Background(),
+			sup.ReceiverChannel[int]{Chan: ch}.RecvAndThen(nil),
+			sup.After(time.Hour, func() error { t.Errorf("should not fire"); return nil }),
+		)
+		shouldEqual(t, err, nil)
+	})
+	t.Run("should restart its clock for each select", func(t *testing.T) {
+		after := sup.After(5*time.Millisecond, nil)
+		for i := 0; i < 3; i++ {
+			start := time.Now()
+			mustEqual(t, sup.Select(context.Background(), after), nil)
+			if elapsed := time.Since(start); elapsed < 5*time.Millisecond {
+				t.Errorf("select %d returned after only %v", i, elapsed)
+			}
+		}
+	})
+	t.Run("should not accumulate anything across many loops", func(t *testing.T) {
+		ch := make(chan int, 1)
+		rx := sup.ReceiverChannel[int]{Chan: ch}
+		after := sup.After(time.Hour, nil)
+		before := runtime.NumGoroutine()
+		var ms runtime.MemStats
+		runtime.GC()
+		runtime.ReadMemStats(&ms)
+		heapBefore := ms.HeapAlloc
+		for i := 0; i < 10000; i++ {
+			ch <- i
+			mustEqual(t, sup.Select(context.Background(), rx.RecvAndThen(nil), after), nil)
+		}
+		runtime.GC()
+		runtime.ReadMemStats(&ms)
+		// (At most as many as before: goroutines left over from other tests
+		// may have finished meanwhile.)
+		if n := runtime.NumGoroutine(); n > before {
+			t.Errorf("goroutines grew from %d to %d", before, n)
+		}
+		// 10k hour-long timers left running would hold on to well over a megabyte.
+		if ms.HeapAlloc > heapBefore+1<<20 {
+			t.Errorf("heap grew from %d to %d; timers may be leaking", heapBefore, ms.HeapAlloc)
+		}
+	})
+}
+
+func TestTick(t *testing.T) {
+	ticker := time.NewTicker(time.Millisecond)
+	defer ticker.Stop()
+	ticks := 0
+	for ticks < 3 {
+		mustEqual(t, sup.Select(context.Background(), sup.Tick(ticker, func() error { ticks++; return nil })), nil)
+	}
+	shouldEqual(t, ticks, 3)
+}
@@ -0,0 +1,889 @@
+package sup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"runtime"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// ErrClosed is the conventional error to return from a receive callback
+// when it sees its channel has been closed, and that means the loop it's
+// part of is finished.  Code running Select in a loop can check for it with
+// errors.Is, and treat it as a clean end rather than a failure.  (Sends on a
+// closed channel return an ErrChannelClosed, which matches it too.)
+var ErrClosed = errors.New("channel closed")
+
+// ErrAlreadyClosed is returned by SenderChannel.Close if the channel was
+// already closed.
+var ErrAlreadyClosed = errors.New("channel already closed")
+
+// ErrChannelClosed is the error returned when a send is attempted on a
+// closed channel (which, natively, would be a panic).  It's returned by
+// Select (for send cases), Send, and SenderChannel.TrySend.
+//
+// It matches ErrClosed with errors.Is.
+type ErrChannelClosed struct {
+	// ChannelName is the name of the channel (see NewChannel), if known.
+	// If the channel's wrapper has no name, it's a description of the
+	// channel's type and address instead.  (It's empty for Send, which
+	// only has a bare channel to go on.)
+	// When a Select had several send cases, it can't be told which one was
+	// closed; then, this lists all of their names, separated by " or ".
+	ChannelName string
+}
+
+func (e ErrChannelClosed) Error() string {
+	if e.ChannelName == "" {
+		return "send on closed channel"
+	}
+	return fmt.Sprintf("send on closed channel %q", e.ChannelName)
+}
+
+func (e ErrChannelClosed) Is(target error) bool {
+	return target == ErrClosed
+}
+
+// recoverClosedSend turns a send-on-closed-channel panic into an
+// ErrChannelClosed, stored to *err.  Any other panic is re-raised.
+// Call it deferred.
+func recoverClosedSend(err *error, channelName func() string) {
+	r := recover()
+	if r == nil {
+		return
+	}
+	if re, ok := r.(runtime.Error); ok && re.Error() == "send on closed channel" {
+		*err = ErrChannelClosed{channelName()}
+		return
+	}
+	panic(r)
+}
+
+// Selectable is one case of a Select: a send or a receive on some channel,
+// plus what to do when that case is the one that happens.
+//
+// You get Selectables from methods on the channel wrappers, like
+// SenderChannel.SendAndThen and ReceiverChannel.RecvAndThen.
+type Selectable interface {
+	// Name describes the case, for warnings and errors.  For sends and
+	// receives, it's the channel's name (or if the channel has no name,
+	// its type and address); other cases describe themselves ("default").
+	Name() string
+
+	// selectCase returns the case to hand to reflect.Select.
+	selectCase() reflect.SelectCase
+
+	// fire is called when this case was the one chosen.
+	// For receive cases, recv and recvOK are as reflect.Select returned them.
+	// The error returned is what Select returns.
+	fire(recv reflect.Value, recvOK bool) error
+}
+
+// loneSelectable is implemented by Selectables which can do their own native
+// select against the context's Done channel.  Select uses this when such a
+// Selectable is the only case it's given, which saves all the reflection
+// (and all the garbage that comes with it).  It must behave exactly as the
+// general path would.
+type loneSelectable interface {
+	Selectable
+	selectAlone(ctx context.Context) error
+}
+
+// selectableWrapper is implemented by Selectables which decorate another
+// (like the one from SetOverdueReaction).  Select unwraps them to find the
+// case underneath, and collects what they add along the way.
+type selectableWrapper interface {
+	Selectable
+	unwrap() Selectable
+}
+
+// unwrapSelectable strips any wrappers off a Selectable.
+func unwrapSelectable(s Selectable) Selectable {
+	for {
+		w, ok := s.(selectableWrapper)
+		if !ok {
+			return s
+		}
+		s = w.unwrap()
+	}
+}
+
+// armedSelectable is implemented by Selectables which need fresh state for
+// each Select they're used in (a timer, for example).  Select calls arm
+// instead of selectCase, and calls the returned release func when it returns.
+type armedSelectable interface {
+	Selectable
+	arm() (sc reflect.SelectCase, release func())
+}
+
+// SenderChannel wraps the sending end of a channel, and makes Selectables
+// which send on it.
+//
+// Make them with NewChannel, or WrapSender for a channel you already have.
+// (Building one directly around Chan works, but the wrapper then has no name,
+// and no shared state for Close to record in.)
+//
+// The zero value has a nil Chan, and (just like a nil channel in a native
+// select) a send on it will never proceed.
+type SenderChannel[T any] struct {
+	Chan chan<- T
+	meta *channelMeta
+}
+
+// ReceiverChannel wraps the receiving end of a channel, and makes
+// Selectables which receive from it.
+//
+// Make them with NewChannel, or WrapReceiver for a channel you already have.
+//
+// The zero value has a nil Chan, and (just like a nil channel in a native
+// select) a receive on it will never proceed.
+type ReceiverChannel[T any] struct {
+	Chan <-chan T
+	meta *channelMeta
+}
+
+// channelMeta is what the wrappers for the two ends of a channel share
+// (when they were made together, by NewChannel).
+type channelMeta struct {
+	name   string
+	closed atomic.Bool   // set by SenderChannel.Close.
+	stats  *channelStats // nil unless ChannelStatsEnabled was set when the channel was made.
+}
+
+// ForceUnbufferedChannels, if set to true, makes NewChannel ignore the
+// capacity it's asked for, and make every channel unbuffered.  This is meant
+// for tests: code which only works because some channel happened to have
+// room in its buffer will tend to deadlock (or, with Select, stall until
+// cancelled) when this is set, which is much easier to spot than the
+// occasional production hang.
+//
+// Set it before creating any channels (e.g. in TestMain).
+var ForceUnbufferedChannels bool
+
+// NewChannel makes a channel with the given capacity, and returns both ends
+// of it, wrapped.  The wrappers share the one underlying channel, so they
+// can be handed out to different tasks: typically, one gets the sender, and
+// another gets the receiver.
+//
+// The name is carried by both wrappers, and is used to say which channel
+// is concerned in errors and warnings.
+func NewChannel[T any](name string, capacity int) (SenderChannel[T], ReceiverChannel[T]) {
+	if ForceUnbufferedChannels {
+		capacity = 0
+	}
+	ch := make(chan T, capacity)
+	meta := newChannelMeta(name, capacity)
+	return SenderChannel[T]{ch, meta}, ReceiverChannel[T]{ch, meta}
+}
+
+// WrapSender wraps a channel you already have (say, from some other
+// library), so it can be used with Select and the rest, under the given name.
+//
+// Each call makes a wrapper with its own state, so a Close through one
+// WrapSender wrapper isn't known to another made by a separate call
+// (though copies of one wrapper do share it).
+func WrapSender[T any](name string, ch chan<- T) SenderChannel[T] {
+	return SenderChannel[T]{ch, newChannelMeta(name, cap(ch))}
+}
+
+// WrapReceiver wraps a channel you already have (say, from some other
+// library), so it can be used with Select and the rest, under the given name.
+func WrapReceiver[T any](name string, ch <-chan T) ReceiverChannel[T] {
+	return ReceiverChannel[T]{ch, newChannelMeta(name, cap(ch))}
+}
+
+// Name returns the name the channel was made with (see NewChannel).
+// It's empty if the wrapper was built directly around a channel.
+func (c SenderChannel[T]) Name() string {
+	if c.meta == nil {
+		return ""
+	}
+	return c.meta.name
+}
+
+// Name returns the name the channel was made with (see NewChannel).
+// It's empty if the wrapper was built directly around a channel.
+func (c ReceiverChannel[T]) Name() string {
+	if c.meta == nil {
+		return ""
+	}
+	return c.meta.name
+}
+
+// Close closes the channel, if it's not closed already.  If it is, Close
+// returns ErrAlreadyClosed, rather than panicking as a native close would.
+//
+// Closing through a wrapper from NewChannel is also recorded, so that
+// Closed can report it, and so that later sends through that channel's
+// wrappers return ErrChannelClosed without even trying.  For a wrapper
+// built directly around a channel, Close still won't panic on a second
+// close, but there's nowhere to record it, so Closed can't tell.
+func (c SenderChannel[T]) Close() (err error) {
+	if c.meta != nil && !c.meta.closed.CompareAndSwap(false, true) {
+		return ErrAlreadyClosed
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			if re, ok := r.(runtime.Error); ok && re.Error() == "close of closed channel" {
+				err = ErrAlreadyClosed // closed natively, behind our back.
+				return
+			}
+			panic(r)
+		}
+	}()
+	close(c.Chan)
+	return nil
+}
+
+// Closed reports whether the channel has been closed by Close (on any copy
+// of this wrapper, or of the others from the same NewChannel call).
+// It doesn't know about native closes.
+func (c SenderChannel[T]) Closed() bool {
+	return c.meta != nil && c.meta.closed.Load()
+}
+
+// closedErr returns ErrChannelClosed if the channel is known to be closed.
+func (c SenderChannel[T]) closedErr() error {
+	if c.Closed() {
+		return ErrChannelClosed{c.label()}
+	}
+	return nil
+}
+
+// label returns the channel's name, or failing that, a description of it.
+func (c SenderChannel[T]) label() string {
+	if name := c.Name(); name != "" {
+		return name
+	}
+	return fmt.Sprintf("%T(%p)", c.Chan, c.Chan)
+}
+
+// label returns the channel's name, or failing that, a description of it.
+func (c ReceiverChannel[T]) label() string {
+	if name := c.Name(); name != "" {
+		return name
+	}
+	return fmt.Sprintf("%T(%p)", c.Chan, c.Chan)
+}
+
+// SendAndThen returns a Selectable which sends the value, and then calls the
+// callback (if it's not nil).  The callback's error is returned from Select.
+func (c SenderChannel[T]) SendAndThen(v T, then func() error) Selectable {
+	return sendCase[T]{c, v, then}
+}
+
+// RecvAndThen returns a Selectable which receives a value, and then calls the
+// callback with it (if the callback is not nil).  The callback's error is
+// returned from Select.
+//
+// If the channel is closed, the callback is called with the zero value,
+// just as a native receive would give you.  If you need to tell closure
+// apart from a zero value that was sent, use RecvOrClosed instead.
+func (c ReceiverChannel[T]) RecvAndThen(then func(T) error) Selectable {
+	if then == nil {
+		return recvCase[T]{c, nil}
+	}
+	return recvCase[T]{c, func(v T, _ bool) error { return then(v) }}
+}
+
+// RecvOrClosed is like RecvAndThen, but the callback also gets the ok flag a
+// native comma-ok receive would give: true if a value was received, false
+// (with the zero value) if the channel is closed.
+//
+// A closed channel is often the signal to end a loop; returning ErrClosed
+// from the callback in that case is the conventional way to say so.
+func (c ReceiverChannel[T]) RecvOrClosed(then func(v T, ok bool) error) Selectable {
+	return recvCase[T]{c, then}
+}
+
+// TrySend makes a single non-blocking attempt to send the value.
+// It returns nil if the value was sent, or Nonblock if the channel wasn't
+// ready to take it.
+//
+// If the channel is closed, it returns ErrChannelClosed.
+func (c SenderChannel[T]) TrySend(v T) (err error) {
+	if err := c.closedErr(); err != nil {
+		return err
+	}
+	defer recoverClosedSend(&err, c.label)
+	select {
+	case c.Chan <- v:
+		return nil
+	default:
+		return Nonblock
+	}
+}
+
+// TryRecv makes a single non-blocking attempt to receive a value.
+// It returns the value and nil if one was received; the zero value and
+// Nonblock if none was ready; or the zero value and ErrClosed if the channel
+// is closed.
+func (c ReceiverChannel[T]) TryRecv() (T, error) {
+	select {
+	case v, ok := <-c.Chan:
+		if !ok {
+			return v, ErrClosed
+		}
+		return v, nil
+	default:
+		var zero T
+		return zero, Nonblock
+	}
+}
+
+// Default returns a Selectable which makes a Select non-blocking, just like a
+// default clause in a native select: if no other case (including the
+// context's cancellation) can proceed immediately, the callback is called
+// (if it's not nil) and its error returned.
+//
+// Passing more than one Default to a single Select is a usage error, and
+// Select panics.
+func Default(then func() error) Selectable {
+	return defaultCase{then}
+}
+
+type defaultCase struct {
+	then func() error
+}
+
+func (dc defaultCase) Name() string {
+	return "default"
+}
+
+func (dc defaultCase) selectCase() reflect.SelectCase {
+	return reflect.SelectCase{Dir: reflect.SelectDefault}
+}
+
+func (dc defaultCase) selectAlone(ctx context.Context) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+		return dc.fire(reflect.Value{}, false)
+	}
+}
+
+func (dc defaultCase) fire(reflect.Value, bool) error {
+	if dc.then == nil {
+		return nil
+	}
+	return dc.then()
+}
+
+// OnDone returns a Selectable which proceeds when the given context is done,
+// and then calls the callback (if it's not nil) with the context's cause
+// (see context.Cause; usually, that's just its error).  The callback's error
+// is returned from Select.  With a nil callback, the cause itself is
+// returned.
+//
+// This lets a Select respect more than one context: say, a task's own
+// context (given to Select as usual) and a per-request one.  For plain
+// quit channels, use a ReceiverChannel[struct{}] and RecvAndThen instead.
+//
+// An OnDone for the very context given to Select is allowed, and is a way
+// to customize the error Select returns on cancellation: when that context
+// is done, the OnDone callback is called (exactly once) in place of Select
+// returning the context's error.
+func OnDone(ctx context.Context, then func(cause error) error) Selectable {
+	return onDoneCase{ctx, then}
+}
+
+type onDoneCase struct {
+	ctx  context.Context
+	then func(error) error
+}
+
+func (odc onDoneCase) Name() string {
+	return "done"
+}
+
+func (odc onDoneCase) selectCase() reflect.SelectCase {
+	return reflect.SelectCase{
+		Dir:  reflect.SelectRecv,
+		Chan: reflect.ValueOf(odc.ctx.Done()),
+	}
+}
+
+func (odc onDoneCase) selectAlone(ctx context.Context) error {
+	select {
+	case <-odc.ctx.Done():
+	case <-ctx.Done():
+		if ctx.Done() != odc.ctx.Done() {
+			return ctx.Err()
+		}
+	}
+	return odc.fire(reflect.Value{}, false)
+}
+
+func (odc onDoneCase) fire(reflect.Value, bool) error {
+	cause := context.Cause(odc.ctx)
+	if odc.then == nil {
+		return cause
+	}
+	return odc.then(cause)
+}
+
+// OnResolved returns a Selectable which proceeds when the given promise is
+// resolved, and then calls the callback (if it's not nil) with the value.
+// The callback's error is returned from Select.  If the promise is canceled
+// instead, the callback isn't called, and Select returns context.Canceled
+// (as AwaitValue would).
+//
+// This lets a task wait on promises (say, for replies to requests it's
+// sent) in the same Select as its channels, without a goroutine per
+// promise.  Nothing is registered on the promise: the case is its
+// ResolvedCh, and the value is read once that's closed.
+func OnResolved(p Promise, then func(v interface{}) error) Selectable {
+	return resolvedCase{p, then}
+}
+
+type resolvedCase struct {
+	p    Promise
+	then func(interface{}) error
+}
+
+func (rc resolvedCase) Name() string {
+	return "resolved"
+}
+
+func (rc resolvedCase) selectCase() reflect.SelectCase {
+	return reflect.SelectCase{
+		Dir:  reflect.SelectRecv,
+		Chan: reflect.ValueOf(rc.p.ResolvedCh()),
+	}
+}
+
+func (rc resolvedCase) fire(reflect.Value, bool) error {
+	v, ok := rc.p.TryValue()
+	if !ok {
+		return context.Canceled
+	}
+	if rc.then == nil {
+		return nil
+	}
+	return rc.then(v)
+}
+
+type sendCase[T any] struct {
+	c    SenderChannel[T]
+	v    T
+	then func() error
+}
+
+func (sc sendCase[T]) selectCase() reflect.SelectCase {
+	return reflect.SelectCase{
+		Dir:  reflect.SelectSend,
+		Chan: reflect.ValueOf(sc.c.Chan),
+		Send: reflect.ValueOf(&sc.v).Elem(), // via pointer, so interface-typed T with a nil value still works.
+	}
+}
+
+func (sc sendCase[T]) closedErr() error {
+	return sc.c.closedErr()
+}
+
+func (sc sendCase[T]) Name() string {
+	return sc.c.label()
+}
+
+func (sc sendCase[T]) selectOp() string {
+	return "send"
+}
+
+func (sc sendCase[T]) selectAlone(ctx context.Context) (err error) {
+	if err := sc.c.closedErr(); err != nil {
+		return err
+	}
+	defer recoverClosedSend(&err, sc.Name)
+	if st := sc.c.stats(); st != nil {
+		if err := sampledSend(ctx, st, sc.c.Chan, sc.v); err != nil {
+			return err
+		}
+	} else {
+		select {
+		case sc.c.Chan <- sc.v:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	if sc.then == nil {
+		return nil
+	}
+	return sc.then()
+}
+
+func (sc sendCase[T]) fire(reflect.Value, bool) error {
+	if sc.then == nil {
+		return nil
+	}
+	return sc.then()
+}
+
+type recvCase[T any] struct {
+	c    ReceiverChannel[T]
+	then func(T, bool) error
+}
+
+func (rc recvCase[T]) selectCase() reflect.SelectCase {
+	return reflect.SelectCase{
+		Dir:  reflect.SelectRecv,
+		Chan: reflect.ValueOf(rc.c.Chan),
+	}
+}
+
+func (rc recvCase[T]) Name() string {
+	return rc.c.label()
+}
+
+func (rc recvCase[T]) selectOp() string {
+	return "receive"
+}
+
+func (rc recvCase[T]) selectAlone(ctx context.Context) error {
+	if st := rc.c.stats(); st != nil {
+		v, ok, err := sampledRecv(ctx, st, rc.c.Chan)
+		if err != nil || rc.then == nil {
+			return err
+		}
+		return rc.then(v, ok)
+	}
+	select {
+	case v, ok := <-rc.c.Chan:
+		if rc.then == nil {
+			return nil
+		}
+		return rc.then(v, ok)
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (rc recvCase[T]) fire(recv reflect.Value, recvOK bool) error {
+	if rc.then == nil {
+		return nil
+	}
+	var v T
+	if recvOK {
+		v, _ = recv.Interface().(T) // comma-ok, because a nil interface value won't assert.
+	}
+	return rc.then(v, recvOK)
+}
+
+// Send sends the value on the channel, unless the context is done first,
+// in which case it returns the context's error.
+//
+// It's just a two-case native select, so it's cheap (no reflection, and no
+// allocations); prefer it over Select in hot loops where there's only the
+// one channel to deal with.
+//
+// If the channel is closed, Send returns ErrChannelClosed rather than
+// panicking.
+func Send[T any](ctx context.Context, ch chan<- T, v T) (err error) {
+	if StrictSupervision != StrictMode_off {
+		checkSupervised(ctx, "sup.Send")
+	}
+	return send(ctx, ch, v)
+}
+
+// send is Send without the StrictSupervision check, for forwarding done
+// inside go-sup on behalf of a caller who's been checked already.
+func send[T any](ctx context.Context, ch chan<- T, v T) (err error) {
+	defer recoverClosedSend(&err, func() string { return "" })
+	select {
+	case ch <- v:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Recv receives a value from the channel, unless the context is done first,
+// in which case it returns the context's error.  The bool is the ok flag of
+// a native comma-ok receive: false (with the zero value) means the channel
+// is closed.
+//
+// Like Send, this is just a native select, and costs no more than one.
+func Recv[T any](ctx context.Context, ch <-chan T) (T, bool, error) {
+	if StrictSupervision != StrictMode_off {
+		checkSupervised(ctx, "sup.Recv")
+	}
+	return recv(ctx, ch)
+}
+
+// recv is Recv without the StrictSupervision check; see send.
+func recv[T any](ctx context.Context, ch <-chan T) (T, bool, error) {
+	select {
+	case v, ok := <-ch:
+		return v, ok, nil
+	case <-ctx.Done():
+		var zero T
+		return zero, false, ctx.Err()
+	}
+}
+
+// SetFollowup returns a Selectable which behaves exactly like the given one,
+// but when its case is the one a Select chooses, the followup is called
+// after the case's own callback has returned.  The followup gets the
+// Selectable SetFollowup returned (which may be compared with ==, to tell
+// which case it was), so generic instrumentation (timing, counting, logging)
+// can be attached to any case without caring whether it's a send or a receive.
+//
+// Followups are purely observational: they can't change what Select returns,
+// which is still the case's callback's error (whatever it was; the followup
+// runs either way).  They're only called for a case that actually proceeded,
+// so not when the context's cancellation wins, and not when a send fails
+// because its channel is closed.
+//
+// Several followups may be stacked on one Selectable; the innermost runs
+// first.
+func SetFollowup(s Selectable, followup func(Selectable)) Selectable {
+	return &followupCase{s, followup}
+}
+
+type followupCase struct {
+	Selectable
+	followup func(Selectable)
+}
+
+func (fc *followupCase) unwrap() Selectable { return fc.Selectable }
+
+func (fc *followupCase) fire(recv reflect.Value, recvOK bool) error {
+	err := fc.Selectable.fire(recv, recvOK)
+	fc.followup(fc)
+	return err
+}
+
+// Select blocks until one of the given cases can proceed, or the context is
+// done.  It's just like a native select statement, with the context's Done
+// channel as an implicit extra case.
+//
+// If one of the cases proceeds, its callback is called (on this goroutine),
+// and Select returns the callback's error.  If the context is done first,
+// no callback is called, and Select returns the context's error (so,
+// errors.Is(err, context.Canceled) or context.DeadlineExceeded).
+//
+// As with a native select, if several cases are ready at once, one of them
+// is chosen at random (unless Prioritized is given).  With no cases at all,
+// Select simply waits for the context to be done.  Include a Default case to
+// make Select non-blocking.
+//
+// Select is cheapest when it's given a single send or receive case (and
+// that Selectable is made once, outside of any loop): then it's just a native
+// select, with no reflection and no allocations.  Otherwise, it uses
+// reflect.Select, which allocates a little on every call.
+func Select(ctx context.Context, doThese ...Selectable) error {
+	if StrictSupervision != StrictMode_off {
+		checkSupervised(ctx, "sup.Select")
+	}
+	if len(doThese) == 1 {
+		if ls, ok := doThese[0].(loneSelectable); ok {
+			return ls.selectAlone(ctx)
+		}
+	}
+	// The plan gets a copy of the slice: if it kept ours, escape analysis
+	//  would put every caller's variadic slice on the heap, fast path or not.
+	var plan selectPlan
+	plan.init(append([]Selectable(nil), doThese...))
+	return plan.run(ctx)
+}
+
+// selectPlan is the prepared form of a set of Selectables: the wrappers
+// unwrapped, and the reflect.SelectCase slice built (with a slot at the end
+// for the context's Done channel).  Select makes one for each call;
+// a SelectSet keeps one, and runs it repeatedly.
+type selectPlan struct {
+	selectables []Selectable
+	cases       []reflect.SelectCase
+	armed       []armedCase       // cases which must be re-armed for every run.
+	limited     []timeLimitedCase // cases with their own timer, which goes in the case list after the context's.
+	overdue     []overdueCase
+	hasDefault  bool
+	prioritized bool // whether a Prioritized marker was among the cases.
+	sampled     bool // whether any of the cases is on a channel recording statistics.
+}
+
+type armedCase struct {
+	idx int
+	as  armedSelectable
+}
+
+func (plan *selectPlan) init(doThese []Selectable) {
+	plan.selectables = doThese
+	plan.cases = make([]reflect.SelectCase, len(doThese)+1)
+	for i, s := range doThese {
+		for {
+			w, ok := s.(selectableWrapper)
+			if !ok {
+				break
+			}
+			if oc, ok := w.(overdueCase); ok {
+				plan.overdue = append(plan.overdue, oc)
+			}
+			s = w.unwrap()
+		}
+		if ss, ok := s.(sampledSelectable); ok && ss.stats() != nil {
+			plan.sampled = true
+		}
+		if _, ok := s.(prioritizedCase); ok {
+			plan.prioritized = true
+		}
+		if tls, ok := s.(timeLimitedSelectable); ok {
+			plan.limited = append(plan.limited, timeLimitedCase{i, tls})
+		}
+		if as, ok := s.(armedSelectable); ok {
+			plan.armed = append(plan.armed, armedCase{i, as})
+			continue
+		}
+		plan.cases[i] = s.selectCase()
+		if plan.cases[i].Dir == reflect.SelectDefault {
+			if plan.hasDefault {
+				panic("usage: sup.Select given more than one Default case")
+			}
+			plan.hasDefault = true
+		}
+	}
+	plan.cases = append(plan.cases, make([]reflect.SelectCase, len(plan.limited))...)
+}
+
+func (plan *selectPlan) run(ctx context.Context) error {
+	_, _, _, err := plan.runIndexed(ctx)
+	return err
+}
+
+// runIndexed does the select, and calls the chosen case's callback.
+// It returns the chosen index, and what reflect.Select said was received,
+// along with the error.  If the context was done, a send failed on a
+// closed channel, or a send timed out, the index is -1.
+func (plan *selectPlan) runIndexed(ctx context.Context) (int, reflect.Value, bool, error) {
+	for _, ac := range plan.armed {
+		var release func()
+		plan.cases[ac.idx], release = ac.as.arm()
+		defer release()
+	}
+	for _, s := range plan.selectables {
+		if ce, ok := unwrapSelectable(s).(interface{ closedErr() error }); ok {
+			if err := ce.closedErr(); err != nil {
+				return -1, reflect.Value{}, false, err
+			}
+		}
+	}
+	n := len(plan.selectables)
+	plan.cases[n] = reflect.SelectCase{
+		Dir:  reflect.SelectRecv,
+		Chan: reflect.ValueOf(ctx.Done()),
+	}
+	var armedAt time.Time
+	if len(plan.limited) > 0 {
+		armedAt = time.Now()
+	}
+	for j, lc := range plan.limited {
+		timer := time.NewTimer(lc.tls.timeLimit())
+		defer timer.Stop()
+		plan.cases[n+1+j] = reflect.SelectCase{
+			Dir:  reflect.SelectRecv,
+			Chan: reflect.ValueOf(timer.C),
+		}
+	}
+	var start time.Time
+	if plan.sampled {
+		start = time.Now()
+	}
+	chosen, recv, recvOK, err := -1, reflect.Value{}, false, error(nil)
+	if plan.prioritized {
+		chosen, recv, recvOK, err = plan.tryInOrder(ctx)
+		if err != nil {
+			return -1, recv, false, err
+		}
+	}
+	if chosen < 0 {
+		if len(plan.overdue) > 0 && !plan.hasDefault {
+			info, _ := ctx.Value(ctxKey{}).(ctxInfo)
+			// watchOverdue sorts and fills in its slice, so it gets a copy.
+			defer watchOverdue(info, append([]overdueCase(nil), plan.overdue...)).stop()
+		}
+		chosen, recv, recvOK, err = reflectSelect(plan.cases, plan.selectables)
+		if err != nil {
+			return -1, recv, false, err
+		}
+	}
+	if plan.sampled && chosen < n {
+		if ss, ok := unwrapSelectable(plan.selectables[chosen]).(sampledSelectable); ok {
+			if st := ss.stats(); st != nil {
+				st.record(time.Since(start), ss.depth(recvOK))
+			}
+		}
+	}
+	if chosen > n {
+		return -1, recv, false, plan.limited[chosen-n-1].tls.timeoutErr(time.Since(armedAt))
+	}
+	if chosen == n {
+		// If there's an OnDone for this same context, it takes the win.
+		for i, s := range plan.selectables {
+			if odc, ok := unwrapSelectable(s).(onDoneCase); ok && odc.ctx.Done() == ctx.Done() {
+				return i, recv, false, s.fire(recv, false)
+			}
+		}
+		return -1, recv, false, ctx.Err()
+	}
+	return chosen, recv, recvOK, plan.selectables[chosen].fire(recv, recvOK)
+}
+
+// SelectValue is Select, but it also says which case was chosen, and what
+// was received (if it was a receive case), so you can switch on the index
+// yourself instead of writing callbacks.  Cases made with nil callbacks
+// work fine for this.  (If the cases do have callbacks, they're still
+// called, and their error returned, just as from Select.)
+//
+// The index is the position of the chosen case among the arguments.
+// It's -1 if no case proceeded: when the context was done first (and err is
+// the context's error), when a send was on a closed channel (and err is
+// an ErrChannelClosed), or when a SendWithin timed out (and err is an
+// ErrSendTimeout).  recvOK is as for a native comma-ok receive, and
+// is false for send and Default cases.
+//
+// This saves the garbage of callback closures, but it's not free either:
+// the received value is boxed into an interface, which allocates for most
+// types, and SelectValue always uses the reflect.Select path (there's no
+// single-case fast path).  So it pays off mostly for send-heavy selects,
+// or where the values are pointers anyway.
+func SelectValue(ctx context.Context, doThese ...Selectable) (caseIndex int, recvValue interface{}, recvOK bool, err error) {
+	if StrictSupervision != StrictMode_off {
+		checkSupervised(ctx, "sup.SelectValue")
+	}
+	var plan selectPlan
+	plan.init(append([]Selectable(nil), doThese...))
+	chosen, recv, recvOK, err := plan.runIndexed(ctx)
+	if recvOK {
+		recvValue = recv.Interface()
+	}
+	return chosen, recvValue, recvOK, err
+}
+
+// reflectSelect calls reflect.Select, but returns an ErrChannelClosed rather
+// than panicking if one of the send cases is on a closed channel.
+//
+// This covers a channel being closed at any time before the send happens:
+// whether it was already closed when Select was called, or was closed while
+// Select was blocked.  (Though the latter is a race in your program, just as
+// it would be with a native send, and the race detector will say so.)
+// Once a send case has been chosen, the value has been handed over, so a
+// close after that is no concern of the send's.  However,
+// reflect.Select doesn't say which case it was panicking about, so if there
+// are several send cases, the error names all of them.
+func reflectSelect(cases []reflect.SelectCase, doThese []Selectable) (chosen int, recv reflect.Value, recvOK bool, err error) {
+	defer recoverClosedSend(&err, func() string {
+		var names []string
+		for i, s := range doThese {
+			if cases[i].Dir == reflect.SelectSend {
+				names = append(names, s.Name())
+			}
+		}
+		return strings.Join(names, " or ")
+	})
+	chosen, recv, recvOK = reflect.Select(cases)
+	return
+}
@@ -0,0 +1,622 @@
+package sup_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/warpfork/go-sup"
+)
+
+func TestSelect(t *testing.T) {
+	t.Run("zero cases should wait for cancellation", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+		defer cancel()
+		err := sup.Select(ctx)
+		shouldEqual(t, errors.Is(err, context.DeadlineExceeded), true)
+	})
+	t.Run("cancellation should not fire callbacks", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		rx := sup.ReceiverChannel[int]{Chan: make(chan int)}
+		err := sup.Select(ctx, rx.RecvAndThen(func(int) error {
+			t.Errorf("callback should not fire")
+			return nil
+		}))
+		shouldEqual(t, errors.Is(err, context.Canceled), true)
+	})
+	t.Run("receive should deliver the value and return the callback's error", func(t *testing.T) {
+		ch := make(chan string, 1)
+		ch <- "hi"
+		boom := errors.New("boom")
+		var got string
+		err := sup.Select(context.Background(), sup.ReceiverChannel[string]{Chan: ch}.RecvAndThen(func(v string) error {
+			got = v
+			return boom
+		}))
+		shouldEqual(t, got, "hi")
+		shouldEqual(t, err, boom)
+	})
+	t.Run("receive from a closed channel should deliver the zero value", func(t *testing.T) {
+		ch := make(chan int)
+		close(ch)
+		got := -1
+		err := sup.Select(context.Background(), sup.ReceiverChannel[int]{Chan: ch}.RecvAndThen(func(v int) error {
+			got = v
+			return nil
+		}))
+		shouldEqual(t, err, nil)
+		shouldEqual(t, got, 0)
+	})
+	t.Run("send should send, including nil interface values", func(t *testing.T) {
+		ch := make(chan error, 1)
+		fired := false
+		err := sup.Select(context.Background(), sup.SenderChannel[error]{Chan: ch}.SendAndThen(nil, func() error {
+			fired = true
+			return nil
+		}))
+		shouldEqual(t, err, nil)
+		shouldEqual(t, fired, true)
+		shouldEqual(t, len(ch), 1)
+		shouldEqual(t, <-ch, nil)
+	})
+	t.Run("nil callbacks should be fine", func(t *testing.T) {
+		ch := make(chan int, 1)
+		shouldEqual(t, sup.Select(context.Background(), sup.SenderChannel[int]{Chan: ch}.SendAndThen(1, nil)), nil)
+		shouldEqual(t, sup.Select(context.Background(), sup.ReceiverChannel[int]{Chan: ch}.RecvAndThen(nil)), nil)
+	})
+	t.Run("multiple ready cases should each get chosen sometimes", func(t *testing.T) {
+		a := make(chan int, 1)
+		b := make(chan int, 1)
+		counts := map[string]int{}
+		for i := 0; i < 100; i++ {
+			a <- 1
+			b <- 2
+			err := sup.Select(context.Background(),
+				sup.ReceiverChannel[int]{Chan: a}.RecvAndThen(func(int) error { counts["a"]++; return nil }),
+				sup.ReceiverChannel[int]{Chan: b}.RecvAndThen(func(int) error { counts["b"]++; return nil }),
+			)
+			mustEqual(t, err, nil)
+			// drain whichever wasn't chosen.
+			select {
+			case <-a:
+			case <-b:
+			}
+		}
+		shouldEqual(t, counts["a"]+counts["b"], 100)
+		if counts["a"] == 0 || counts["b"] == 0 {
+			t.Errorf("both cases should have been chosen at least once; got %v", counts)
+		}
+	})
+}
+
+func TestRecvOrClosed(t *testing.T) {
+	type result struct {
+		v  int
+		ok bool
+	}
+	recv := func(ctx context.Context, ch chan int) (result, error) {
+		var r result
+		err := sup.Select(ctx, sup.ReceiverChannel[int]{Chan: ch}.RecvOrClosed(func(v int, ok bool) error {
+			r = result{v, ok}
+			if !ok {
+				return sup.ErrClosed
+			}
+			return nil
+		}))
+		return r, err
+	}
+	t.Run("value", func(t *testing.T) {
+		ch := make(chan int, 1)
+		ch <- 0 // a zero value that was really sent.
+		r, err := recv(context.Background(), ch)
+		shouldEqual(t, err, nil)
+		shouldEqual(t, r, result{0, true})
+	})
+	t.Run("closed before select", func(t *testing.T) {
+		ch := make(chan int)
+		close(ch)
+		r, err := recv(context.Background(), ch)
+		shouldEqual(t, errors.Is(err, sup.ErrClosed), true)
+		shouldEqual(t, r, result{0, false})
+	})
+	t.Run("closed during select", func(t *testing.T) {
+		ch := make(chan int)
+		go func() {
+			time.Sleep(time.Millisecond)
+			close(ch)
+		}()
+		r, err := recv(context.Background(), ch)
+		shouldEqual(t, errors.Is(err, sup.ErrClosed), true)
+		shouldEqual(t, r, result{0, false})
+	})
+}
+
+func TestRecvNilInterface(t *testing.T) {
+	ch := make(chan error, 1)
+	ch <- nil
+	var got struct {
+		err error
+		ok  bool
+	}
+	err := sup.Select(context.Background(), sup.ReceiverChannel[error]{Chan: ch}.RecvOrClosed(func(v error, ok bool) error {
+		got.err, got.ok = v, ok
+		return nil
+	}))
+	shouldEqual(t, err, nil)
+	shouldEqual(t, got.err, nil)
+	shouldEqual(t, got.ok, true)
+}
+
+func TestNonblocking(t *testing.T) {
+	t.Run("default should fire when nothing is ready", func(t *testing.T) {
+		fired := false
+		err := sup.Select(context.Background(),
+			sup.ReceiverChannel[int]{Chan: make(chan int)}.RecvAndThen(func(int) error {
+				t.Errorf("receive should not fire")
+				return nil
+			}),
+			sup.Default(func() error { fired = true; return nil }),
+		)
+		shouldEqual(t, err, nil)
+		shouldEqual(t, fired, true)
+	})
+	t.Run("default should not fire when something is ready", func(t *testing.T) {
+		ch := make(chan int, 1)
+		ch <- 1
+		err := sup.Select(context.Background(),
+			sup.ReceiverChannel[int]{Chan: ch}.RecvAndThen(nil),
+			sup.Default(func() error { t.Errorf("default should not fire"); return nil }),
+		)
+		shouldEqual(t, err, nil)
+		shouldEqual(t, len(ch), 0)
+	})
+	t.Run("draining a buffer should be expressible", func(t *testing.T) {
+		ch := make(chan int, 3)
+		ch <- 1
+		ch <- 2
+		ch <- 3
+		var drained []int
+		empty := errors.New("empty")
+		for {
+			err := sup.Select(context.Background(),
+				sup.ReceiverChannel[int]{Chan: ch}.RecvAndThen(func(v int) error { drained = append(drained, v); return nil }),
+				sup.Default(func() error { return empty }),
+			)
+			if err == empty {
+				break
+			}
+			mustEqual(t, err, nil)
+		}
+		shouldEqual(t, fmt.Sprint(drained), "[1 2 3]")
+	})
+	t.Run("two defaults should panic", func(t *testing.T) {
+		defer func() {
+			if recover() == nil {
+				t.Errorf("should have panicked")
+			}
+		}()
+		sup.Select(context.Background(), sup.Default(nil), sup.Default(nil))
+	})
+	t.Run("TrySend", func(t *testing.T) {
+		tx := sup.SenderChannel[int]{Chan: make(chan int, 1)}
+		shouldEqual(t, tx.TrySend(1), nil)
+		shouldEqual(t, tx.TrySend(2), sup.Nonblock)
+	})
+	t.Run("TryRecv", func(t *testing.T) {
+		ch := make(chan int, 1)
+		rx := sup.ReceiverChannel[int]{Chan: ch}
+		v, err := rx.TryRecv()
+		shouldEqual(t, v, 0)
+		shouldEqual(t, err, sup.Nonblock)
+		ch <- 4
+		v, err = rx.TryRecv()
+		shouldEqual(t, v, 4)
+		shouldEqual(t, err, nil)
+		close(ch)
+		_, err = rx.TryRecv()
+		shouldEqual(t, err, sup.ErrClosed)
+	})
+}
+
+func TestSendRecv(t *testing.T) {
+	t.Run("send and receive", func(t *testing.T) {
+		ch := make(chan int, 1)
+		shouldEqual(t, sup.Send(context.Background(), ch, 4), nil)
+		v, ok, err := sup.Recv(context.Background(), ch)
+		shouldEqual(t, v, 4)
+		shouldEqual(t, ok, true)
+		shouldEqual(t, err, nil)
+	})
+	t.Run("receive from closed channel", func(t *testing.T) {
+		ch := make(chan int)
+		close(ch)
+		v, ok, err := sup.Recv(context.Background(), ch)
+		shouldEqual(t, v, 0)
+		shouldEqual(t, ok, false)
+		shouldEqual(t, err, nil)
+	})
+	t.Run("cancellation", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		ch := make(chan int)
+		shouldEqual(t, sup.Send(ctx, ch, 4), context.Canceled)
+		_, ok, err := sup.Recv(ctx, ch)
+		shouldEqual(t, ok, false)
+		shouldEqual(t, err, context.Canceled)
+	})
+	t.Run("should not allocate", func(t *testing.T) {
+		ctx := context.Background()
+		ch := make(chan int, 1)
+		allocs := testing.AllocsPerRun(100, func() {
+			sup.Send(ctx, ch, 1)
+			sup.Recv(ctx, ch)
+		})
+		shouldEqual(t, allocs, 0.0)
+	})
+}
+
+func BenchmarkSendRecv(b *testing.B) {
+	ctx := context.Background()
+	ch := make(chan int, 1)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		sup.Send(ctx, ch, i)
+		sup.Recv(ctx, ch)
+	}
+}
+
+func TestSelectLoneCaseAllocs(t *testing.T) {
+	ctx := context.Background()
+	ch := make(chan int, 1)
+	sum := 0
+	recv := sup.ReceiverChannel[int]{Chan: ch}.RecvAndThen(func(v int) error { sum += v; return nil })
+	allocs := testing.AllocsPerRun(100, func() {
+		ch <- 1
+		sup.Select(ctx, recv)
+	})
+	shouldEqual(t, allocs, 0.0)
+	shouldEqual(t, sum, 101) // AllocsPerRun does one warmup run.
+}
+
+// BenchmarkSelectLoneRecv and BenchmarkSelectGeneralRecv are the same
+// receive loop; the former hits the single-case fast path, and the latter
+// has an extra never-ready case, which forces the reflect.Select path.
+func BenchmarkSelectLoneRecv(b *testing.B) {
+	ctx := context.Background()
+	ch := make(chan int, 1)
+	recv := sup.ReceiverChannel[int]{Chan: ch}.RecvAndThen(nil)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		ch <- i
+		sup.Select(ctx, recv)
+	}
+}
+
+func BenchmarkSelectGeneralRecv(b *testing.B) {
+	ctx := context.Background()
+	ch := make(chan int, 1)
+	recv := sup.ReceiverChannel[int]{Chan: ch}.RecvAndThen(nil)
+	never := sup.ReceiverChannel[int]{}.RecvAndThen(nil)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		ch <- i
+		sup.Select(ctx, recv, never)
+	}
+}
+
+func BenchmarkSelectSendRecv(b *testing.B) {
+	ctx := context.Background()
+	ch := make(chan int, 1)
+	tx := sup.SenderChannel[int]{Chan: ch}
+	rx := sup.ReceiverChannel[int]{Chan: ch}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		sup.Select(ctx, tx.SendAndThen(i, nil))
+		sup.Select(ctx, rx.RecvAndThen(nil))
+	}
+}
+
+func TestNewChannel(t *testing.T) {
+	t.Run("ends should share the channel and name", func(t *testing.T) {
+		tx, rx := sup.NewChannel[int]("inbox", 2)
+		shouldEqual(t, tx.Name(), "inbox")
+		shouldEqual(t, rx.Name(), "inbox")
+		shouldEqual(t, cap(tx.Chan), 2)
+		mustEqual(t, tx.TrySend(4), nil)
+		v, err := rx.TryRecv()
+		shouldEqual(t, v, 4)
+		shouldEqual(t, err, nil)
+	})
+	t.Run("forcing unbuffered", func(t *testing.T) {
+		sup.ForceUnbufferedChannels = true
+		defer func() { sup.ForceUnbufferedChannels = false }()
+		tx, _ := sup.NewChannel[int]("inbox", 2)
+		shouldEqual(t, cap(tx.Chan), 0)
+		shouldEqual(t, tx.TrySend(4), sup.Nonblock) // nobody's receiving.
+	})
+}
+
+func TestSendOnClosed(t *testing.T) {
+	t.Run("select, closed before", func(t *testing.T) {
+		tx, _ := sup.NewChannel[int]("outbox", 0)
+		close(tx.Chan)
+		err := sup.Select(context.Background(), tx.SendAndThen(1, func() error {
+			t.Errorf("callback should not fire")
+			return nil
+		}))
+		shouldEqual(t, err, sup.ErrChannelClosed{ChannelName: "outbox"})
+		shouldEqual(t, errors.Is(err, sup.ErrClosed), true)
+		shouldEqual(t, err.Error(), `send on closed channel "outbox"`)
+	})
+	t.Run("select with several sends", func(t *testing.T) {
+		tx1, _ := sup.NewChannel[int]("one", 0)
+		tx2, _ := sup.NewChannel[int]("two", 0)
+		close(tx2.Chan)
+		err := sup.Select(context.Background(), tx1.SendAndThen(1, nil), tx2.SendAndThen(2, nil))
+		shouldEqual(t, err, sup.ErrChannelClosed{ChannelName: "one or two"})
+	})
+	t.Run("Send", func(t *testing.T) {
+		ch := make(chan int)
+		close(ch)
+		err := sup.Send(context.Background(), ch, 1)
+		shouldEqual(t, err, sup.ErrChannelClosed{})
+		shouldEqual(t, err.Error(), "send on closed channel")
+	})
+	t.Run("TrySend", func(t *testing.T) {
+		tx, _ := sup.NewChannel[int]("outbox", 0)
+		close(tx.Chan)
+		shouldEqual(t, tx.TrySend(1), sup.ErrChannelClosed{ChannelName: "outbox"})
+	})
+	t.Run("other panics should not be swallowed", func(t *testing.T) {
+		defer func() {
+			if recover() == nil {
+				t.Errorf("should have panicked")
+			}
+		}()
+		ch := make(chan int)
+		sup.Select(context.Background(), // a nil channel in a Send case panics differently.
+			sup.SenderChannel[int]{Chan: ch}.SendAndThen(1, nil),
+			sup.ReceiverChannel[int]{Chan: ch}.RecvAndThen(nil),
+			sup.Default(func() error { panic("boom") }),
+		)
+	})
+}
+
+func TestSetFollowup(t *testing.T) {
+	t.Run("should run after the callback, and not change the error", func(t *testing.T) {
+		ch := make(chan int, 1)
+		ch <- 1
+		boom := errors.New("boom")
+		var order []string
+		var followed sup.Selectable
+		s := sup.SetFollowup(sup.ReceiverChannel[int]{Chan: ch}.RecvAndThen(func(int) error {
+			order = append(order, "callback")
+			return boom
+		}), func(s sup.Selectable) {
+			order = append(order, "followup")
+			followed = s
+		})
+		err := sup.Select(context.Background(), s)
+		shouldEqual(t, err, boom)
+		shouldEqual(t, fmt.Sprint(order), "[callback followup]")
+		shouldEqual(t, followed, s)
+	})
+	t.Run("should stack, innermost first", func(t *testing.T) {
+		var order []string
+		s := sup.Default(nil)
+		s = sup.SetFollowup(s, func(sup.Selectable) { order = append(order, "inner") })
+		s = sup.SetFollowup(s, func(sup.Selectable) { order = append(order, "outer") })
+		mustEqual(t, sup.Select(context.Background(), s), nil)
+		shouldEqual(t, fmt.Sprint(order), "[inner outer]")
+	})
+	t.Run("should not run on cancellation", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		err := sup.Select(ctx, sup.SetFollowup(sup.ReceiverChannel[int]{}.RecvAndThen(nil), func(sup.Selectable) {
+			t.Errorf("followup should not run")
+		}))
+		shouldEqual(t, err, context.Canceled)
+	})
+	t.Run("should not run when the send failed", func(t *testing.T) {
+		ch := make(chan int)
+		close(ch)
+		err := sup.Select(context.Background(), sup.SetFollowup(sup.SenderChannel[int]{Chan: ch}.SendAndThen(1, nil), func(sup.Selectable) {
+			t.Errorf("followup should not run")
+		}))
+		shouldEqual(t, errors.Is(err, sup.ErrClosed), true)
+	})
+}
+
+func TestSelectValue(t *testing.T) {
+	t.Run("receive", func(t *testing.T) {
+		a := sup.ReceiverChannel[int]{Chan: make(chan int)}
+		bCh := make(chan string, 1)
+		bCh <- "hi"
+		idx, v, ok, err := sup.SelectValue(context.Background(), a.RecvAndThen(nil), sup.ReceiverChannel[string]{Chan: bCh}.RecvAndThen(nil))
+		shouldEqual(t, idx, 1)
+		shouldEqual(t, v, "hi")
+		shouldEqual(t, ok, true)
+		shouldEqual(t, err, nil)
+	})
+	t.Run("closed receive", func(t *testing.T) {
+		ch := make(chan int)
+		close(ch)
+		idx, v, ok, err := sup.SelectValue(context.Background(), sup.ReceiverChannel[int]{Chan: ch}.RecvAndThen(nil))
+		shouldEqual(t, idx, 0)
+		shouldEqual(t, v, nil)
+		shouldEqual(t, ok, false)
+		shouldEqual(t, err, nil)
+	})
+	t.Run("send and default", func(t *testing.T) {
+		ch := make(chan int, 1)
+		tx := sup.SenderChannel[int]{Chan: ch}
+		idx, _, _, err := sup.SelectValue(context.Background(), sup.Default(nil), tx.SendAndThen(1, nil))
+		shouldEqual(t, idx, 1)
+		shouldEqual(t, err, nil)
+		idx, _, ok, err := sup.SelectValue(context.Background(), sup.Default(nil), tx.SendAndThen(2, nil))
+		shouldEqual(t, idx, 0)
+		shouldEqual(t, ok, false)
+		shouldEqual(t, err, nil)
+	})
+	t.Run("cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		idx, _, _, err := sup.SelectValue(ctx, sup.ReceiverChannel[int]{}.RecvAndThen(nil))
+		shouldEqual(t, idx, -1)
+		shouldEqual(t, err, context.Canceled)
+	})
+	t.Run("closed send", func(t *testing.T) {
+		tx, _ := sup.NewChannel[int]("out", 0)
+		close(tx.Chan)
+		idx, _, _, err := sup.SelectValue(context.Background(), tx.SendAndThen(1, nil))
+		shouldEqual(t, idx, -1)
+		shouldEqual(t, err, sup.ErrChannelClosed{ChannelName: "out"})
+	})
+}
+
+func TestOnDone(t *testing.T) {
+	t.Run("a second context should be able to end the select", func(t *testing.T) {
+		reqCtx, cancel := context.WithCancelCause(context.Background())
+		quit := errors.New("request abandoned")
+		cancel(quit)
+		var got error
+		err := sup.Select(context.Background(),
+			sup.ReceiverChannel[int]{}.RecvAndThen(nil),
+			sup.OnDone(reqCtx, func(cause error) error { got = cause; return cause }),
+		)
+		shouldEqual(t, got, quit)
+		shouldEqual(t, err, quit)
+	})
+	t.Run("nil callback should return the cause", func(t *testing.T) {
+		reqCtx, cancel := context.WithCancel(context.Background())
+		cancel()
+		shouldEqual(t, sup.Select(context.Background(), sup.OnDone(reqCtx, nil)), context.Canceled)
+	})
+	t.Run("the same context as select's should fire exactly once", func(t *testing.T) {
+		for _, extra := range []int{0, 1} { // with and without another case (so, fast path and general path).
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			custom := errors.New("custom")
+			fired := 0
+			cases := []sup.Selectable{sup.OnDone(ctx, func(error) error { fired++; return custom })}
+			if extra > 0 {
+				cases = append(cases, sup.ReceiverChannel[int]{}.RecvAndThen(nil))
+			}
+			for i := 0; i < 20; i++ { // the implicit case and the explicit one are both ready, so try a few times.
+				mustEqual(t, sup.Select(ctx, cases...), custom)
+			}
+			shouldEqual(t, fired, 20)
+		}
+	})
+}
+
+func TestOnResolved(t *testing.T) {
+	t.Run("the callback should get the resolved value", func(t *testing.T) {
+		p := sup.NewPromise()
+		go p.Resolve(7)
+		var got interface{}
+		err := sup.Select(context.Background(),
+			sup.ReceiverChannel[int]{}.RecvAndThen(nil),
+			sup.OnResolved(p, func(v interface{}) error { got = v; return nil }),
+		)
+		shouldEqual(t, err, nil)
+		shouldEqual(t, got, 7)
+	})
+	t.Run("a nil value should still be delivered", func(t *testing.T) {
+		p := sup.NewPromise()
+		p.Resolve(nil)
+		called := false
+		mustEqual(t, sup.Select(context.Background(), sup.OnResolved(p, func(v interface{}) error {
+			called = v == nil
+			return nil
+		})), nil)
+		shouldEqual(t, called, true)
+	})
+	t.Run("a canceled promise should return context.Canceled", func(t *testing.T) {
+		p := sup.NewPromise()
+		p.Cancel()
+		err := sup.Select(context.Background(), sup.OnResolved(p, func(interface{}) error {
+			t.Error("callback should not be called")
+			return nil
+		}))
+		shouldEqual(t, err, context.Canceled)
+	})
+	t.Run("an unresolved promise should wait", func(t *testing.T) {
+		err := sup.Select(context.Background(),
+			sup.OnResolved(sup.NewPromise(), nil),
+			sup.Default(nil),
+		)
+		shouldEqual(t, err, nil)
+	})
+}
+
+func TestSenderChannelClose(t *testing.T) {
+	t.Run("should close once, then report", func(t *testing.T) {
+		tx, rx := sup.NewChannel[int]("out", 0)
+		shouldEqual(t, tx.Closed(), false)
+		shouldEqual(t, tx.Close(), nil)
+		shouldEqual(t, tx.Closed(), true)
+		shouldEqual(t, tx.Close(), sup.ErrAlreadyClosed)
+		_, err := rx.TryRecv()
+		shouldEqual(t, err, sup.ErrClosed)
+	})
+	t.Run("copies should share the closed state", func(t *testing.T) {
+		tx, _ := sup.NewChannel[int]("out", 0)
+		tx2 := tx
+		mustEqual(t, tx2.Close(), nil)
+		shouldEqual(t, tx.Closed(), true)
+	})
+	t.Run("later sends should fail without panicking, even with other cases ready", func(t *testing.T) {
+		tx, _ := sup.NewChannel[int]("out", 1)
+		mustEqual(t, tx.Close(), nil)
+		ready := make(chan int, 1)
+		ready <- 1
+		want := sup.ErrChannelClosed{ChannelName: "out"}
+		shouldEqual(t, sup.Select(context.Background(), tx.SendAndThen(1, nil)), want)
+		shouldEqual(t, sup.Select(context.Background(), tx.SendAndThen(1, nil), sup.ReceiverChannel[int]{Chan: ready}.RecvAndThen(nil)), want)
+		shouldEqual(t, len(ready), 1)
+		shouldEqual(t, tx.TrySend(1), want)
+		shouldEqual(t, sup.NewSelectSet(tx.SettableSend(nil)).Wait(context.Background()), want)
+	})
+	t.Run("a natively closed channel should report consistently", func(t *testing.T) {
+		tx, _ := sup.NewChannel[int]("out", 0)
+		close(tx.Chan)
+		shouldEqual(t, tx.Close(), sup.ErrAlreadyClosed)
+		raw := sup.SenderChannel[int]{Chan: make(chan int)}
+		shouldEqual(t, raw.Close(), nil)
+		shouldEqual(t, raw.Close(), sup.ErrAlreadyClosed)
+		shouldEqual(t, raw.Closed(), false) // nowhere to record it.
+	})
+}
+
+func TestWrap(t *testing.T) {
+	ch := make(chan int, 1)
+	tx := sup.WrapSender("theirs", ch)
+	rx := sup.WrapReceiver("theirs", ch)
+	shouldEqual(t, tx.Name(), "theirs")
+	shouldEqual(t, rx.Name(), "theirs")
+	mustEqual(t, sup.Select(context.Background(), tx.SendAndThen(1, nil)), nil)
+	v, err := rx.TryRecv()
+	shouldEqual(t, v, 1)
+	shouldEqual(t, err, nil)
+	mustEqual(t, tx.Close(), nil)
+	shouldEqual(t, tx.Closed(), true)
+	shouldEqual(t, tx.TrySend(2), sup.ErrChannelClosed{ChannelName: "theirs"})
+}
+
+func TestSelectableNames(t *testing.T) {
+	tx, rx := sup.NewChannel[int]("pinger→ponger", 0)
+	shouldEqual(t, tx.SendAndThen(1, nil).Name(), "pinger→ponger")
+	shouldEqual(t, rx.RecvAndThen(nil).Name(), "pinger→ponger")
+	shouldEqual(t, tx.SettableSend(nil).Name(), "pinger→ponger")
+	shouldEqual(t, sup.SetFollowup(rx.RecvAndThen(nil), func(sup.Selectable) {}).Name(), "pinger→ponger")
+	shouldEqual(t, sup.Default(nil).Name(), "default")
+	shouldEqual(t, sup.After(time.Second, nil).Name(), "after 1s")
+
+	ch := make(chan int)
+	shouldEqual(t, sup.SenderChannel[int]{Chan: ch}.SendAndThen(1, nil).Name(), fmt.Sprintf("chan<- int(%p)", ch))
+	shouldEqual(t, sup.ReceiverChannel[int]{Chan: ch}.RecvAndThen(nil).Name(), fmt.Sprintf("<-chan int(%p)", ch))
+}
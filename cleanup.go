@@ -0,0 +1,45 @@
+package sup
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+// WithCleanup wraps a task so that the given closers are closed when the
+// task's Run returns, however it returns: with or without an error, or by
+// panicking.  They're closed in reverse order (as with defers), and any
+// errors from them are joined onto the task's error (see errors.Join).  If
+// the task panicked, the closers are still closed, before the panic goes on
+// to the supervisor, but their errors are lost.
+//
+// If the task is a NamedTask, the wrapper is too, with the same name.
+func WithCleanup(t Task, closers ...io.Closer) Task {
+	fns := make([]func() error, len(closers))
+	for i, c := range closers {
+		fns[i] = c.Close
+	}
+	return WithCleanupFunc(t, fns...)
+}
+
+// WithCleanupFunc is WithCleanup, with cleanup functions rather than
+// closers.
+func WithCleanupFunc(t Task, cleanups ...func() error) Task {
+	return keepName(t, cleanupTask{t, cleanups})
+}
+
+type cleanupTask struct {
+	task     Task
+	cleanups []func() error
+}
+
+func (t cleanupTask) Run(ctx context.Context) (err error) {
+	defer func() {
+		errs := []error{err}
+		for i := len(t.cleanups) - 1; i >= 0; i-- {
+			errs = append(errs, t.cleanups[i]())
+		}
+		err = errors.Join(errs...)
+	}()
+	return t.task.Run(ctx)
+}
@@ -0,0 +1,50 @@
+package sup_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/warpfork/go-sup"
+)
+
+// closer records that it was closed, in a shared log, and returns the
+// given error.
+type closer struct {
+	name string
+	log  *[]string
+	err  error
+}
+
+func (c closer) Close() error {
+	*c.log = append(*c.log, c.name)
+	return c.err
+}
+
+func TestWithCleanup(t *testing.T) {
+	t.Run("closers should be closed in reverse order", func(t *testing.T) {
+		var log []string
+		task := sup.WithCleanup(namedFunc{"worker", func(context.Context) error { return nil }},
+			closer{"file", &log, nil}, closer{"conn", &log, nil})
+		shouldEqual(t, task.(sup.NamedTask).Name(), "worker")
+		shouldEqual(t, task.Run(context.Background()), nil)
+		shouldEqual(t, fmt.Sprint(log), "[conn file]")
+	})
+	t.Run("close errors should be joined onto the task's", func(t *testing.T) {
+		var log []string
+		task := sup.WithCleanup(sup.TaskFromFunc(func(context.Context) error { return errors.New("task failed") })[0],
+			closer{"file", &log, errors.New("close failed")})
+		err := task.Run(context.Background())
+		shouldEqual(t, fmt.Sprint(err), "task failed\nclose failed")
+	})
+	t.Run("a panicking task should still be cleaned up", func(t *testing.T) {
+		var log []string
+		task := sup.WithCleanupFunc(namedFunc{"worker", func(context.Context) error { panic("oh no") }},
+			func() error { log = append(log, "cleaned"); return nil })
+		err := sup.SuperviseRoot(context.Background(), sup.SuperviseForkJoin("main", []sup.Task{task}))
+		mustEqual(t, err != nil, true)
+		shouldEqual(t, err.(*sup.ErrChild).WasPanic, true)
+		shouldEqual(t, fmt.Sprint(log), "[cleaned]")
+	})
+}
@@ -0,0 +1,174 @@
+package sup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"time"
+)
+
+// CommandTask returns a task which runs an external command, built afresh
+// for each Run by newCmd (so a task wrapped by Retry, say, runs a new
+// process each time).  Run starts the command, and waits for it to exit.
+// If it exits with a non-zero status, Run returns an ErrCommandFailed,
+// with the exit code, and the tail of what the command wrote to stderr.
+// (Stderr is still written to cmd.Stderr too, if newCmd set it.)
+//
+// If the context is done while the command's running, it's stopped, and
+// Run returns the context's error once it has exited.  Stopping escalates:
+// the command is asked to stop first (with SIGTERM, on unix), and if it
+// hasn't exited after a grace period (see CommandKillGrace), it's killed.
+// On unix, the command runs in a process group of its own, and signals go
+// to the whole group, so that whatever it started is stopped too.
+// Either way, Run doesn't return until the process has been waited for, so
+// nothing is left behind, neither a zombie process nor a goroutine waiting
+// to reap it.
+//
+// newCmd should build its command with exec.Command, not
+// exec.CommandContext, since the task handles the context itself.  (If it
+// does use CommandContext, the context's own cancellation of the process is
+// turned off, so that stopping escalates as described.)
+func CommandTask(name string, newCmd func(Context) *exec.Cmd, opts ...CommandOption) NamedTask {
+	t := commandTask{name: name, newCmd: newCmd, grace: defaultCommandKillGrace, stderrTail: defaultCommandStderrTail}
+	for _, opt := range opts {
+		opt(&t)
+	}
+	return t
+}
+
+// CommandOption configures a CommandTask.
+type CommandOption func(*commandTask)
+
+const (
+	defaultCommandKillGrace  = 5 * time.Second
+	defaultCommandStderrTail = 4096
+)
+
+// CommandKillGrace sets how long a CommandTask waits for its command to
+// exit after asking it to stop, before killing it.  The default is five
+// seconds.  Zero means the command is killed straight away.
+//
+// It also bounds how long Run waits, after the command exits, for its
+// output to be copied (which may never finish, if something the command
+// started is still holding its stdout or stderr open).
+func CommandKillGrace(d time.Duration) CommandOption {
+	return func(t *commandTask) {
+		t.grace = d
+	}
+}
+
+// CommandStderrTail sets how much of the end of the command's stderr is
+// kept, in bytes, for an ErrCommandFailed.  The default is 4096.  Zero
+// means none is kept.
+func CommandStderrTail(n int) CommandOption {
+	return func(t *commandTask) {
+		t.stderrTail = n
+	}
+}
+
+// ErrCommandFailed is the error returned by a CommandTask when its command
+// exits unsuccessfully.  It unwraps to the *exec.ExitError.
+type ErrCommandFailed struct {
+	Command  string // the command's path.
+	ExitCode int    // the exit code; -1 if the command was ended by a signal.
+	Stderr   string // the tail of what the command wrote to stderr.
+	Err      error  // the error from exec.
+}
+
+func (e ErrCommandFailed) Error() string {
+	if e.ExitCode < 0 {
+		return fmt.Sprintf("command %q failed: %v", e.Command, e.Err)
+	}
+	return fmt.Sprintf("command %q exited with code %d", e.Command, e.ExitCode)
+}
+
+func (e ErrCommandFailed) Unwrap() error {
+	return e.Err
+}
+
+type commandTask struct {
+	name       string
+	newCmd     func(Context) *exec.Cmd
+	grace      time.Duration
+	stderrTail int
+}
+
+func (t commandTask) Name() string {
+	return t.name
+}
+
+func (t commandTask) Run(ctx context.Context) error {
+	cmd := t.newCmd(ctx)
+	tail := &tailBuffer{max: t.stderrTail}
+	if cmd.Stderr == nil {
+		cmd.Stderr = tail
+	} else {
+		cmd.Stderr = io.MultiWriter(cmd.Stderr, tail)
+	}
+	if cmd.Cancel != nil {
+		cmd.Cancel = func() error { return nil } // from CommandContext; we'll do the stopping.
+	}
+	if cmd.WaitDelay == 0 {
+		cmd.WaitDelay = t.grace
+	}
+	setProcessGroup(cmd)
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	waited := make(chan error, 1)
+	go func() { waited <- cmd.Wait() }()
+	var err error
+	select {
+	case err = <-waited:
+	case <-ctx.Done():
+		t.stop(cmd, waited)
+		return ctx.Err()
+	}
+
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return ErrCommandFailed{cmd.Path, exitErr.ExitCode(), tail.String(), err}
+	}
+	return err
+}
+
+// stop asks the command to stop, then kills it if it hasn't after the
+// grace period, and either way, waits until it's been reaped.
+func (t commandTask) stop(cmd *exec.Cmd, waited <-chan error) {
+	if t.grace > 0 && terminateProcess(cmd) == nil {
+		timer := time.NewTimer(t.grace)
+		defer timer.Stop()
+		select {
+		case <-waited:
+			return
+		case <-timer.C:
+		}
+	}
+	killProcess(cmd)
+	<-waited
+}
+
+// tailBuffer is a writer which keeps only the last max bytes written to it.
+type tailBuffer struct {
+	max int
+	buf []byte
+}
+
+func (b *tailBuffer) Write(p []byte) (int, error) {
+	n := len(p)
+	if len(p) > b.max {
+		p = p[len(p)-b.max:]
+	}
+	if over := len(b.buf) + len(p) - b.max; over > 0 {
+		b.buf = append(b.buf[:0], b.buf[over:]...)
+	}
+	b.buf = append(b.buf, p...)
+	return n, nil
+}
+
+func (b *tailBuffer) String() string {
+	return string(b.buf)
+}
@@ -0,0 +1,19 @@
+//go:build !unix
+
+package sup
+
+import (
+	"os"
+	"os/exec"
+)
+
+// setProcessGroup does nothing here: process groups are a unix thing.
+func setProcessGroup(cmd *exec.Cmd) {}
+
+func terminateProcess(cmd *exec.Cmd) error {
+	return cmd.Process.Signal(os.Interrupt)
+}
+
+func killProcess(cmd *exec.Cmd) error {
+	return cmd.Process.Kill()
+}
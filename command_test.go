@@ -0,0 +1,59 @@
+//go:build unix
+
+package sup_test
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/warpfork/go-sup"
+)
+
+func shellTask(script string, opts ...sup.CommandOption) sup.NamedTask {
+	return sup.CommandTask("sh", func(sup.Context) *exec.Cmd {
+		return exec.Command("/bin/sh", "-c", script)
+	}, opts...)
+}
+
+func TestCommandTask(t *testing.T) {
+	t.Run("a successful command should return nil", func(t *testing.T) {
+		shouldEqual(t, shellTask("true").Run(context.Background()), nil)
+	})
+	t.Run("a failing command should say its exit code, and the end of its stderr", func(t *testing.T) {
+		err := shellTask("echo 0123456789 >&2; exit 3", sup.CommandStderrTail(5)).Run(context.Background())
+		var failed sup.ErrCommandFailed
+		mustEqual(t, errors.As(err, &failed), true)
+		shouldEqual(t, failed.ExitCode, 3)
+		shouldEqual(t, failed.Stderr, "6789\n")
+		shouldEqual(t, err.Error(), `command "/bin/sh" exited with code 3`)
+	})
+	t.Run("cancellation should stop the command", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		start := time.Now()
+		err := shellTask("sleep 10").Run(ctx)
+		shouldEqual(t, err, context.DeadlineExceeded)
+		shouldEqual(t, time.Since(start) < 5*time.Second, true)
+	})
+	t.Run("a command ignoring the request to stop should be killed after the grace period, with its children", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		start := time.Now()
+		err := shellTask(`trap "" TERM; sleep 10; sleep 10`, sup.CommandKillGrace(200*time.Millisecond)).Run(ctx)
+		elapsed := time.Since(start)
+		shouldEqual(t, err, context.DeadlineExceeded)
+		shouldEqual(t, elapsed >= 250*time.Millisecond, true)
+		shouldEqual(t, elapsed < 5*time.Second, true)
+	})
+	t.Run("a command which can't be started should fail", func(t *testing.T) {
+		err := sup.CommandTask("nope", func(sup.Context) *exec.Cmd {
+			return exec.Command("/no/such/command")
+		}).Run(context.Background())
+		shouldEqual(t, err != nil, true)
+		shouldEqual(t, strings.Contains(err.Error(), "no such file"), true)
+	})
+}
@@ -0,0 +1,25 @@
+//go:build unix
+
+package sup
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// setProcessGroup makes the command run in a process group of its own, so
+// it can be signalled along with everything it starts.
+func setProcessGroup(cmd *exec.Cmd) {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.Setpgid = true
+}
+
+func terminateProcess(cmd *exec.Cmd) error {
+	return syscall.Kill(-cmd.Process.Pid, syscall.SIGTERM)
+}
+
+func killProcess(cmd *exec.Cmd) error {
+	return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+}
@@ -0,0 +1,104 @@
+package sup
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+)
+
+// Sequence returns a task which runs the given tasks one after another,
+// each in the Sequence's own goroutine and with its context, stopping at
+// the first error, which it returns wrapped in an ErrSequenceStep saying
+// which step failed.  Between steps, it checks whether the context is done,
+// and if so, returns the context's error (also wrapped, naming the step
+// which would have been next).
+//
+// The steps aren't supervised separately: they're all part of the one
+// task, which is named by the given name.  (So a supervisor among the steps,
+// like a Parallel, puts its tasks directly under the Sequence in the task
+// path.)  CurrentStep says which step it's on, so a sequence which is stuck
+// can be found out.
+func Sequence(name string, tasks ...Task) SequenceTask {
+	t := &sequenceTask{name: name, tasks: tasks}
+	t.current.Store(-1)
+	return t
+}
+
+// SequenceTask is the task Sequence returns.
+type SequenceTask interface {
+	NamedTask
+
+	// CurrentStep returns the index of the step which is running (counting
+	// from zero), and its name (if it's a NamedTask; otherwise it's
+	// empty).  The index is -1 if the sequence isn't running.  It's safe
+	// to call from any goroutine.
+	CurrentStep() (index int, name string)
+}
+
+// ErrSequenceStep is the error returned by a Sequence when a step fails.
+// It unwraps to the step's own error.
+type ErrSequenceStep struct {
+	Sequence string // the name of the Sequence.
+	Index    int    // the index of the step, counting from zero.
+	StepName string // the name of the step, if it's a NamedTask; otherwise it's empty.
+	Err      error  // the step's error.
+}
+
+func (e ErrSequenceStep) Error() string {
+	if e.StepName == "" {
+		return fmt.Sprintf("sequence %q failed at step %d: %v", e.Sequence, e.Index, e.Err)
+	}
+	return fmt.Sprintf("sequence %q failed at step %d (%q): %v", e.Sequence, e.Index, e.StepName, e.Err)
+}
+
+func (e ErrSequenceStep) Unwrap() error {
+	return e.Err
+}
+
+type sequenceTask struct {
+	name    string
+	tasks   []Task
+	current atomic.Int64
+}
+
+func (t *sequenceTask) Name() string {
+	return t.name
+}
+
+func (t *sequenceTask) CurrentStep() (int, string) {
+	i := int(t.current.Load())
+	if i < 0 {
+		return -1, ""
+	}
+	return i, stepName(t.tasks[i])
+}
+
+func (t *sequenceTask) Run(ctx context.Context) error {
+	defer t.current.Store(-1)
+	for i, task := range t.tasks {
+		t.current.Store(int64(i))
+		err := ctx.Err()
+		if err == nil {
+			err = task.Run(ctx)
+		}
+		if err != nil {
+			return ErrSequenceStep{t.name, i, stepName(task), err}
+		}
+	}
+	return nil
+}
+
+func stepName(t Task) string {
+	if nt, ok := t.(NamedTask); ok {
+		return nt.Name()
+	}
+	return ""
+}
+
+// Parallel returns a supervisor which runs the given tasks all at once,
+// just as SuperviseForkJoin does.  It's for declaring structure alongside
+// Sequence: a Sequence of steps, one of which is a Parallel of several,
+// say.  As with any supervisor, it can only be run once.
+func Parallel(name string, tasks ...Task) Supervisor {
+	return SuperviseForkJoin(name, tasks)
+}
@@ -0,0 +1,96 @@
+package sup_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/warpfork/go-sup"
+)
+
+func TestSequence(t *testing.T) {
+	t.Run("steps should run in order", func(t *testing.T) {
+		var log []string
+		step := func(name string) sup.Task {
+			return namedFunc{name, func(context.Context) error { log = append(log, name); return nil }}
+		}
+		seq := sup.Sequence("migrate", step("a"), step("b"), step("c"))
+		shouldEqual(t, sup.SuperviseRoot(context.Background(), sup.SuperviseForkJoin("main", []sup.Task{seq})), nil)
+		shouldEqual(t, fmt.Sprint(log), "[a b c]")
+	})
+	t.Run("the first error should stop the sequence", func(t *testing.T) {
+		var log []string
+		seq := sup.Sequence("migrate",
+			namedFunc{"a", func(context.Context) error { log = append(log, "a"); return nil }},
+			namedFunc{"b", func(context.Context) error { return errors.New("boom") }},
+			namedFunc{"c", func(context.Context) error { log = append(log, "c"); return nil }},
+		)
+		err := seq.Run(context.Background())
+		shouldEqual(t, fmt.Sprint(err), `sequence "migrate" failed at step 1 ("b"): boom`)
+		var stepErr sup.ErrSequenceStep
+		mustEqual(t, errors.As(err, &stepErr), true)
+		shouldEqual(t, stepErr.Index, 1)
+		shouldEqual(t, fmt.Sprint(log), "[a]")
+	})
+	t.Run("cancellation should be checked between steps", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		ran := false
+		seq := sup.Sequence("s",
+			sup.TaskFromFunc(func(context.Context) error { cancel(); return nil })[0],
+			sup.TaskFromFunc(func(context.Context) error { ran = true; return nil })[0],
+		)
+		err := seq.Run(ctx)
+		shouldEqual(t, errors.Is(err, context.Canceled), true)
+		shouldEqual(t, fmt.Sprint(err), `sequence "s" failed at step 1: context canceled`)
+		shouldEqual(t, ran, false)
+	})
+	t.Run("the current step should be visible", func(t *testing.T) {
+		inStep := make(chan struct{})
+		release := make(chan struct{})
+		seq := sup.Sequence("s",
+			namedFunc{"quick", func(context.Context) error { return nil }},
+			namedFunc{"slow", func(context.Context) error { close(inStep); <-release; return nil }},
+		)
+		i, _ := seq.CurrentStep()
+		shouldEqual(t, i, -1)
+		done := make(chan error)
+		go func() { done <- seq.Run(context.Background()) }()
+		<-inStep
+		i, name := seq.CurrentStep()
+		shouldEqual(t, i, 1)
+		shouldEqual(t, name, "slow")
+		close(release)
+		shouldEqual(t, <-done, nil)
+		i, _ = seq.CurrentStep()
+		shouldEqual(t, i, -1)
+	})
+}
+
+func TestParallel(t *testing.T) {
+	var mu sync.Mutex
+	var log []string
+	step := func(name string) sup.Task {
+		return namedFunc{name, func(ctx context.Context) error {
+			mu.Lock()
+			defer mu.Unlock()
+			log = append(log, sup.CtxTaskPath(ctx))
+			return nil
+		}}
+	}
+	seq := sup.Sequence("deploy",
+		step("prepare"),
+		sup.Parallel("rollout", step("east"), step("west")),
+		step("verify"),
+	)
+	err := sup.SuperviseRoot(context.Background(), sup.SuperviseForkJoin("main", []sup.Task{seq}))
+	shouldEqual(t, err, nil)
+	mustEqual(t, len(log), 4)
+	shouldEqual(t, log[0], "main/deploy")
+	middle := log[1:3]
+	sort.Strings(middle)
+	shouldEqual(t, fmt.Sprint(middle), "[main/deploy/east main/deploy/west]")
+	shouldEqual(t, log[3], "main/deploy")
+}
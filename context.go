@@ -2,6 +2,9 @@ package sup
 
 import (
 	"context"
+	"log/slog"
+	"reflect"
+	"time"
 )
 
 type Context = context.Context
@@ -11,12 +14,49 @@ type ctxKey struct{}
 type ctxInfo struct {
 	task *boundTask
 	path string
+	cfg  *supervision     // config of the supervisor that launched the task (nil for the root).
+	mgr  *superviseCommon // the supervisor that launched the task (nil for the root).
+
+	submittedAt time.Time     // when the supervisor launched the task.
+	startedAt   time.Time     // when the task's goroutine started running it.
+	exited      chan struct{} // closed when the task returns.
+
+	// The rest are inherited: each task gets its parent's.
+	logger   *slog.Logger    // set by CtxWithLogger.
+	values   []ctxValue      // set by WithValue.  Never modified in place: WithValue copies it, so it can be shared.
+	softStop <-chan struct{} // set by the supervisor, for its children; see SoftStopCh.
+}
+
+type ctxValue struct {
+	key, val interface{}
 }
 
+// appendCtxInfo attaches the info for a newly launched task to its context.
+// The inherited fields are carried over from the info already there (if any).
 func appendCtxInfo(ctx Context, x ctxInfo) Context {
+	if parent, ok := ctx.Value(ctxKey{}).(ctxInfo); ok {
+		x.logger = parent.logger
+		x.values = parent.values
+		x.softStop = parent.softStop
+	}
 	return context.WithValue(ctx, ctxKey{}, x)
 }
 
+// warn sends a warning about the task to the warning handler of the
+// supervisor that launched it.  If there's no such supervisor (the info is
+// empty, or the task is the root), the warning is dropped.
+func (info ctxInfo) warn(kind WarningKind, message string) {
+	if info.cfg == nil {
+		return
+	}
+	info.cfg.warn(SupervisionWarning{
+		Kind:           kind,
+		SupervisorPath: ParentPath(info.path),
+		TaskPath:       info.path,
+		Message:        message,
+	})
+}
+
 // CtxTaskName returns the name of the current task
 // (or if there is no task annotated as owner of this context,
 // returns the empty string).
@@ -25,18 +65,33 @@ func appendCtxInfo(ctx Context, x ctxInfo) Context {
 // and may be missing if you call a task's Run method manually.
 func CtxTaskName(ctx Context) string {
 	ctxInfo, ok := ctx.Value(ctxKey{}).(ctxInfo)
-	if !ok {
+	if !ok || ctxInfo.task == nil {
 		return ""
 	}
 	return ctxInfo.task.name
 }
 
+// CtxRequestedTaskName returns the name the current task asked for, before
+// the supervisor filled in any placeholders (see NameStrategy): "worker-%"
+// rather than "worker-37", say.  It's for things like metrics labels, which
+// should be the same for every task in a pool.  For a task whose name had
+// no placeholders, it's the same as CtxTaskName.
+func CtxRequestedTaskName(ctx Context) string {
+	ctxInfo, ok := ctx.Value(ctxKey{}).(ctxInfo)
+	if !ok || ctxInfo.task == nil {
+		return ""
+	}
+	return ctxInfo.task.requested
+}
+
 // CtxTaskPath returns the full path of names for each task in the supervision
 // tree above this one
 // (or if there is no task annotated as owner of this context,
 // returns the empty string).
 //
-// The path is separated by slashes, as per file paths.
+// The path is separated by slashes (see TaskPathSeparator, which also
+// describes how names containing slashes are escaped); use SplitTaskPath
+// to take it apart.
 //
 // Task name and path info is annotated when tasks are launched by supervisors,
 // and may be missing if you call a task's Run method manually.
@@ -47,3 +102,129 @@ func CtxTaskPath(ctx Context) string {
 	}
 	return ctxInfo.path
 }
+
+// CtxWithLogger returns a context carrying the given logger, for Logger to
+// find.  Tasks launched by supervisors inherit it from their parent's
+// context, so setting it once, on the context given to SuperviseRoot, is
+// enough for the whole tree.
+//
+// Supervisors in the tree use it for their warnings too, unless they were
+// given a logger (or warning handler) of their own with SetLogger (or
+// SetWarningHandler).
+func CtxWithLogger(ctx Context, logger *slog.Logger) Context {
+	info, _ := ctx.Value(ctxKey{}).(ctxInfo)
+	info.logger = logger
+	return context.WithValue(ctx, ctxKey{}, info)
+}
+
+// Logger returns a logger for the current task: the one set by
+// CtxWithLogger (or slog.Default, if there's none), with a "sup.task"
+// attribute set to the task's path, so every line it logs can be traced
+// back to the task.  If the context doesn't belong to a supervised task,
+// the attribute says "[unmanaged]".
+//
+// Each call makes a new logger, so a task that logs a lot should call it
+// once, and keep the result.
+func Logger(ctx Context) *slog.Logger {
+	info, _ := ctx.Value(ctxKey{}).(ctxInfo)
+	path := info.path
+	if path == "" {
+		path = "[unmanaged]"
+	}
+	return ctxLogger(ctx).With("sup.task", path)
+}
+
+// ctxLogger returns the logger set by CtxWithLogger, or slog.Default.
+func ctxLogger(ctx Context) *slog.Logger {
+	if info, _ := ctx.Value(ctxKey{}).(ctxInfo); info.logger != nil {
+		return info.logger
+	}
+	return slog.Default()
+}
+
+// WithValue returns a context carrying the value under the given key, for
+// ValueFrom to find.  It's like context.WithValue, but the value is kept in
+// the same attachment as the rest of go-sup's task info, so looking it up
+// takes a single context lookup, no matter how deep the supervision tree is
+// or how many values there are.  It's for tree-wide metadata, like a tenant
+// or request ID.
+//
+// Tasks launched by supervisors inherit all the values from their parent's
+// context, as it was when the supervisor was run.  Values set later, on some
+// other context, don't appear in tasks that were already launched: each task
+// has a snapshot.
+//
+// As with context.WithValue, the key must be comparable, and should be of a
+// type of your own, so it can't collide with anyone else's; otherwise,
+// WithValue panics.
+func WithValue(ctx Context, key, val interface{}) Context {
+	if key == nil || !reflect.TypeOf(key).Comparable() {
+		panic("usage: sup.WithValue key must be comparable, and not nil")
+	}
+	info, _ := ctx.Value(ctxKey{}).(ctxInfo)
+	values := make([]ctxValue, 0, len(info.values)+1)
+	for _, kv := range info.values {
+		if kv.key != key {
+			values = append(values, kv)
+		}
+	}
+	info.values = append(values, ctxValue{key, val})
+	return context.WithValue(ctx, ctxKey{}, info)
+}
+
+// ValueFrom returns the value set with WithValue for the given key, or nil
+// if there's none.
+func ValueFrom(ctx Context, key interface{}) interface{} {
+	info, _ := ctx.Value(ctxKey{}).(ctxInfo)
+	for _, kv := range info.values {
+		if kv.key == key {
+			return kv.val
+		}
+	}
+	return nil
+}
+
+// SoftStopCh returns a channel which is closed when the supervisor running
+// the current task is asked to stop softly (see Supervisor.SoftStop).
+// Unlike the context being cancelled, this is a request, not an order:
+// it means "finish the work item you're on, then return", so in-flight work
+// (IO, say) can complete.  Tasks which care can select on it, between work
+// items; tasks which don't are unaffected, and will be cancelled as usual
+// if they don't return in time.
+//
+// If the context doesn't belong to a supervised task, the channel is nil,
+// so it never fires.
+func SoftStopCh(ctx Context) <-chan struct{} {
+	info, _ := ctx.Value(ctxKey{}).(ctxInfo)
+	return info.softStop
+}
+
+// QueueLatency returns how long the current task waited between its
+// supervisor launching it and its goroutine actually starting to run it:
+// that is, the scheduling delay.  It's zero if the context doesn't belong to
+// a supervised task.  (See also Supervisor.QueueLatency, for the aggregate.)
+func QueueLatency(ctx Context) time.Duration {
+	info, _ := ctx.Value(ctxKey{}).(ctxInfo)
+	if info.submittedAt.IsZero() {
+		return 0
+	}
+	return info.startedAt.Sub(info.submittedAt)
+}
+
+// TaskPathString returns the current task's path (as CtxTaskPath does), and
+// whether there is one.  It's for logging and tracing middleware which
+// wants the path, and nothing else.
+func TaskPathString(ctx Context) (string, bool) {
+	path := CtxTaskPath(ctx)
+	return path, path != ""
+}
+
+// TaskPathContextKey is the key under which supervisors given the
+// ExposeTaskPath option also attach each child's task path to its context,
+// as a plain string.  Code which can't (or would rather not) import this
+// package can then read it with ctx.Value("sup.taskpath").
+//
+// It's deliberately a plain string, not a private type as context keys
+// usually are: the point is that it can be written down without importing
+// anything.
+const TaskPathContextKey = "sup.taskpath"
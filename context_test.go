@@ -0,0 +1,70 @@
+package sup_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/warpfork/go-sup"
+)
+
+type tenantKey struct{}
+type requestKey struct{}
+
+func TestWithValue(t *testing.T) {
+	t.Run("values should be inherited down the tree", func(t *testing.T) {
+		ctx := sup.WithValue(context.Background(), tenantKey{}, "acme")
+		ctx = sup.WithValue(ctx, requestKey{}, 42)
+		var tenant, request interface{}
+		sup.SuperviseRoot(ctx,
+			sup.SuperviseForkJoin("main", []sup.Task{
+				sup.SuperviseForkJoin("inner", []sup.Task{
+					namedFunc{"worker", func(ctx context.Context) error {
+						tenant = sup.ValueFrom(ctx, tenantKey{})
+						request = sup.ValueFrom(ctx, requestKey{})
+						return nil
+					}},
+				}),
+			}),
+		)
+		shouldEqual(t, tenant, "acme")
+		shouldEqual(t, request, 42)
+	})
+	t.Run("setting a key again should replace it, without disturbing the parent", func(t *testing.T) {
+		parent := sup.WithValue(context.Background(), tenantKey{}, "acme")
+		child := sup.WithValue(parent, tenantKey{}, "initech")
+		shouldEqual(t, sup.ValueFrom(parent, tenantKey{}), "acme")
+		shouldEqual(t, sup.ValueFrom(child, tenantKey{}), "initech")
+		shouldEqual(t, sup.ValueFrom(child, requestKey{}), nil)
+	})
+	t.Run("uncomparable keys should panic", func(t *testing.T) {
+		defer func() {
+			shouldEqual(t, recover(), "usage: sup.WithValue key must be comparable, and not nil")
+		}()
+		sup.WithValue(context.Background(), []int{}, 1)
+	})
+}
+
+func TestTaskPathString(t *testing.T) {
+	run := func(opts ...sup.SupervisionOptions) (path string, ok bool, foreign interface{}) {
+		sup.SuperviseRoot(context.Background(),
+			sup.SuperviseForkJoin("main", []sup.Task{
+				namedFunc{"worker", func(ctx context.Context) error {
+					path, ok = sup.TaskPathString(ctx)
+					foreign = ctx.Value("sup.taskpath")
+					return nil
+				}},
+			}, opts...),
+		)
+		return
+	}
+	path, ok, foreign := run()
+	shouldEqual(t, path, "main/worker")
+	shouldEqual(t, ok, true)
+	shouldEqual(t, foreign, nil)
+
+	_, _, foreign = run(sup.ExposeTaskPath())
+	shouldEqual(t, foreign, "main/worker")
+
+	_, ok = sup.TaskPathString(context.Background())
+	shouldEqual(t, ok, false)
+}
@@ -0,0 +1,77 @@
+package sup
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+)
+
+// Delay returns a task which waits for the given duration, and then calls
+// fn once, and returns what it returns.  If the context is done while it's
+// waiting, it returns the context's error without calling fn; if the
+// supervisor asks for a soft stop while it's waiting, it returns nil
+// without calling fn.
+//
+// The clock starts when the task starts running.  (This is Delay, rather
+// than After, since After is the Selectable for a timeout in a Select.)
+//
+// The task is a ScheduledTask, so whoever has it can tell when it's due.
+func Delay(d time.Duration, fn func(Context) error) ScheduledTask {
+	return &delayTask{delay: d, fn: fn}
+}
+
+// At returns a task which waits until the given time, and then calls fn
+// once, just as Delay does.  If the time has already passed when the task
+// starts, fn is called at once.
+func At(t time.Time, fn func(Context) error) ScheduledTask {
+	return &delayTask{at: t, fn: fn}
+}
+
+// ScheduledTask is a task which is waiting to do something at a certain
+// time, like those made by Delay and At.  Scheduled returns that time, and
+// true, while the task is waiting (it's running, as far as its supervisor
+// is concerned; it's just sleeping), and false before the task starts or
+// after its wait is over.  It's safe to call from any goroutine.
+type ScheduledTask interface {
+	Task
+	Scheduled() (time.Time, bool)
+}
+
+type delayTask struct {
+	delay   time.Duration
+	at      time.Time // if zero, the delay is used.
+	fn      func(Context) error
+	waiting atomic.Int64 // when it's due, in Unix nanoseconds, while it's waiting; otherwise zero.
+}
+
+func (t *delayTask) Scheduled() (time.Time, bool) {
+	due := t.waiting.Load()
+	if due == 0 {
+		return time.Time{}, false
+	}
+	return time.Unix(0, due), true
+}
+
+func (t *delayTask) Run(ctx context.Context) error {
+	due := t.at
+	if due.IsZero() {
+		due = time.Now().Add(t.delay)
+	}
+	t.waiting.Store(due.UnixNano())
+	timer := time.NewTimer(time.Until(due))
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-ctx.Done():
+		t.waiting.Store(0)
+		return ctx.Err()
+	case <-SoftStopCh(ctx):
+		t.waiting.Store(0)
+		return nil
+	}
+	t.waiting.Store(0)
+	if err := ctx.Err(); err != nil {
+		return err // the timer and the context were both ready; the context wins.
+	}
+	return t.fn(ctx)
+}
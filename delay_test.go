@@ -0,0 +1,64 @@
+package sup_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/warpfork/go-sup"
+)
+
+func TestDelay(t *testing.T) {
+	t.Run("the function should be called after the delay", func(t *testing.T) {
+		start := time.Now()
+		var calledAfter time.Duration
+		err := sup.Delay(20*time.Millisecond, func(context.Context) error {
+			calledAfter = time.Since(start)
+			return errors.New("done")
+		}).Run(context.Background())
+		shouldEqual(t, fmt.Sprint(err), "done")
+		shouldEqual(t, calledAfter >= 20*time.Millisecond, true)
+	})
+	t.Run("cancellation should skip the function", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		called := false
+		err := sup.Delay(time.Hour, func(context.Context) error { called = true; return nil }).Run(ctx)
+		shouldEqual(t, errors.Is(err, context.DeadlineExceeded), true)
+		shouldEqual(t, called, false)
+	})
+	t.Run("a soft stop should skip the function", func(t *testing.T) {
+		called := false
+		svr := sup.SuperviseForkJoin("main", []sup.Task{
+			sup.Delay(time.Hour, func(context.Context) error { called = true; return nil }),
+		})
+		svr.SoftStop()
+		shouldEqual(t, sup.SuperviseRoot(context.Background(), svr), nil)
+		shouldEqual(t, called, false)
+	})
+	t.Run("the schedule should be visible while waiting", func(t *testing.T) {
+		due := time.Now().Add(50 * time.Millisecond)
+		var during time.Time
+		var duringOK bool
+		task := sup.At(due, func(context.Context) error { return nil })
+		_, ok := task.Scheduled()
+		shouldEqual(t, ok, false)
+		done := make(chan error)
+		go func() { done <- task.Run(context.Background()) }()
+		for !duringOK {
+			during, duringOK = task.Scheduled()
+		}
+		shouldEqual(t, during.Equal(due), true)
+		shouldEqual(t, <-done, nil)
+		_, ok = task.Scheduled()
+		shouldEqual(t, ok, false)
+	})
+	t.Run("a time in the past should fire at once", func(t *testing.T) {
+		called := false
+		err := sup.At(time.Now().Add(-time.Hour), func(context.Context) error { called = true; return nil }).Run(context.Background())
+		shouldEqual(t, err, nil)
+		shouldEqual(t, called, true)
+	})
+}
@@ -0,0 +1,92 @@
+package sup
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Detach returns a context for work which must outlive the current task's
+// cancellation, like writing an audit record during shutdown.  The context
+// isn't cancelled when ctx is; instead, it has a deadline of its own, the
+// given budget from now, so detached work can't run on forever.
+// Call the cancel function when the work is done, as with
+// context.WithTimeout.
+//
+// Unlike context.WithoutCancel, Detach keeps the go-sup task info: the task
+// path is the current one with ".detached" on the end (so logs from the
+// detached work are attributable, and recognizably detached), and values
+// from WithValue and the logger from CtxWithLogger are still there.
+//
+// The detached context is registered with the supervisor that launched the
+// current task.  If that supervisor finishes while the context is still
+// live (neither cancelled nor past its deadline), it emits a detached
+// warning naming it: the work is still a stray goroutine, but at least a
+// known one.
+func Detach(ctx Context, budget time.Duration) (Context, context.CancelFunc) {
+	info, _ := ctx.Value(ctxKey{}).(ctxInfo)
+	detached, cancel := context.WithTimeout(context.WithoutCancel(ctx), budget)
+	if info.path != "" {
+		info.path += ".detached"
+	}
+	info.softStop = nil // a detached context is past stopping softly.
+	detached = context.WithValue(detached, ctxKey{}, info)
+	if info.mgr != nil {
+		path := info.path
+		info.mgr.detached.add(path)
+		// Deregister when the deadline passes, or synchronously on cancel,
+		//  so work that's cancelled before the task returns is never reported.
+		stop := context.AfterFunc(detached, func() { info.mgr.detached.remove(path) })
+		return detached, func() {
+			if stop() {
+				info.mgr.detached.remove(path)
+			}
+			cancel()
+		}
+	}
+	return detached, cancel
+}
+
+// detachments tracks which detached contexts made by a supervisor's
+// children are still live, by path.
+type detachments struct {
+	mu   sync.Mutex
+	live map[string]int
+}
+
+func (d *detachments) add(path string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.live[path]++
+}
+
+func (d *detachments) remove(path string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.live[path]--; d.live[path] == 0 {
+		delete(d.live, path)
+	}
+}
+
+// warnDetached emits a warning for each detached context still live.
+func (mgr *superviseCommon) warnDetached() {
+	if mgr.detached == nil {
+		return // never ran.
+	}
+	mgr.detached.mu.Lock()
+	paths := make([]string, 0, len(mgr.detached.live))
+	for path := range mgr.detached.live {
+		paths = append(paths, path)
+	}
+	mgr.detached.mu.Unlock()
+	sort.Strings(paths)
+	for _, path := range paths {
+		mgr.cfg.warn(SupervisionWarning{
+			Kind:           WarningKind_detached,
+			SupervisorPath: mgr.path,
+			TaskPath:       path,
+			Message:        "detached work is still running after the supervisor finished",
+		})
+	}
+}
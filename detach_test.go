@@ -0,0 +1,53 @@
+package sup_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/warpfork/go-sup"
+)
+
+func TestDetach(t *testing.T) {
+	t.Run("should survive cancellation, keep the task info, and have its own deadline", func(t *testing.T) {
+		var path string
+		var errAfterCancel, errAfterBudget error
+		sup.SuperviseRoot(context.Background(),
+			sup.SuperviseForkJoin("main", []sup.Task{
+				namedFunc{"worker", func(ctx context.Context) error {
+					ctx, cancel := context.WithCancel(ctx)
+					detached, cancelDetached := sup.Detach(ctx, 5*time.Millisecond)
+					defer cancelDetached()
+					cancel()
+					path = sup.CtxTaskPath(detached)
+					errAfterCancel = detached.Err()
+					<-detached.Done()
+					errAfterBudget = detached.Err()
+					return nil
+				}},
+			}),
+		)
+		shouldEqual(t, path, "main/worker.detached")
+		shouldEqual(t, errAfterCancel, nil)
+		shouldEqual(t, errors.Is(errAfterBudget, context.DeadlineExceeded), true)
+	})
+	t.Run("should be reported if still live when the supervisor finishes", func(t *testing.T) {
+		var warnings []sup.SupervisionWarning
+		var cancelDetached context.CancelFunc
+		sup.SuperviseRoot(context.Background(),
+			sup.SuperviseForkJoin("main", []sup.Task{
+				namedFunc{"worker", func(ctx context.Context) error {
+					_, cancelDetached = sup.Detach(ctx, time.Hour)
+					_, cancelDone := sup.Detach(ctx, time.Hour)
+					cancelDone() // this one's finished, so it shouldn't be reported.
+					return nil
+				}},
+			}, sup.SetWarningHandler(func(w sup.SupervisionWarning) { warnings = append(warnings, w) })),
+		)
+		cancelDetached()
+		mustEqual(t, len(warnings), 1)
+		shouldEqual(t, warnings[0].Kind, sup.WarningKind_detached)
+		shouldEqual(t, warnings[0].TaskPath, "main/worker.detached")
+	})
+}
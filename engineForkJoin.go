@@ -2,44 +2,40 @@ package sup
 
 import (
 	"context"
+	"fmt"
 	"sync/atomic"
 )
 
 type superviseFJ struct {
-	name        string
-	tasks       []*boundTask
-	phase       uint32
-	reportCh    <-chan reportMsg
-	groupCancel func()
-	awaiting    map[*boundTask]struct{}
-	results     map[*boundTask]*ErrChild
-	firstErr    error
-}
-
-func (mgr superviseFJ) Phase() Phase {
-	return Phase(atomic.LoadUint32(&mgr.phase))
+	superviseCommon
+	tasks []*boundTask
+	limit int // the most tasks to run at once; zero means no limit.
 }
 
 func (mgr superviseFJ) init(tasks []Task) Supervisor {
 	mgr.phase = uint32(Phase_init)
+	mgr.doneCh = make(chan struct{})
+	mgr.softStopCh = make(chan struct{})
+	mgr.quitCh = make(chan struct{})
+	mgr.live = &liveTasks{}
 	mgr.tasks = bindTasks(tasks)
 	return &mgr
 }
 
-func (mgr superviseFJ) Name() string {
-	return mgr.name
-}
-
 func (mgr *superviseFJ) Run(parentCtx context.Context) error {
 	// Enforce single-run under mutex for sanity.
-	ok := atomic.CompareAndSwapUint32(&mgr.phase, uint32(Phase_init), uint32(Phase_collecting))
+	//  (With a limit, there's a running phase, while tasks are launched.)
+	start := Phase_collecting
+	if mgr.limited() {
+		start = Phase_running
+	}
+	ok := atomic.CompareAndSwapUint32(&mgr.phase, uint32(Phase_init), uint32(start))
 	if !ok {
 		panic("supervisor can only be Run() once!")
 	}
 
-	// Allocate statekeepers.
-	mgr.awaiting = make(map[*boundTask]struct{}, len(mgr.tasks))
-	mgr.results = make(map[*boundTask]*ErrChild, len(mgr.tasks))
+	parentCtx, cancel := mgr.quittable(parentCtx)
+	defer cancel()
 
 	// Step through phases (the halting phase will return a nil next phase).
 	for phase := mgr._running; phase != nil; {
@@ -50,63 +46,63 @@ func (mgr *superviseFJ) Run(parentCtx context.Context) error {
 }
 
 func (mgr *superviseFJ) _running(parentCtx context.Context) phaseFn {
-	// Build the child status channel we'll be watching,
-	// and the groupCtx which will let us cancel all children in bulk.
-	reportCh := make(chan reportMsg)
-	mgr.reportCh = reportCh
-	groupCtx, groupCancel := context.WithCancel(parentCtx)
-	mgr.groupCancel = groupCancel
+	groupCtx := mgr.prepare(parentCtx, len(mgr.tasks))
+	if mgr.limited() {
+		return mgr._runningLimited(parentCtx, groupCtx)
+	}
 
 	// Launch all child goroutines... then move immediately on to "collecting".
 	//  The joy of a fork-join pattern is this loop is simple.
 	for _, task := range mgr.tasks {
-		mgr.awaiting[task] = struct{}{}
-		go childLaunch(groupCtx, reportCh, task)
+		mgr.launch(groupCtx, task)
 	}
 	return mgr._collecting
 }
 
-func (mgr *superviseFJ) _collecting(parentCtx context.Context) phaseFn {
-	atomic.StoreUint32(&mgr.phase, uint32(Phase_collecting))
+func (mgr *superviseFJ) limited() bool {
+	return mgr.limit > 0 && mgr.limit < len(mgr.tasks)
+}
 
-	// We're not accepting new tasks anymore, so this loop is now only
-	//  for collecting results or accepting a group cancel instruction;
-	//  and it can move directly to halt if there are no disruptions.
-	for len(mgr.awaiting) > 0 {
+// _runningLimited launches tasks up to the limit, and then one more each
+// time one finishes, until they've all been launched, and the collecting
+// phase can take over.  If we start winding down first, the rest are
+// skipped.
+func (mgr *superviseFJ) _runningLimited(parentCtx, groupCtx context.Context) phaseFn {
+	next := 0
+	for ; next < mgr.limit; next++ {
+		mgr.launch(groupCtx, mgr.tasks[next])
+	}
+	for next < len(mgr.tasks) {
 		select {
 		case report := <-mgr.reportCh:
-			delete(mgr.awaiting, report.task)
-			mgr.results[report.task] = report.result
+			mgr.collect(report)
 			if report.result != nil {
-				mgr.firstErr = report.result
+				mgr.exit(ExitReason_childError, report.result)
+				mgr.skip(mgr.tasks[next:])
 				return mgr._halting
 			}
+			mgr.launch(groupCtx, mgr.tasks[next])
+			next++
 		case <-parentCtx.Done():
-			mgr.firstErr = parentCtx.Err()
+			mgr.exit(ExitReason_parentCancelled, parentCtx.Err())
+			mgr.skip(mgr.tasks[next:])
 			return mgr._halting
+		case <-mgr.softStopCh:
+			mgr.skip(mgr.tasks[next:])
+			return mgr._collecting
 		}
 	}
-	return mgr._halt
+	return mgr._collecting
 }
 
-func (mgr *superviseFJ) _halting(_ context.Context) phaseFn {
-	atomic.StoreUint32(&mgr.phase, uint32(Phase_halting))
-
-	// We're halting, not entirely happily.  Cancel all children.
-	mgr.groupCancel()
-
-	// Keep watching reports.
-	for len(mgr.awaiting) > 0 {
-		report := <-mgr.reportCh
-		delete(mgr.awaiting, report.task)
-		mgr.results[report.task] = report.result
+// skip records tasks which will never be launched, and warns about them.
+func (mgr *superviseFJ) skip(tasks []*boundTask) {
+	for _, task := range tasks {
+		mgr.results[task] = &ErrChild{ErrTaskSkipped, false}
 	}
-
-	// Move on.
-	return mgr._halt
-}
-
-func (mgr *superviseFJ) _halt(_ context.Context) phaseFn {
-	atomic.StoreUint32(&mgr.phase, uint32(Phase_halt))
-	return nil
+	mgr.cfg.warn(SupervisionWarning{
+		Kind:           WarningKind_unlaunched,
+		SupervisorPath: mgr.path,
+		Message:        fmt.Sprintf("%d tasks were never launched because the supervisor is winding down", len(tasks)),
+	})
 }
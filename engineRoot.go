@@ -2,7 +2,7 @@ package sup
 
 import (
 	"context"
-	"path/filepath"
+	"time"
 )
 
 type superviseRoot struct {
@@ -16,6 +16,30 @@ func (superviseRoot) Phase() Phase {
 	return Phase_collecting
 }
 
+func (mgr superviseRoot) ExitReason() ExitReason {
+	return mgr.task.original.(Supervisor).ExitReason()
+}
+
+func (mgr superviseRoot) Await(ctx context.Context) error {
+	return mgr.task.original.(Supervisor).Await(ctx)
+}
+
+func (mgr superviseRoot) QuitAggressively() {
+	mgr.task.original.(Supervisor).QuitAggressively()
+}
+
+func (mgr superviseRoot) QueueLatency() (max, mean time.Duration) {
+	return mgr.task.original.(Supervisor).QueueLatency()
+}
+
+func (mgr superviseRoot) Find(path string) (TaskInfo, bool) {
+	return mgr.task.original.(Supervisor).Find(path)
+}
+
+func (mgr superviseRoot) SoftStop() {
+	mgr.task.original.(Supervisor).SoftStop()
+}
+
 func (mgr superviseRoot) init(task Supervisor) Supervisor {
 	mgr.task = bindTask(task)
 	return &mgr
@@ -36,8 +60,8 @@ func (mgr superviseRoot) childLaunch(groupCtx context.Context, task *boundTask)
 		// TODO panic recovery
 		// also TODO this child launcher isn't *exactly* duped yet but it's close, refactor
 	}()
-	taskPath := filepath.Join(CtxTaskPath(groupCtx), task.name)
-	ctx := appendCtxInfo(groupCtx, ctxInfo{task, taskPath})
+	taskPath := joinTaskPath(CtxTaskPath(groupCtx), task.name)
+	ctx := appendCtxInfo(groupCtx, ctxInfo{task: task, path: taskPath})
 	childErr = task.original.Run(ctx)
 	return
 }
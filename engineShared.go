@@ -3,7 +3,12 @@ package sup
 import (
 	"context"
 	"fmt"
-	"path/filepath"
+	"log/slog"
+	"runtime"
+	"runtime/pprof"
+	"strings"
+	"sync/atomic"
+	"time"
 )
 
 type Phase uint32
@@ -17,6 +22,36 @@ const (
 	Phase_halt         = Phase(5) // all tasks have returned, we're done here and you can have the final result.
 )
 
+// ExitReason describes why a supervisor stopped.
+// Unlike the error returned from Run, it distinguishes between being
+// cancelled by our parent and a child that failed with context.Canceled.
+type ExitReason uint32
+
+const (
+	ExitReason_notFinished     = ExitReason(0) // the supervisor is still accepting or collecting tasks (or hasn't been run yet).
+	ExitReason_drained         = ExitReason(1) // all tasks completed successfully, and there was no more work.
+	ExitReason_parentCancelled = ExitReason(2) // the context the supervisor was run with was cancelled.
+	ExitReason_childError      = ExitReason(3) // a child task returned an error (or panicked), so the rest were cancelled.
+	ExitReason_aborted         = ExitReason(4) // the supervisor gave up on its children without collecting them.  (None of the supervisors in this package do this yet.)
+)
+
+func (r ExitReason) String() string {
+	switch r {
+	case ExitReason_notFinished:
+		return "not-finished"
+	case ExitReason_drained:
+		return "drained"
+	case ExitReason_parentCancelled:
+		return "parent-cancelled"
+	case ExitReason_childError:
+		return "child-error"
+	case ExitReason_aborted:
+		return "aborted"
+	default:
+		return "unknown"
+	}
+}
+
 type phaseFn func(parentCtx context.Context) phaseFn
 
 type reportMsg struct {
@@ -39,16 +74,348 @@ func (e ErrChild) Error() string {
 	return e.Err.Error()
 }
 
+// supervision holds the configuration of a supervisor, as assembled from
+// SupervisionOptions at construction time.  It's immutable after that,
+// except that prepare fills in the logger from the context, if none was set.
+type supervision struct {
+	logger           *slog.Logger
+	warningHandler   func(SupervisionWarning)
+	runawayThreshold time.Duration
+	callbackWatchdog time.Duration
+	childStartHook   func(TaskInfo)
+	childExitHook    func(TaskInfo, error)
+	nameStrategy     NameStrategy
+	maxTaskNameLen   int
+	noPprofLabels    bool
+	exposeTaskPath   bool
+}
+
+func applyOptions(opts []SupervisionOptions) supervision {
+	cfg := supervision{
+		runawayThreshold: 2 * time.Second,
+		maxTaskNameLen:   defaultMaxTaskNameLen,
+	}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return cfg
+}
+
+func (cfg supervision) warn(w SupervisionWarning) {
+	handler := cfg.warningHandler
+	if handler == nil {
+		handler = SlogWarningHandler(cfg.logger)
+	}
+	cfg.guard(w.SupervisorPath, "warning handler", func() { handler(w) })
+}
+
+// guard calls a user-supplied callback.  If the callback watchdog is
+// enabled, the callback runs on its own goroutine, and if it hasn't
+// returned by the time the watchdog expires, we emit a stuck-callback
+// warning and move on without it.  Returns false in that case.
+//
+// The stuck-callback warning always goes to the slog handler -- the
+// user's warning handler may well be the very callback that's stuck.
+func (cfg supervision) guard(supervisorPath string, what string, fn func()) bool {
+	if cfg.callbackWatchdog <= 0 {
+		fn()
+		return true
+	}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		fn()
+	}()
+	timer := time.NewTimer(cfg.callbackWatchdog)
+	defer timer.Stop()
+	select {
+	case <-done:
+		return true
+	case <-timer.C:
+	}
+	stack := make([]byte, 16<<10)
+	stack = stack[:runtime.Stack(stack, true)]
+	SlogWarningHandler(cfg.logger)(SupervisionWarning{
+		Kind:           WarningKind_stuckCallback,
+		SupervisorPath: supervisorPath,
+		Message:        fmt.Sprintf("%s has not returned after %v; proceeding without it", what, cfg.callbackWatchdog),
+		Stack:          string(stack),
+	})
+	return false
+}
+
+// superviseCommon is the state and bookkeeping shared by the supervisor
+// engines.  Each engine embeds it, and provides its own Run and _running
+// phase; the collecting and halting phases are the same for all of them.
+type superviseCommon struct {
+	name        string
+	cfg         supervision
+	phase       uint32
+	exitReason  uint32
+	path        string // our own task path; sampled from the parent context when Run.
+	reportCh    chan reportMsg
+	groupCancel func()
+	awaiting    map[*boundTask]struct{}
+	names       map[string]int // count of awaited tasks by name, for noticing collisions.
+	results     map[*boundTask]*ErrChild
+	firstErr    error
+	doneCh      chan struct{} // closed on reaching Phase_halt.  Made at init, since Await may be called before Run.
+	detached    *detachments  // contexts our children have detached; see Detach.
+	softStopCh  chan struct{} // closed by SoftStop.  Made at init, too.
+	live        *liveTasks    // our running children, for Find.  Made at init, too.
+	softStopped uint32        // set (atomically) by the first SoftStop, which closes softStopCh.
+	quitCh      chan struct{} // closed by QuitAggressively.  Made at init, too.
+	quit        uint32        // set (atomically) by the first QuitAggressively, which closes quitCh.
+
+	// Queue latency totals, in nanoseconds; updated atomically, by the children.
+	queueLatencySum   int64
+	queueLatencyCount int64
+	queueLatencyMax   int64
+}
+
+func (mgr *superviseCommon) Phase() Phase {
+	return Phase(atomic.LoadUint32(&mgr.phase))
+}
+
+func (mgr *superviseCommon) ExitReason() ExitReason {
+	return ExitReason(atomic.LoadUint32(&mgr.exitReason))
+}
+
+func (mgr *superviseCommon) Name() string {
+	return mgr.name
+}
+
+func (mgr *superviseCommon) Await(ctx context.Context) error {
+	select {
+	case <-mgr.doneCh:
+		return mgr.firstErr
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (mgr *superviseCommon) SoftStop() {
+	if atomic.CompareAndSwapUint32(&mgr.softStopped, 0, 1) {
+		close(mgr.softStopCh)
+	}
+}
+
+func (mgr *superviseCommon) QuitAggressively() {
+	if atomic.CompareAndSwapUint32(&mgr.quit, 0, 1) {
+		close(mgr.quitCh)
+	}
+}
+
+// quittable returns the parent context as the supervisor sees it: one
+// which is also cancelled by QuitAggressively.
+func (mgr *superviseCommon) quittable(parentCtx context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(parentCtx)
+	go func() {
+		select {
+		case <-mgr.quitCh:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	return ctx, cancel
+}
+
+func (mgr *superviseCommon) QueueLatency() (max, mean time.Duration) {
+	n := atomic.LoadInt64(&mgr.queueLatencyCount)
+	if n == 0 {
+		return 0, 0
+	}
+	return time.Duration(atomic.LoadInt64(&mgr.queueLatencyMax)), time.Duration(atomic.LoadInt64(&mgr.queueLatencySum) / n)
+}
+
+func (mgr *superviseCommon) recordQueueLatency(d time.Duration) {
+	atomic.AddInt64(&mgr.queueLatencySum, int64(d))
+	atomic.AddInt64(&mgr.queueLatencyCount, 1)
+	for {
+		max := atomic.LoadInt64(&mgr.queueLatencyMax)
+		if int64(d) <= max || atomic.CompareAndSwapInt64(&mgr.queueLatencyMax, max, int64(d)) {
+			return
+		}
+	}
+}
+
+// exit records the reason we're leaving the running/collecting phases,
+// and the error we'll return (if any).  Call it exactly once.
+func (mgr *superviseCommon) exit(reason ExitReason, err error) {
+	mgr.firstErr = err
+	atomic.StoreUint32(&mgr.exitReason, uint32(reason))
+}
+
+// prepare allocates statekeepers and builds the child status channel
+// we'll be watching, plus the groupCtx which will let us cancel all
+// children in bulk.  The groupCtx is returned; it also carries our soft
+// stop channel, for the children to inherit.
+func (mgr *superviseCommon) prepare(parentCtx context.Context, sizeHint int) context.Context {
+	info, _ := parentCtx.Value(ctxKey{}).(ctxInfo)
+	mgr.path = info.path
+	mgr.live.setPath(mgr.path)
+	if mgr.cfg.logger == nil {
+		mgr.cfg.logger = info.logger // from CtxWithLogger, if any; SetLogger wins, though.
+	}
+	mgr.awaiting = make(map[*boundTask]struct{}, sizeHint)
+	mgr.names = make(map[string]int, sizeHint)
+	mgr.results = make(map[*boundTask]*ErrChild, sizeHint)
+	mgr.reportCh = make(chan reportMsg)
+	mgr.detached = &detachments{live: make(map[string]int)}
+	groupCtx, groupCancel := context.WithCancel(parentCtx)
+	mgr.groupCancel = groupCancel
+
+	// Children get our soft stop channel; and a soft stop from above is
+	//  passed on to it.
+	if parentSoftStop := info.softStop; parentSoftStop != nil {
+		go func() {
+			select {
+			case <-parentSoftStop:
+				mgr.SoftStop()
+			case <-mgr.doneCh:
+			}
+		}()
+	}
+	info.softStop = mgr.softStopCh
+	return context.WithValue(groupCtx, ctxKey{}, info)
+}
+
+// launch starts a goroutine for the task, and starts awaiting its report.
+// If the task's name has placeholders, or is blank, it's filled in first,
+// and then it's sanitized.  (The requested name is kept too, sanitized.)
+func (mgr *superviseCommon) launch(groupCtx context.Context, task *boundTask) {
+	task.requested = cleanTaskName(task.name, mgr.cfg.maxTaskNameLen)
+	if task.name == "" || strings.Contains(task.name, "%") {
+		task.name = mgr.selectName(task.name)
+	}
+	task.name = mgr.sanitizeName(task.name)
+	mgr.awaiting[task] = struct{}{}
+	mgr.names[task.name]++
+	if mgr.names[task.name] == 2 {
+		mgr.cfg.warn(SupervisionWarning{
+			Kind:           WarningKind_nameCollision,
+			SupervisorPath: mgr.path,
+			TaskPath:       joinTaskPath(mgr.path, task.name),
+			Message:        fmt.Sprintf("more than one task named %q is running in this supervisor", task.name),
+		})
+	}
+	mgr.live.add(task)
+	go childLaunch(groupCtx, mgr.reportCh, task, mgr, time.Now())
+}
+
+// collect records a child's report, and calls the exit hook, if any.
+func (mgr *superviseCommon) collect(report reportMsg) {
+	delete(mgr.awaiting, report.task)
+	mgr.live.remove(report.task)
+	if mgr.names[report.task.name]--; mgr.names[report.task.name] == 0 {
+		delete(mgr.names, report.task.name)
+	}
+	mgr.results[report.task] = report.result
+	if hook := mgr.cfg.childExitHook; hook != nil {
+		info := report.task.info(mgr.path)
+		var err error
+		if report.result != nil {
+			err = report.result
+		}
+		mgr.cfg.guard(mgr.path, "child exit hook", func() { hook(info, err) })
+	}
+}
+
+func (mgr *superviseCommon) _collecting(parentCtx context.Context) phaseFn {
+	atomic.StoreUint32(&mgr.phase, uint32(Phase_collecting))
+
+	// We're not accepting new tasks anymore, so this loop is now only
+	//  for collecting results or accepting a group cancel instruction;
+	//  and it can move directly to halt if there are no disruptions.
+	for len(mgr.awaiting) > 0 {
+		select {
+		case report := <-mgr.reportCh:
+			mgr.collect(report)
+			if report.result != nil {
+				mgr.exit(ExitReason_childError, report.result)
+				return mgr._halting
+			}
+		case <-parentCtx.Done():
+			mgr.exit(ExitReason_parentCancelled, parentCtx.Err())
+			return mgr._halting
+		}
+	}
+	mgr.exit(ExitReason_drained, nil)
+	return mgr._halt
+}
+
+func (mgr *superviseCommon) _halting(_ context.Context) phaseFn {
+	atomic.StoreUint32(&mgr.phase, uint32(Phase_halting))
+
+	// We're halting, not entirely happily.  Cancel all children.
+	mgr.groupCancel()
+
+	// Keep watching reports.
+	//  If some children are slow to return after being cancelled, warn
+	//  about them (once); they're probably not minding their context.
+	var runawayAlarm <-chan time.Time
+	if mgr.cfg.runawayThreshold > 0 && len(mgr.awaiting) > 0 {
+		timer := time.NewTimer(mgr.cfg.runawayThreshold)
+		defer timer.Stop()
+		runawayAlarm = timer.C
+	}
+	for len(mgr.awaiting) > 0 {
+		select {
+		case report := <-mgr.reportCh:
+			mgr.collect(report)
+		case <-runawayAlarm:
+			runawayAlarm = nil
+			for task := range mgr.awaiting {
+				mgr.cfg.warn(SupervisionWarning{
+					Kind:           WarningKind_slowCancel,
+					SupervisorPath: mgr.path,
+					TaskPath:       joinTaskPath(mgr.path, task.name),
+					Message:        fmt.Sprintf("task has not returned %v after cancellation", mgr.cfg.runawayThreshold),
+				})
+			}
+		}
+	}
+
+	// Move on.
+	return mgr._halt
+}
+
+func (mgr *superviseCommon) _halt(_ context.Context) phaseFn {
+	atomic.StoreUint32(&mgr.phase, uint32(Phase_halt))
+	mgr.warnDetached()
+	close(mgr.doneCh)
+	return nil
+}
+
 // childLaunch is the first function on a child goroutine's stack.
 // It handles context tree extension, defer capturing, etc.
-func childLaunch(groupCtx context.Context, report chan<- reportMsg, task *boundTask) {
+// The start hook, if any, is called here too (so, a panic in it is handled
+// just like a panic from the task).  The task runs with a "sup_task" pprof
+// label, unless that's been disabled.
+func childLaunch(groupCtx context.Context, report chan<- reportMsg, task *boundTask, mgr *superviseCommon, submittedAt time.Time) {
+	startedAt := time.Now()
+	mgr.recordQueueLatency(startedAt.Sub(submittedAt))
 	var childErr error // The child's *returned* error is stored here.
+	exited := make(chan struct{})
 	defer func() {
+		close(exited)
 		report <- reportMsg{task, siftError(childErr, recover())}
 	}()
-	taskPath := filepath.Join(CtxTaskPath(groupCtx), task.name)
-	ctx := appendCtxInfo(groupCtx, ctxInfo{task, taskPath})
-	childErr = task.original.Run(ctx)
+	taskPath := joinTaskPath(CtxTaskPath(groupCtx), task.name)
+	ctx := appendCtxInfo(groupCtx, ctxInfo{task: task, path: taskPath, cfg: &mgr.cfg, mgr: mgr, submittedAt: submittedAt, startedAt: startedAt, exited: exited})
+	if mgr.cfg.exposeTaskPath {
+		ctx = context.WithValue(ctx, TaskPathContextKey, taskPath)
+	}
+	if mgr.cfg.childStartHook != nil {
+		mgr.cfg.childStartHook(task.info(mgr.path))
+	}
+	if mgr.cfg.noPprofLabels {
+		childErr = task.original.Run(ctx)
+		return
+	}
+	pprof.Do(ctx, pprof.Labels("sup_task", taskPath), func(ctx context.Context) {
+		childErr = task.original.Run(ctx)
+	})
 }
 
 func siftError(retErr error, rcvr interface{}) *ErrChild {
@@ -2,34 +2,25 @@ package sup
 
 import (
 	"context"
+	"fmt"
 	"sync/atomic"
 )
 
 type superviseStream struct {
-	name        string
-	taskGen     TaskGen
-	phase       uint32
-	reportCh    <-chan reportMsg
-	groupCancel func()
-	awaiting    map[*boundTask]struct{}
-	results     map[*boundTask]*ErrChild
-	firstErr    error
-}
-
-func (mgr superviseStream) Phase() Phase {
-	return Phase(atomic.LoadUint32(&mgr.phase))
+	superviseCommon
+	taskGen TaskGen
 }
 
 func (mgr superviseStream) init(tg TaskGen) Supervisor {
 	mgr.phase = uint32(Phase_init)
+	mgr.doneCh = make(chan struct{})
+	mgr.softStopCh = make(chan struct{})
+	mgr.quitCh = make(chan struct{})
+	mgr.live = &liveTasks{}
 	mgr.taskGen = tg
 	return &mgr
 }
 
-func (mgr superviseStream) Name() string {
-	return mgr.name
-}
-
 func (mgr *superviseStream) Run(parentCtx context.Context) error {
 	// Enforce single-run under mutex for sanity.
 	ok := atomic.CompareAndSwapUint32(&mgr.phase, uint32(Phase_init), uint32(Phase_running))
@@ -37,9 +28,8 @@ func (mgr *superviseStream) Run(parentCtx context.Context) error {
 		panic("supervisor can only be Run() once!")
 	}
 
-	// Allocate statekeepers.
-	mgr.awaiting = make(map[*boundTask]struct{})
-	mgr.results = make(map[*boundTask]*ErrChild)
+	parentCtx, cancel := mgr.quittable(parentCtx)
+	defer cancel()
 
 	// Step through phases (the halting phase will return a nil next phase).
 	for phase := mgr._running; phase != nil; {
@@ -50,12 +40,7 @@ func (mgr *superviseStream) Run(parentCtx context.Context) error {
 }
 
 func (mgr *superviseStream) _running(parentCtx context.Context) phaseFn {
-	// Build the child status channel we'll be watching,
-	// and the groupCtx which will let us cancel all children in bulk.
-	reportCh := make(chan reportMsg)
-	mgr.reportCh = reportCh
-	groupCtx, groupCancel := context.WithCancel(parentCtx)
-	mgr.groupCancel = groupCancel
+	groupCtx := mgr.prepare(parentCtx, 0)
 
 	// Loop selecting over new task submissions, result collection, or
 	//  accepting a group cancel instruction.  We'll only break out on
@@ -66,64 +51,34 @@ func (mgr *superviseStream) _running(parentCtx context.Context) phaseFn {
 			if !ok {
 				return mgr._collecting
 			}
-			task := bindTask(newTask)
-			mgr.awaiting[task] = struct{}{}
-			go childLaunch(groupCtx, reportCh, task)
-		case report := <-reportCh:
-			delete(mgr.awaiting, report.task)
-			mgr.results[report.task] = report.result
-			if report.result != nil {
-				mgr.firstErr = report.result
-				return mgr._halting
-			}
-		case <-parentCtx.Done():
-			mgr.firstErr = parentCtx.Err()
-			return mgr._halting
-		}
-	}
-}
-
-func (mgr *superviseStream) _collecting(parentCtx context.Context) phaseFn {
-	atomic.StoreUint32(&mgr.phase, uint32(Phase_collecting))
-
-	// We're not accepting new tasks anymore, so this loop is now only
-	//  for collecting results or accepting a group cancel instruction;
-	//  and it can move directly to halt if there are no disruptions.
-	for len(mgr.awaiting) > 0 {
-		select {
+			mgr.launch(groupCtx, bindTask(newTask))
 		case report := <-mgr.reportCh:
-			delete(mgr.awaiting, report.task)
-			mgr.results[report.task] = report.result
+			mgr.collect(report)
 			if report.result != nil {
-				mgr.firstErr = report.result
+				mgr.exit(ExitReason_childError, report.result)
+				mgr.warnUnlaunched()
 				return mgr._halting
 			}
 		case <-parentCtx.Done():
-			mgr.firstErr = parentCtx.Err()
+			mgr.exit(ExitReason_parentCancelled, parentCtx.Err())
+			mgr.warnUnlaunched()
 			return mgr._halting
+		case <-mgr.softStopCh:
+			mgr.warnUnlaunched()
+			return mgr._collecting
 		}
 	}
-	return mgr._halt
 }
 
-func (mgr *superviseStream) _halting(_ context.Context) phaseFn {
-	atomic.StoreUint32(&mgr.phase, uint32(Phase_halting))
-
-	// We're halting, not entirely happily.  Cancel all children.
-	mgr.groupCancel()
-
-	// Keep watching reports.
-	for len(mgr.awaiting) > 0 {
-		report := <-mgr.reportCh
-		delete(mgr.awaiting, report.task)
-		mgr.results[report.task] = report.result
+// warnUnlaunched emits a warning if we're winding down while tasks are
+// still buffered in the taskgen channel.  (We can't see any tasks the
+// producer hasn't sent yet, of course; this is a best-effort notice.)
+func (mgr *superviseStream) warnUnlaunched() {
+	if n := len(mgr.taskGen); n > 0 {
+		mgr.cfg.warn(SupervisionWarning{
+			Kind:           WarningKind_unlaunched,
+			SupervisorPath: mgr.path,
+			Message:        fmt.Sprintf("%d tasks were never launched because the supervisor is winding down", n),
+		})
 	}
-
-	// Move on.
-	return mgr._halt
-}
-
-func (mgr *superviseStream) _halt(_ context.Context) phaseFn {
-	atomic.StoreUint32(&mgr.phase, uint32(Phase_halt))
-	return nil
 }
@@ -0,0 +1,140 @@
+package sup
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"time"
+)
+
+// Every returns a task which calls fn periodically, once per interval,
+// until its context is done (then it returns the context's error), or the
+// supervisor asks for a soft stop (then it returns nil), or fn returns an
+// error.  A call which is underway when the context is cancelled isn't
+// interrupted: fn gets the task's context, and is expected to mind it, as
+// any task would.
+//
+// By default, the first call is one interval after the task starts, each
+// call after that is one interval after the previous one returned (so a
+// slow call pushes the rest back), and an error from fn ends the task,
+// with that error (except ErrLoopDone, which ends it with nil).  The
+// options change these: see EveryFixedRate, EveryJitter, EveryImmediately,
+// and EveryOnError.
+//
+// It panics if the interval isn't positive.
+func Every(interval time.Duration, fn func(Context) error, opts ...EveryOption) Task {
+	if interval <= 0 {
+		panic("usage: Every needs a positive interval")
+	}
+	t := everyTask{interval: interval, fn: fn}
+	for _, opt := range opts {
+		opt(&t)
+	}
+	return t
+}
+
+// EveryOption configures a task made by Every.
+type EveryOption func(*everyTask)
+
+// EveryFixedRate makes calls keep to a fixed schedule, one per interval
+// since the task started, rather than waiting an interval after each call
+// returns.  If a call runs so long that it misses some times on the
+// schedule, they're skipped, not made up for: the next call is at the next
+// time on the schedule.
+func EveryFixedRate() EveryOption {
+	return func(t *everyTask) {
+		t.fixedRate = true
+	}
+}
+
+// EveryJitter makes each wait longer by a random amount, of up to the given
+// fraction of the interval, so that many tasks started at once don't all
+// call at once, forever after.  A fraction of 0.1 with an interval of a
+// minute means each call is up to six seconds late.  (With EveryFixedRate,
+// lateness doesn't accumulate: the schedule itself isn't changed.)
+func EveryJitter(fraction float64) EveryOption {
+	if fraction < 0 {
+		panic("usage: EveryJitter needs a fraction which isn't negative")
+	}
+	return func(t *everyTask) {
+		t.jitter = fraction
+	}
+}
+
+// EveryImmediately makes the first call as soon as the task starts, rather
+// than an interval later.
+func EveryImmediately() EveryOption {
+	return func(t *everyTask) {
+		t.immediately = true
+	}
+}
+
+// EveryOnError sets a function which decides what happens when fn returns
+// an error (other than ErrLoopDone): if it returns nil, the task carries on
+// as if the call succeeded; if it returns an error, the task ends with that
+// error.  It's for logging errors and moving on, say, or for stopping only
+// after several in a row.  It's called on the task's own goroutine.
+func EveryOnError(onError func(ctx Context, err error) error) EveryOption {
+	return func(t *everyTask) {
+		t.onError = onError
+	}
+}
+
+type everyTask struct {
+	interval    time.Duration
+	fn          func(Context) error
+	fixedRate   bool
+	jitter      float64
+	immediately bool
+	onError     func(Context, error) error
+}
+
+func (t everyTask) Run(ctx context.Context) error {
+	softStop := SoftStopCh(ctx)
+	next := time.Now()
+	if !t.immediately {
+		next = next.Add(t.interval)
+	}
+	timer := time.NewTimer(t.jittered(time.Until(next)))
+	defer timer.Stop()
+	for {
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-softStop:
+			return nil
+		}
+		if err := ctx.Err(); err != nil {
+			return err // the timer and the context were both ready; the context wins.
+		}
+		if err := t.fn(ctx); err != nil {
+			if errors.Is(err, ErrLoopDone) {
+				return nil
+			}
+			if t.onError == nil {
+				return err
+			}
+			if err := t.onError(ctx, err); err != nil {
+				return err
+			}
+		}
+		now := time.Now()
+		if t.fixedRate {
+			for next = next.Add(t.interval); !next.After(now); next = next.Add(t.interval) {
+				// Skip the times we missed.
+			}
+		} else {
+			next = now.Add(t.interval)
+		}
+		timer.Reset(t.jittered(next.Sub(now)))
+	}
+}
+
+// jittered adds the jitter, if any, to a wait.
+func (t everyTask) jittered(d time.Duration) time.Duration {
+	if t.jitter == 0 {
+		return d
+	}
+	return d + time.Duration(rand.Float64()*t.jitter*float64(t.interval))
+}
@@ -0,0 +1,94 @@
+package sup_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/warpfork/go-sup"
+)
+
+// everyTimes runs an Every task until its function has been called the
+// given number of times, and returns when each call started, measured from
+// when the task started.  The first call takes the given time.
+func everyTimes(calls int, interval, firstCallTakes time.Duration, opts ...sup.EveryOption) []time.Duration {
+	var times []time.Duration
+	start := time.Now()
+	task := sup.Every(interval, func(context.Context) error {
+		times = append(times, time.Since(start))
+		if len(times) == 1 {
+			time.Sleep(firstCallTakes)
+		}
+		if len(times) == calls {
+			return sup.ErrLoopDone
+		}
+		return nil
+	}, opts...)
+	task.Run(context.Background())
+	return times
+}
+
+func TestEvery(t *testing.T) {
+	const ms = time.Millisecond
+	t.Run("fixed delay should wait after each call", func(t *testing.T) {
+		times := everyTimes(2, 200*ms, 300*ms, sup.EveryImmediately())
+		shouldEqual(t, times[1] >= 500*ms, true)
+	})
+	t.Run("fixed rate should keep to the schedule, skipping missed times", func(t *testing.T) {
+		times := everyTimes(2, 200*ms, 300*ms, sup.EveryImmediately(), sup.EveryFixedRate())
+		shouldEqual(t, times[1] >= 400*ms && times[1] < 500*ms, true)
+	})
+	t.Run("immediately should make the first call at once", func(t *testing.T) {
+		times := everyTimes(2, 100*ms, 0, sup.EveryImmediately())
+		shouldEqual(t, times[0] < 50*ms, true)
+		shouldEqual(t, times[1] >= 100*ms, true)
+		times = everyTimes(1, 100*ms, 0)
+		shouldEqual(t, times[0] >= 100*ms, true)
+	})
+	t.Run("jitter should only make waits longer", func(t *testing.T) {
+		times := everyTimes(3, 20*ms, 0, sup.EveryJitter(1))
+		shouldEqual(t, times[0] >= 20*ms, true)
+		shouldEqual(t, times[2]-times[1] >= 20*ms, true)
+	})
+	t.Run("errors should end the task by default", func(t *testing.T) {
+		calls := 0
+		err := sup.Every(ms, func(context.Context) error { calls++; return errors.New("boom") }).Run(context.Background())
+		shouldEqual(t, fmt.Sprint(err), "boom")
+		shouldEqual(t, calls, 1)
+	})
+	t.Run("the error policy should decide whether to carry on", func(t *testing.T) {
+		calls, seen := 0, 0
+		err := sup.Every(ms, func(context.Context) error { calls++; return errors.New("boom") },
+			sup.EveryOnError(func(ctx context.Context, err error) error {
+				if seen++; seen == 3 {
+					return fmt.Errorf("three strikes: %w", err)
+				}
+				return nil
+			}),
+		).Run(context.Background())
+		shouldEqual(t, fmt.Sprint(err), "three strikes: boom")
+		shouldEqual(t, calls, 3)
+	})
+	t.Run("cancellation should end the wait promptly", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*ms)
+		defer cancel()
+		start := time.Now()
+		err := sup.Every(time.Hour, func(context.Context) error { return nil }).Run(ctx)
+		shouldEqual(t, errors.Is(err, context.DeadlineExceeded), true)
+		shouldEqual(t, time.Since(start) < time.Second, true)
+	})
+	t.Run("a soft stop should end the task", func(t *testing.T) {
+		var svr sup.Supervisor
+		calls := 0
+		svr = sup.SuperviseForkJoin("main", []sup.Task{sup.Every(ms, func(context.Context) error {
+			if calls++; calls == 2 {
+				svr.SoftStop()
+			}
+			return nil
+		})})
+		shouldEqual(t, sup.SuperviseRoot(context.Background(), svr), nil)
+		shouldEqual(t, calls, 2)
+	})
+}
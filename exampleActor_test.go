@@ -0,0 +1,41 @@
+package sup_test
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/warpfork/go-sup"
+)
+
+// ExampleActor is ping-pong again (compare the pinger and ponger in the
+// stepped task tests), with actors: each is just its handler, and the
+// wiring is one line each way.  The pinger stops after three rounds, which
+// closes the ponger's inbox, so the ponger stops too.
+func ExampleActor() {
+	ping := sup.NewActor("ping", func(ctx sup.Context, n int, out sup.SenderChannel[int]) error {
+		if n == 3 {
+			return sup.ErrLoopDone
+		}
+		fmt.Println("ping", n+1)
+		return sup.Select(ctx, out.SendAndThen(n+1, nil))
+	}, sup.ActorInboxCapacity(1))
+	pong := sup.NewActor("pong", func(ctx sup.Context, n int, out sup.SenderChannel[int]) error {
+		fmt.Println("pong", n)
+		return sup.Select(ctx, out.SendAndThen(n, nil))
+	})
+	ping.SetOutbox(pong.Inbox())
+	pong.SetOutbox(ping.Inbox())
+
+	ping.Inbox().TrySend(0) // serve.
+	err := sup.SuperviseRoot(context.Background(), sup.SuperviseForkJoin("main", []sup.Task{ping, pong}))
+	fmt.Println(err)
+
+	// Output:
+	// ping 1
+	// pong 1
+	// ping 2
+	// pong 2
+	// ping 3
+	// pong 3
+	// <nil>
+}
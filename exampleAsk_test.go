@@ -0,0 +1,50 @@
+package sup_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/warpfork/go-sup"
+)
+
+// This example shows both halves of the Ask pattern.  The server is an
+// actor whose inbox carries Envelopes; it answers each one with Reply.
+// The client calls Ask, which sends the request and waits for that reply
+// -- and if either side is cancelled, neither is left stuck.
+func ExampleAsk() {
+	inboxTx, inboxRx := sup.NewChannel[sup.Envelope[string, string]]("shouter", 0)
+
+	err := sup.SuperviseRoot(context.Background(),
+		sup.SuperviseForkJoin("main", []sup.Task{
+			namedFunc{"server", func(ctx context.Context) error {
+				return sup.SelectLoop(ctx, inboxRx.RecvOrClosed(func(env sup.Envelope[string, string], ok bool) error {
+					if !ok {
+						return sup.ErrLoopDone
+					}
+					if env.Request == "" {
+						env.Reply("", fmt.Errorf("nothing to shout"))
+						return nil
+					}
+					env.Reply(strings.ToUpper(env.Request)+"!", nil)
+					return nil
+				}))
+			}},
+			namedFunc{"client", func(ctx context.Context) error {
+				defer inboxTx.Close()
+				for _, req := range []string{"hello", "", "bye"} {
+					resp, err := sup.Ask(ctx, inboxTx, req)
+					fmt.Printf("asked %q: got %q, error: %v\n", req, resp, err)
+				}
+				return nil
+			}},
+		}),
+	)
+	fmt.Printf("supervisor error: %v\n", err)
+
+	// Output:
+	// asked "hello": got "HELLO!", error: <nil>
+	// asked "": got "", error: nothing to shout
+	// asked "bye": got "BYE!", error: <nil>
+	// supervisor error: <nil>
+}
@@ -0,0 +1,57 @@
+package sup_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/warpfork/go-sup"
+)
+
+// ExampleHTTPServerTask shows an http.Server being shut down gracefully:
+// the request in flight when the context is cancelled still gets its
+// response, and the supervisor doesn't return until it has.
+func ExampleHTTPServerTask() {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		panic(err)
+	}
+	handling := make(chan struct{})
+	srv := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Println("handling a request in", sup.CtxTaskPath(r.Context()))
+		close(handling)
+		time.Sleep(50 * time.Millisecond)
+		fmt.Fprint(w, "hello")
+	})}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	err = sup.SuperviseRoot(ctx, sup.SuperviseForkJoin("main", []sup.Task{
+		sup.HTTPServerTaskWithListener("http", srv, ln, time.Second),
+		sup.NamedFunc("client", func(ctx context.Context) error {
+			resp, err := http.Get("http://" + ln.Addr().String())
+			if err != nil {
+				return err
+			}
+			defer resp.Body.Close()
+			body, err := io.ReadAll(resp.Body)
+			fmt.Println("client got", string(body))
+			return err
+		}),
+		sup.NamedFunc("canceller", func(ctx context.Context) error {
+			<-handling
+			fmt.Println("cancelling")
+			cancel()
+			return nil
+		}),
+	}))
+	fmt.Println("supervisor returned:", err)
+
+	// Output:
+	// handling a request in main/http
+	// cancelling
+	// client got hello
+	// supervisor returned: context canceled
+}
@@ -0,0 +1,33 @@
+package sup_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/warpfork/go-sup"
+)
+
+// ExampleMainSupervisor shows the shape of a go-sup program's main
+// function: the program's tasks go under a MainSupervisor, which is run by
+// SuperviseRoot.  Ctrl-C asks the tasks to finish up (the server shuts down
+// gracefully, and the ticker returns at its next tick); a second Ctrl-C
+// cancels them.
+//
+// (It isn't run as a test, since it waits for a signal.)
+func ExampleMainSupervisor() {
+	server := &http.Server{Addr: "localhost:8080"}
+	tasks := []sup.Task{
+		sup.HTTPServerTask("http", server, 10*time.Second),
+		sup.Every(time.Minute, func(ctx context.Context) error {
+			fmt.Println("still here")
+			return nil
+		}),
+	}
+	if err := sup.SuperviseRoot(context.Background(), sup.MainSupervisor("main", tasks)); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
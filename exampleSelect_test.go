@@ -0,0 +1,173 @@
+package sup_test
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/warpfork/go-sup"
+)
+
+// This example plays ping-pong between two tasks.  Every send and receive
+// is done with sup.Select, so if either player is cancelled (or the other
+// one fails), neither can get stuck waiting forever on a channel that the
+// other side has stopped servicing.
+func ExampleSelect() {
+	pingCh := make(chan int)
+	pongCh := make(chan int)
+	toPong := sup.SenderChannel[int]{Chan: pingCh}
+	fromPing := sup.ReceiverChannel[int]{Chan: pingCh}
+	toPing := sup.SenderChannel[int]{Chan: pongCh}
+	fromPong := sup.ReceiverChannel[int]{Chan: pongCh}
+
+	const volleys = 3
+	err := sup.SuperviseRoot(context.Background(),
+		sup.SuperviseForkJoin("game", []sup.Task{
+			namedFunc{"ping", func(ctx context.Context) error {
+				ball := 0
+				for i := 0; i < volleys; i++ {
+					if err := sup.Select(ctx, toPong.SendAndThen(ball, nil)); err != nil {
+						return err
+					}
+					if err := sup.Select(ctx, fromPong.RecvAndThen(func(v int) error {
+						fmt.Printf("ping got %d\n", v)
+						ball = v + 1
+						return nil
+					})); err != nil {
+						return err
+					}
+				}
+				return nil
+			}},
+			namedFunc{"pong", func(ctx context.Context) error {
+				for i := 0; i < volleys; i++ {
+					var ball int
+					if err := sup.Select(ctx, fromPing.RecvAndThen(func(v int) error {
+						fmt.Printf("pong got %d\n", v)
+						ball = v + 1
+						return nil
+					})); err != nil {
+						return err
+					}
+					if err := sup.Select(ctx, toPing.SendAndThen(ball, nil)); err != nil {
+						return err
+					}
+				}
+				return nil
+			}},
+		}),
+	)
+	fmt.Printf("supervisor error: %v\n", err)
+
+	// Output:
+	// pong got 0
+	// ping got 1
+	// pong got 2
+	// ping got 3
+	// pong got 4
+	// ping got 5
+	// supervisor error: <nil>
+}
+
+// This is the same ping-pong game as the Select example, written with the
+// imperative Send and Recv helpers instead.  When there's only one channel
+// operation to wait on at a time, these are shorter, and cheaper too.
+func ExampleSend() {
+	pingCh := make(chan int)
+	pongCh := make(chan int)
+
+	const volleys = 3
+	err := sup.SuperviseRoot(context.Background(),
+		sup.SuperviseForkJoin("game", []sup.Task{
+			namedFunc{"ping", func(ctx context.Context) error {
+				ball := 0
+				for i := 0; i < volleys; i++ {
+					if err := sup.Send(ctx, pingCh, ball); err != nil {
+						return err
+					}
+					v, _, err := sup.Recv(ctx, pongCh)
+					if err != nil {
+						return err
+					}
+					fmt.Printf("ping got %d\n", v)
+					ball = v + 1
+				}
+				return nil
+			}},
+			namedFunc{"pong", func(ctx context.Context) error {
+				for i := 0; i < volleys; i++ {
+					v, _, err := sup.Recv(ctx, pingCh)
+					if err != nil {
+						return err
+					}
+					fmt.Printf("pong got %d\n", v)
+					if err := sup.Send(ctx, pongCh, v+1); err != nil {
+						return err
+					}
+				}
+				return nil
+			}},
+		}),
+	)
+	fmt.Printf("supervisor error: %v\n", err)
+
+	// Output:
+	// pong got 0
+	// ping got 1
+	// pong got 2
+	// ping got 3
+	// pong got 4
+	// ping got 5
+	// supervisor error: <nil>
+}
+
+// Here, the pong player is an actor whose whole body is one SelectLoop.
+// It keeps answering until its inbox is closed, and then returns
+// sup.ErrLoopDone from its callback, which ends the loop without an error.
+func ExampleSelectLoop() {
+	pingCh := make(chan int)
+	pongCh := make(chan int)
+	fromPing := sup.ReceiverChannel[int]{Chan: pingCh}
+
+	const volleys = 3
+	err := sup.SuperviseRoot(context.Background(),
+		sup.SuperviseForkJoin("game", []sup.Task{
+			namedFunc{"ping", func(ctx context.Context) error {
+				defer close(pingCh)
+				ball := 0
+				for i := 0; i < volleys; i++ {
+					if err := sup.Send(ctx, pingCh, ball); err != nil {
+						return err
+					}
+					v, _, err := sup.Recv(ctx, pongCh)
+					if err != nil {
+						return err
+					}
+					fmt.Printf("ping got %d\n", v)
+					ball = v + 1
+				}
+				return nil
+			}},
+			namedFunc{"pong", func(ctx context.Context) error {
+				return sup.SelectLoop(ctx, fromPing.RecvOrClosed(func(v int, ok bool) error {
+					if !ok {
+						fmt.Println("pong done")
+						return sup.ErrLoopDone
+					}
+					fmt.Printf("pong got %d\n", v)
+					return sup.Send(ctx, pongCh, v+1)
+				}))
+			}},
+		}),
+	)
+	fmt.Printf("supervisor error: %v\n", err)
+
+	// Output:
+	// pong got 0
+	// ping got 1
+	// pong got 2
+	// ping got 3
+	// pong got 4
+	// ping got 5
+	// pong done
+	// supervisor error: <nil>
+}
@@ -0,0 +1,48 @@
+package sup_test
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/warpfork/go-sup"
+)
+
+// counter is an actor which adds up the numbers sent to its inbox, until
+// the inbox is closed.
+type counter struct {
+	inbox sup.ReceiverChannel[int]
+	total int
+}
+
+func (c *counter) Run(ctx context.Context) error {
+	return sup.RunSteps(ctx, c.step)
+}
+
+func (c *counter) step(ctx context.Context) error {
+	return sup.Select(ctx, c.inbox.RecvOrClosed(func(n int, ok bool) error {
+		if !ok {
+			return sup.ErrLoopDone
+		}
+		c.total += n
+		return nil
+	}))
+}
+
+// ExampleRunSteps shows the recommended way to write a task that's a loop:
+// a step method, which does one thing, and a Run method, which hands it to
+// RunSteps.  RunSteps takes care of checking for cancellation and soft
+// stops between steps.
+func ExampleRunSteps() {
+	tx, rx := sup.NewChannel[int]("numbers", 3)
+	for i := 1; i <= 3; i++ {
+		tx.TrySend(i)
+	}
+	tx.Close()
+
+	c := &counter{inbox: rx}
+	err := sup.SuperviseRoot(context.Background(), sup.SuperviseForkJoin("main", []sup.Task{c}))
+	fmt.Println(c.total, err)
+
+	// Output:
+	// 6 <nil>
+}
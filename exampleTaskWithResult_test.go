@@ -0,0 +1,40 @@
+package sup_test
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/warpfork/go-sup"
+)
+
+// This example shows how TaskWithResult lets each task hand back a typed
+// result through a promise, instead of every task gathering its output into
+// some shared variable behind a mutex.
+func ExampleTaskWithResult() {
+	var tasks []sup.Task
+	var results []sup.Promise
+	for _, n := range []int{1, 2, 3} {
+		task, result := sup.TaskWithResult(func(ctx context.Context) (int, error) {
+			return n * n, nil
+		})
+		tasks = append(tasks, task)
+		results = append(results, result)
+	}
+
+	err := sup.SuperviseRoot(context.Background(),
+		sup.SuperviseForkJoin("squares", tasks),
+	)
+	fmt.Printf("supervisor error: %v\n", err)
+
+	for _, result := range results {
+		v, err := sup.AwaitResult[int](context.Background(), result)
+		fmt.Printf("%v %v\n", v, err)
+	}
+
+	// Output:
+	//
+	// supervisor error: <nil>
+	// 1 <nil>
+	// 4 <nil>
+	// 9 <nil>
+}
@@ -0,0 +1,36 @@
+package sup_test
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+
+	"github.com/warpfork/go-sup"
+)
+
+// ExampleWorkerPool shows a pool of workers handling items fed to it by
+// another task.  The producer closes the pool when it's done, and the pool
+// returns once its workers have handled everything.
+func ExampleWorkerPool() {
+	var total atomic.Int64
+	pool := sup.NewWorkerPool("squarer", 4, func(ctx context.Context, n int) error {
+		total.Add(int64(n * n))
+		return nil
+	})
+	err := sup.SuperviseRoot(context.Background(), sup.SuperviseForkJoin("main", []sup.Task{
+		pool,
+		sup.NamedFunc("producer", func(ctx context.Context) error {
+			defer pool.Close()
+			for i := 1; i <= 10; i++ {
+				if err := pool.Submit(ctx, i); err != nil {
+					return err
+				}
+			}
+			return nil
+		}),
+	}))
+	fmt.Println(total.Load(), err)
+
+	// Output:
+	// 385 <nil>
+}
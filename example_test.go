@@ -19,7 +19,7 @@ import (
 // In Go with go-sup, this task is almost the same -- declaring the variable
 // to hold your gathered results and mutexing the gather is still considered
 // your application logic.  Go-sup handles the goroutine launch and waitgroup.
-// In this example, we used a TasksFromMap helper function to generate tasks,
+// In this example, we used a TasksForMap helper function to generate tasks,
 // but you can take manual control over this or use other helpers.
 //
 // In addition, go-sup takes care of:
@@ -51,9 +51,7 @@ func ExampleSuperviseForkJoin() {
 	//     - do ??? if they're not -- something configurable, i guess
 	//   - return the first error.
 	err := sup.SuperviseForkJoin("main",
-		sup.TasksFromMap(foobarIn, func(ctx context.Context, k_, v_ interface{}) error {
-			k, v := k_.(string), v_.(int)
-
+		sup.TasksForMap(foobarIn, func(ctx context.Context, k string, v int) error {
 			// pretend this is slow :)
 			v += 4
 
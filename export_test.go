@@ -0,0 +1,13 @@
+package sup
+
+// Things the external tests (package sup_test) need to reach in for.
+
+// ResetStrictWarnings forgets which callers StrictSupervision has already
+// warned about, so a test of the warn-once behavior can run more than once
+// in a process (as with -count).
+func ResetStrictWarnings() {
+	unsupervisedCallers.Range(func(pc, _ any) bool {
+		unsupervisedCallers.Delete(pc)
+		return true
+	})
+}
@@ -0,0 +1,112 @@
+package sup
+
+import (
+	"strings"
+	"sync"
+)
+
+// liveTasks tracks a supervisor's running children, by name, for Find
+// (and counts them, for SignalTask).  Unlike the rest of the supervisor's
+// bookkeeping, it's read from other goroutines, so it has a lock of its own.
+type liveTasks struct {
+	mu      sync.Mutex
+	path    string // the supervisor's own path; empty until it's run.
+	byName  map[string][]*boundTask
+	n       int           // the number of tasks in byName.
+	removed chan struct{} // closed (and replaced) each time a task is removed; made when first asked for.
+}
+
+func (l *liveTasks) setPath(path string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.path = path
+	l.byName = make(map[string][]*boundTask)
+}
+
+func (l *liveTasks) add(task *boundTask) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.byName[task.name] = append(l.byName[task.name], task)
+	l.n++
+}
+
+func (l *liveTasks) remove(task *boundTask) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	tasks := l.byName[task.name]
+	for i, t := range tasks {
+		if t == task {
+			tasks = append(tasks[:i], tasks[i+1:]...)
+			break
+		}
+	}
+	if len(tasks) == 0 {
+		delete(l.byName, task.name)
+	} else {
+		l.byName[task.name] = tasks
+	}
+	l.n--
+	if l.removed != nil {
+		close(l.removed)
+		l.removed = nil
+	}
+}
+
+// waitFewer blocks until there are no more than n tasks running, or stop
+// is closed.
+func (l *liveTasks) waitFewer(n int, stop <-chan struct{}) {
+	for {
+		l.mu.Lock()
+		if l.n <= n {
+			l.mu.Unlock()
+			return
+		}
+		if l.removed == nil {
+			l.removed = make(chan struct{})
+		}
+		removed := l.removed
+		l.mu.Unlock()
+		select {
+		case <-removed:
+		case <-stop:
+			return
+		}
+	}
+}
+
+// Find looks up a running task by path; see Supervisor.Find.
+//
+// If several running children have the same name, the one launched first
+// is found.
+func (mgr *superviseCommon) Find(path string) (TaskInfo, bool) {
+	if mgr.Phase() == Phase_halt {
+		return TaskInfo{}, false
+	}
+	mgr.live.mu.Lock()
+	own := mgr.live.path
+	var rest string
+	switch {
+	case own == "":
+		rest = path
+	case strings.HasPrefix(path, own+TaskPathSeparator):
+		rest = path[len(own)+len(TaskPathSeparator):]
+	}
+	names := SplitTaskPath(rest)
+	var task *boundTask
+	if len(names) > 0 {
+		if tasks := mgr.live.byName[names[0]]; len(tasks) > 0 {
+			task = tasks[0]
+		}
+	}
+	mgr.live.mu.Unlock()
+	if task == nil {
+		return TaskInfo{}, false
+	}
+	if len(names) == 1 {
+		return task.info(own), true
+	}
+	if child, ok := task.original.(Supervisor); ok {
+		return child.Find(path)
+	}
+	return TaskInfo{}, false
+}
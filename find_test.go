@@ -0,0 +1,54 @@
+package sup_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/warpfork/go-sup"
+)
+
+func TestFind(t *testing.T) {
+	running := make(chan struct{})
+	release := make(chan struct{})
+	worker := namedFunc{"worker-7", func(ctx context.Context) error {
+		close(running)
+		<-release
+		return nil
+	}}
+	pool := sup.SuperviseForkJoin("pool", []sup.Task{worker})
+	quick := sup.SuperviseForkJoin("quick", []sup.Task{namedFunc{"done", func(ctx context.Context) error { return nil }}})
+	root := sup.SuperviseForkJoin("root", []sup.Task{pool, quick})
+	_, found := root.Find("root/pool/worker-7")
+	shouldEqual(t, found, false) // not running yet.
+
+	errs := make(chan error)
+	go func() { errs <- sup.SuperviseRoot(context.Background(), root) }()
+	<-running
+	t.Run("a running grandchild should be found", func(t *testing.T) {
+		info, found := root.Find("root/pool/worker-7")
+		mustEqual(t, found, true)
+		shouldEqual(t, info.Name, "worker-7")
+		shouldEqual(t, info.Path, "root/pool/worker-7")
+		shouldEqual(t, info.Task.(sup.NamedTask).Name(), "worker-7")
+	})
+	t.Run("a child supervisor should be found", func(t *testing.T) {
+		info, found := root.Find("root/pool")
+		mustEqual(t, found, true)
+		shouldEqual(t, info.Task, sup.Task(pool))
+	})
+	t.Run("tasks under a halted supervisor shouldn't be found", func(t *testing.T) {
+		mustEqual(t, quick.Await(context.Background()), nil)
+		_, found := root.Find("root/quick/done")
+		shouldEqual(t, found, false)
+	})
+	t.Run("other paths shouldn't be found", func(t *testing.T) {
+		for _, path := range []string{"", "root", "pool/worker-7", "root/pool/worker-8", "root/pool/worker-7/more", "other/pool/worker-7"} {
+			_, found := root.Find(path)
+			shouldEqual(t, found, false)
+		}
+	})
+	close(release)
+	mustEqual(t, <-errs, nil)
+	_, found = root.Find("root/pool/worker-7")
+	shouldEqual(t, found, false)
+}
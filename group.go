@@ -0,0 +1,125 @@
+package sup
+
+import (
+	"context"
+	"sync"
+)
+
+// Group is shaped like golang.org/x/sync/errgroup's Group, so code written
+// against errgroup can be moved under supervision without being rewritten:
+// each function given to Go runs as a supervised task (so it gets a name,
+// and a task path, and its panics are caught, and it's covered by the
+// supervisor's warnings), under a stream supervisor which belongs to the
+// Group.
+//
+// Make one with NewGroup.  The differences from errgroup are these:
+//
+//   - The functions given to Go take no context, just as with errgroup, so
+//     they should use the one NewGroup returns, which is cancelled when the
+//     first of them fails (or panics), or when Wait returns.
+//   - A panic doesn't crash the program: it's returned by Wait, as an
+//     *ErrChild with WasPanic set.  (A returned error is returned as it was.)
+//   - Once the group is winding down, because a function failed, or the
+//     context given to NewGroup is done, or the supervisor was soft
+//     stopped, Go may not run the function at all.
+//   - If the context given to NewGroup is done, Wait returns its error, as
+//     any supervisor does.
+//   - Go mustn't be called after Wait.
+type Group struct {
+	svr    Supervisor
+	gen    chan Task
+	ctx    Context
+	cancel context.CancelFunc
+	done   chan struct{}
+	err    error // what the supervisor returned.
+
+	failOnce sync.Once
+	firstErr error // the first error (or panic) from the functions.
+
+	mu     sync.Mutex
+	waited bool
+}
+
+// NewGroup starts a Group, with a stream supervisor of the given name.
+// (Its tasks' paths are under the task whose context is given, if any,
+// just as if the supervisor had been run by it.)  The tasks are named
+// "go-1", "go-2", and so on, unless the options say otherwise.
+//
+// Like errgroup.WithContext, it returns a context derived from the one
+// given, for the functions given to Go to use.
+func NewGroup(ctx Context, name string, opts ...SupervisionOptions) (*Group, Context) {
+	opts = append([]SupervisionOptions{SetNameStrategy(SequentialNameStrategy())}, opts...)
+	g := &Group{gen: make(chan Task), done: make(chan struct{})}
+	g.svr = SuperviseStream(name, g.gen, opts...)
+	g.ctx, g.cancel = context.WithCancel(ctx)
+	go func() {
+		g.err = g.svr.Run(ctx)
+		close(g.done)
+	}()
+	return g, g.ctx
+}
+
+// Go runs the function as a task of the group's supervisor.  It blocks
+// until the supervisor has taken the task (which is quick, unless the
+// supervisor is busy).
+//
+// It panics if it's called after Wait.
+func (g *Group) Go(fn func() error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.waited {
+		panic("usage: Group.Go called after Wait")
+	}
+	task := NamedFunc("go-%", func(Context) error {
+		defer func() {
+			if r := recover(); r != nil {
+				g.fail(siftError(nil, r))
+				panic(r) // for the supervisor to catch, too.
+			}
+		}()
+		err := fn()
+		if err != nil {
+			g.fail(err)
+		}
+		return err
+	})
+	select {
+	case g.gen <- task:
+	case <-g.done:
+	}
+}
+
+// Wait waits for all the functions given to Go to return, and returns the
+// first error (or panic) from them, if any.  It may be called any number of
+// times, from any goroutine.
+func (g *Group) Wait() error {
+	g.mu.Lock()
+	if !g.waited {
+		g.waited = true
+		close(g.gen)
+	}
+	g.mu.Unlock()
+	<-g.done
+	g.cancel()
+	if g.firstErr != nil {
+		return g.firstErr
+	}
+	return g.err
+}
+
+// fail records the first error from the functions, and cancels the
+// context.  It's recorded here (as errgroup does), rather than left to the
+// supervisor, since the supervisor may hear first from another function,
+// which returned because of the cancellation.
+func (g *Group) fail(err error) {
+	g.failOnce.Do(func() {
+		g.firstErr = err
+		g.cancel()
+	})
+}
+
+// Supervisor returns the group's supervisor, for Find, QueueLatency, and
+// the like.
+func (g *Group) Supervisor() Supervisor {
+	return g.svr
+}
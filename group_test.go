@@ -0,0 +1,79 @@
+package sup_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/warpfork/go-sup"
+)
+
+func TestGroup(t *testing.T) {
+	t.Run("it should run everything, and Wait should cancel the context", func(t *testing.T) {
+		var mu sync.Mutex
+		var ran []int
+		g, ctx := sup.NewGroup(context.Background(), "group")
+		for i := 0; i < 5; i++ {
+			g.Go(func() error {
+				mu.Lock()
+				defer mu.Unlock()
+				ran = append(ran, i)
+				return nil
+			})
+		}
+		shouldEqual(t, g.Wait(), nil)
+		shouldEqual(t, g.Wait(), nil)
+		sort.Ints(ran)
+		shouldEqual(t, fmt.Sprint(ran), "[0 1 2 3 4]")
+		shouldEqual(t, ctx.Err(), context.Canceled)
+	})
+	t.Run("the first error should cancel the context, and be returned as it was", func(t *testing.T) {
+		boom := errors.New("boom")
+		g, ctx := sup.NewGroup(context.Background(), "group")
+		g.Go(func() error {
+			<-ctx.Done()
+			return ctx.Err()
+		})
+		g.Go(func() error { return boom })
+		shouldEqual(t, g.Wait(), boom)
+	})
+	t.Run("a panic should be returned, not crash", func(t *testing.T) {
+		g, _ := sup.NewGroup(context.Background(), "group")
+		g.Go(func() error { panic("oops") })
+		err := g.Wait()
+		var child *sup.ErrChild
+		mustEqual(t, errors.As(err, &child), true)
+		shouldEqual(t, child.WasPanic, true)
+		shouldEqual(t, child.Error(), "oops")
+	})
+	t.Run("tasks should be named, under the task which made the group", func(t *testing.T) {
+		var mu sync.Mutex
+		var paths []string
+		err := sup.SuperviseRoot(context.Background(), sup.SuperviseForkJoin("main", []sup.Task{
+			sup.NamedFunc("legacy", func(ctx context.Context) error {
+				g, _ := sup.NewGroup(ctx, "group", sup.SetChildStartHook(func(info sup.TaskInfo) {
+					mu.Lock()
+					defer mu.Unlock()
+					paths = append(paths, info.Path)
+				}))
+				g.Go(func() error { return nil })
+				g.Go(func() error { return nil })
+				return g.Wait()
+			}),
+		}))
+		shouldEqual(t, err, nil)
+		sort.Strings(paths)
+		shouldEqual(t, fmt.Sprint(paths), "[main/legacy/go-1 main/legacy/go-2]")
+	})
+	t.Run("Go after Wait should panic", func(t *testing.T) {
+		g, _ := sup.NewGroup(context.Background(), "group")
+		g.Wait()
+		defer func() {
+			shouldEqual(t, recover(), "usage: Group.Go called after Wait")
+		}()
+		g.Go(func() error { return nil })
+	})
+}
@@ -0,0 +1,44 @@
+package sup
+
+// TaskInfo describes a supervised task.  It's what lifecycle hooks are
+// given to identify the task they're being called about.
+type TaskInfo struct {
+	Name          string // the task's name (as also seen by CtxTaskName).
+	RequestedName string // the name the task asked for (as also seen by CtxRequestedTaskName).
+	Path          string // the task's full path (as also seen by CtxTaskPath).
+	Task          Task   // the task itself, as it was given to the supervisor.
+}
+
+// SetChildStartHook sets a function to be called each time the supervisor
+// starts a child task.
+//
+// The hook is called on the child's own goroutine, immediately before the
+// task's Run method.  It should return promptly, since the task doesn't
+// start until it does.  A panic in the hook is treated exactly like a
+// panic from the task.
+func SetChildStartHook(fn func(TaskInfo)) SupervisionOptions {
+	return func(cfg *supervision) {
+		cfg.childStartHook = fn
+	}
+}
+
+// SetChildExitHook sets a function to be called each time the supervisor
+// collects the result of a child task.  The error is nil if the task
+// returned nil; otherwise it's the *ErrChild wrapping what the task returned
+// or panicked.
+//
+// The hook is called on the supervisor's goroutine, at the moment the
+// supervisor processes the child's exit: after the child's start hook
+// (if any) and after its Run has returned, and before the supervisor
+// acts on the result (e.g. before it cancels siblings because of an error).
+// It's called for every child that was started, including children that
+// exit because the supervisor cancelled them, so counts of starts and exits
+// always balance by the time the supervisor's Run returns.
+//
+// Like the warning handler, the exit hook should return promptly;
+// see CallbackWatchdog.
+func SetChildExitHook(fn func(TaskInfo, error)) SupervisionOptions {
+	return func(cfg *supervision) {
+		cfg.childExitHook = fn
+	}
+}
@@ -0,0 +1,80 @@
+package sup_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/warpfork/go-sup"
+)
+
+func TestChildHooks(t *testing.T) {
+	var mu sync.Mutex
+	started := map[string]int{}
+	exited := map[string]error{}
+	boom := errors.New("boom")
+	err := sup.SuperviseRoot(context.Background(),
+		sup.SuperviseForkJoin("main",
+			[]sup.Task{
+				namedFunc{"waits", func(ctx context.Context) error { <-ctx.Done(); return nil }},
+				namedFunc{"alsowaits", func(ctx context.Context) error { <-ctx.Done(); return ctx.Err() }},
+				namedFunc{"fails", func(ctx context.Context) error { return boom }},
+			},
+			sup.SetChildStartHook(func(info sup.TaskInfo) {
+				mu.Lock()
+				defer mu.Unlock()
+				started[info.Path]++
+			}),
+			sup.SetChildExitHook(func(info sup.TaskInfo, err error) {
+				mu.Lock()
+				defer mu.Unlock()
+				if started[info.Path] != 1 {
+					t.Errorf("exit hook for %q called before its start hook", info.Path)
+				}
+				exited[info.Path] = err
+			}),
+		),
+	)
+	mustEqual(t, unwrapChild(err), boom)
+	shouldEqual(t, len(started), 3)
+	shouldEqual(t, len(exited), 3)
+	shouldEqual(t, exited["main/waits"], nil)
+	shouldEqual(t, unwrapChild(exited["main/alsowaits"]), context.Canceled)
+	shouldEqual(t, unwrapChild(exited["main/fails"]), boom)
+}
+
+// unwrapChild returns the error inside an *ErrChild, or nil.
+func unwrapChild(err error) error {
+	if e, ok := err.(*sup.ErrChild); ok {
+		return e.Err
+	}
+	return err
+}
+
+func TestChildHooksStream(t *testing.T) {
+	var mu sync.Mutex
+	var starts, exits []string
+	err := sup.SuperviseRoot(context.Background(),
+		sup.SuperviseStream("pool",
+			sup.TaskGenFromTasks([]sup.Task{
+				namedFunc{"one", func(ctx context.Context) error { return nil }},
+				namedFunc{"two", func(ctx context.Context) error { return nil }},
+			}),
+			sup.SetChildStartHook(func(info sup.TaskInfo) {
+				mu.Lock()
+				defer mu.Unlock()
+				starts = append(starts, info.Name)
+			}),
+			sup.SetChildExitHook(func(info sup.TaskInfo, err error) {
+				mu.Lock()
+				defer mu.Unlock()
+				exits = append(exits, info.Path)
+				shouldEqual(t, err, nil)
+			}),
+		),
+	)
+	shouldEqual(t, err, nil)
+	shouldEqual(t, len(starts), 2)
+	shouldEqual(t, len(exits), 2)
+}
@@ -0,0 +1,90 @@
+package sup
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"time"
+)
+
+// HTTPServerTask returns a task which runs an http.Server, with
+// ListenAndServe, until the task's context is done, or it's soft stopped
+// (see SoftStopCh).  Then, it shuts the server down gracefully: it calls
+// Shutdown, which stops the server taking new connections, and waits for
+// the requests in flight to finish, for up to shutdownGrace; if they
+// haven't by then, it calls Close, which drops them.
+//
+// Run returns nil after a shutdown, whether graceful or not, unless the
+// graceful shutdown failed and the context wasn't done (so, after a soft
+// stop which didn't finish in time); then it returns Shutdown's error.  If
+// the server stops by itself (because it couldn't listen, say), Run returns
+// its error (and nil for http.ErrServerClosed, in case something else
+// called Shutdown or Close).
+//
+// If the server has no BaseContext, it's given one, so request handlers'
+// contexts are derived from the task's, and CtxTaskName (and the like)
+// work in handlers.  Cancellation isn't passed on, though: when the task's
+// context is done, requests in flight are given their grace period, and
+// it's Close which cancels their contexts, if it comes to that.
+//
+// A server can't be started again once it's been shut down, so neither can
+// the task.
+func HTTPServerTask(name string, srv *http.Server, shutdownGrace time.Duration) NamedTask {
+	return httpServerTask{name, srv, nil, shutdownGrace}
+}
+
+// HTTPServerTaskWithListener is HTTPServerTask, except that the server
+// serves on the given listener (with Serve), rather than listening itself.
+// The listener is closed when the server is.
+func HTTPServerTaskWithListener(name string, srv *http.Server, ln net.Listener, shutdownGrace time.Duration) NamedTask {
+	return httpServerTask{name, srv, ln, shutdownGrace}
+}
+
+type httpServerTask struct {
+	name  string
+	srv   *http.Server
+	ln    net.Listener
+	grace time.Duration
+}
+
+func (t httpServerTask) Name() string {
+	return t.name
+}
+
+func (t httpServerTask) Run(ctx context.Context) error {
+	if t.srv.BaseContext == nil {
+		baseCtx := context.WithoutCancel(ctx)
+		t.srv.BaseContext = func(net.Listener) context.Context { return baseCtx }
+	}
+	served := make(chan error, 1)
+	go func() {
+		if t.ln != nil {
+			served <- t.srv.Serve(t.ln)
+		} else {
+			served <- t.srv.ListenAndServe()
+		}
+	}()
+
+	select {
+	case err := <-served:
+		if errors.Is(err, http.ErrServerClosed) {
+			return nil
+		}
+		return err
+	case <-ctx.Done():
+	case <-SoftStopCh(ctx):
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), t.grace)
+	defer cancel()
+	err := t.srv.Shutdown(shutdownCtx)
+	if err != nil {
+		t.srv.Close()
+	}
+	<-served // returns ErrServerClosed as soon as Shutdown is called.
+	if ctx.Err() != nil {
+		return nil
+	}
+	return err
+}
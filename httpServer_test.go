@@ -0,0 +1,55 @@
+package sup_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/warpfork/go-sup"
+)
+
+func TestHTTPServerTask(t *testing.T) {
+	t.Run("a server which can't listen should fail", func(t *testing.T) {
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		mustEqual(t, err, nil)
+		defer ln.Close()
+		srv := &http.Server{Addr: ln.Addr().String()}
+		err = sup.HTTPServerTask("http", srv, time.Second).Run(context.Background())
+		shouldEqual(t, err != nil, true)
+	})
+	t.Run("cancellation should shut the server down, and return nil", func(t *testing.T) {
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		mustEqual(t, err, nil)
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		err = sup.HTTPServerTaskWithListener("http", &http.Server{}, ln, time.Second).Run(ctx)
+		shouldEqual(t, err, nil)
+		_, err = net.Dial("tcp", ln.Addr().String())
+		shouldEqual(t, err != nil, true)
+	})
+	t.Run("a soft stop which doesn't finish in time should close the server, and fail", func(t *testing.T) {
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		mustEqual(t, err, nil)
+		handling := make(chan struct{})
+		srv := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			close(handling)
+			<-r.Context().Done() // cancelled by Close, not by the soft stop.
+		})}
+		svr := sup.SuperviseForkJoin("main", []sup.Task{
+			sup.HTTPServerTaskWithListener("http", srv, ln, 50*time.Millisecond),
+			sup.NamedFunc("client", func(ctx context.Context) error {
+				http.Get("http://" + ln.Addr().String())
+				return nil
+			}),
+		})
+		go func() {
+			<-handling
+			svr.SoftStop()
+		}()
+		err = unwrapChild(sup.SuperviseRoot(context.Background(), svr))
+		shouldEqual(t, errors.Is(err, context.DeadlineExceeded), true)
+	})
+}
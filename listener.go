@@ -0,0 +1,164 @@
+package sup
+
+import (
+	"context"
+	"errors"
+	"net"
+	"time"
+)
+
+// ListenerTask returns a task which runs an accept loop on the listener,
+// handling each connection in a child task of its own.  The children run
+// under a stream supervisor which belongs to the listener task (so their
+// paths are under its path), and each is named after the connection's
+// remote address.  The connection is closed when handle returns, or when
+// the child's context is done, so a handler blocked reading it is woken.
+//
+// When the task's context is done, or it's soft stopped, the listener is
+// closed, and the connections still being handled are given a grace
+// period to finish (see ListenerDrainGrace); then, their contexts are
+// cancelled.  Run returns once they've all returned.  (Their contexts
+// aren't cancelled straight away, so a soft stop from above is passed on to
+// them, and they can finish gracefully.)
+//
+// Just as with any supervisor, a handler's error (or panic) stops the
+// others, and is returned by Run, after closing the listener.  A handler
+// which wants to shrug off a failed connection should return nil.
+//
+// A temporary error from Accept (like running out of file descriptors) is
+// waited out, backing off from 5ms up to a second between tries, as
+// http.Server does.  Any other error from Accept ends the task, once the
+// connections still being handled have drained, and is returned.
+//
+// Run returns nil if the context was done.
+func ListenerTask(name string, ln net.Listener, handle func(Context, net.Conn) error, opts ...ListenerOption) NamedTask {
+	t := listenerTask{name: name, ln: ln, handle: handle, grace: defaultListenerDrainGrace}
+	for _, opt := range opts {
+		opt(&t)
+	}
+	return t
+}
+
+// ListenerOption configures a ListenerTask.
+type ListenerOption func(*listenerTask)
+
+const defaultListenerDrainGrace = 5 * time.Second
+
+// ListenerDrainGrace sets how long a ListenerTask waits for the connections
+// being handled to finish, once it's stopping, before cancelling them.  The
+// default is five seconds.  Zero means they're cancelled straight away.
+func ListenerDrainGrace(d time.Duration) ListenerOption {
+	return func(t *listenerTask) {
+		t.grace = d
+	}
+}
+
+type listenerTask struct {
+	name   string
+	ln     net.Listener
+	handle func(Context, net.Conn) error
+	grace  time.Duration
+}
+
+func (t listenerTask) Name() string {
+	return t.name
+}
+
+func (t listenerTask) Run(ctx context.Context) error {
+	connCtx, cancelConns := context.WithCancel(context.WithoutCancel(ctx))
+	defer cancelConns()
+	gen := make(chan Task)
+	svr := SuperviseStream(t.name, gen)
+	svrDone := make(chan struct{})
+	var svrErr error
+	go func() {
+		svrErr = svr.Run(connCtx)
+		close(svrDone)
+	}()
+
+	// Close the listener when we're to stop, so Accept returns.
+	stopping := make(chan struct{})
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-SoftStopCh(ctx):
+		case <-svrDone:
+		}
+		close(stopping)
+		t.ln.Close()
+	}()
+
+	acceptErr := t.acceptLoop(gen, stopping)
+	close(gen)
+
+	select {
+	case <-svrDone:
+	default:
+		if t.grace > 0 {
+			timer := time.NewTimer(t.grace)
+			select {
+			case <-svrDone:
+			case <-timer.C:
+			}
+			timer.Stop()
+		}
+		cancelConns()
+		<-svrDone
+	}
+	switch {
+	case ctx.Err() != nil:
+		return nil
+	case svrErr != nil:
+		return svrErr
+	default:
+		return acceptErr
+	}
+}
+
+// acceptLoop accepts connections, and sends a task for each to the
+// supervisor, until stopping is closed, or Accept fails permanently.
+func (t listenerTask) acceptLoop(gen chan<- Task, stopping <-chan struct{}) error {
+	var backoff time.Duration
+	for {
+		conn, err := t.ln.Accept()
+		if err != nil {
+			select {
+			case <-stopping:
+				return nil
+			default:
+			}
+			var ne net.Error
+			if !errors.As(err, &ne) || !ne.Temporary() {
+				t.ln.Close()
+				return err
+			}
+			if backoff == 0 {
+				backoff = 5 * time.Millisecond
+			} else if backoff *= 2; backoff > time.Second {
+				backoff = time.Second
+			}
+			timer := time.NewTimer(backoff)
+			select {
+			case <-timer.C:
+			case <-stopping:
+			}
+			timer.Stop()
+			continue
+		}
+		backoff = 0
+		select {
+		case gen <- NamedFunc(conn.RemoteAddr().String(), t.connTask(conn)):
+		case <-stopping:
+			conn.Close()
+		}
+	}
+}
+
+func (t listenerTask) connTask(conn net.Conn) func(Context) error {
+	return func(ctx Context) error {
+		stop := context.AfterFunc(ctx, func() { conn.Close() })
+		defer stop()
+		defer conn.Close()
+		return t.handle(ctx, conn)
+	}
+}
@@ -0,0 +1,144 @@
+package sup_test
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/warpfork/go-sup"
+)
+
+func localListener(t *testing.T) net.Listener {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	mustEqual(t, err, nil)
+	return ln
+}
+
+// flakyListener fails to accept with the given errors, in turn.
+type flakyListener struct {
+	net.Listener
+	mu   sync.Mutex
+	errs []error
+}
+
+func (l *flakyListener) Accept() (net.Conn, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	err := l.errs[0]
+	l.errs = l.errs[1:]
+	return nil, err
+}
+
+type temporaryError struct{}
+
+func (temporaryError) Error() string   { return "try again" }
+func (temporaryError) Timeout() bool   { return false }
+func (temporaryError) Temporary() bool { return true }
+
+func TestListenerTask(t *testing.T) {
+	t.Run("connections should be handled by children named after their remote address", func(t *testing.T) {
+		ln := localListener(t)
+		var handlerPath string
+		ctx, cancel := context.WithCancel(context.Background())
+		err := sup.SuperviseRoot(ctx, sup.SuperviseForkJoin("main", []sup.Task{
+			sup.ListenerTask("listener", ln, func(ctx sup.Context, conn net.Conn) error {
+				handlerPath = sup.CtxTaskPath(ctx)
+				line, err := bufio.NewReader(conn).ReadString('\n')
+				if err != nil {
+					return err
+				}
+				_, err = conn.Write([]byte(line))
+				return err
+			}),
+			sup.NamedFunc("client", func(ctx context.Context) error {
+				defer cancel()
+				conn, err := net.Dial("tcp", ln.Addr().String())
+				if err != nil {
+					return err
+				}
+				defer conn.Close()
+				conn.Write([]byte("hello\n"))
+				line, err := bufio.NewReader(conn).ReadString('\n')
+				shouldEqual(t, line, "hello\n")
+				shouldEqual(t, handlerPath, "main/listener/"+conn.LocalAddr().String())
+				return err
+			}),
+		}))
+		shouldEqual(t, err, context.Canceled)
+	})
+	t.Run("stopping should let connections in flight finish", func(t *testing.T) {
+		ln := localListener(t)
+		handling := make(chan struct{})
+		finished := false
+		ctx, cancel := context.WithCancel(context.Background())
+		task := sup.ListenerTask("listener", ln, func(ctx sup.Context, conn net.Conn) error {
+			close(handling)
+			time.Sleep(50 * time.Millisecond)
+			finished = ctx.Err() == nil
+			return nil
+		})
+		go func() {
+			conn, err := net.Dial("tcp", ln.Addr().String())
+			if err == nil {
+				defer conn.Close()
+			}
+			<-handling
+			cancel()
+		}()
+		shouldEqual(t, task.Run(ctx), nil)
+		shouldEqual(t, finished, true)
+		_, err := net.Dial("tcp", ln.Addr().String())
+		shouldEqual(t, err != nil, true)
+	})
+	t.Run("connections still going after the grace period should be cancelled and closed", func(t *testing.T) {
+		ln := localListener(t)
+		handling := make(chan struct{})
+		var readErr error
+		ctx, cancel := context.WithCancel(context.Background())
+		task := sup.ListenerTask("listener", ln, func(ctx sup.Context, conn net.Conn) error {
+			close(handling)
+			_, readErr = conn.Read(make([]byte, 1)) // the client never writes.
+			return readErr
+		}, sup.ListenerDrainGrace(50*time.Millisecond))
+		go func() {
+			conn, err := net.Dial("tcp", ln.Addr().String())
+			if err == nil {
+				defer conn.Close()
+			}
+			<-handling
+			cancel()
+			time.Sleep(time.Second)
+		}()
+		start := time.Now()
+		shouldEqual(t, task.Run(ctx), nil)
+		shouldEqual(t, time.Since(start) < time.Second, true)
+		shouldEqual(t, errors.Is(readErr, net.ErrClosed), true)
+	})
+	t.Run("a handler's error should end the task", func(t *testing.T) {
+		ln := localListener(t)
+		go func() {
+			conn, err := net.Dial("tcp", ln.Addr().String())
+			if err == nil {
+				defer conn.Close()
+			}
+			time.Sleep(time.Second)
+		}()
+		err := sup.ListenerTask("listener", ln, func(ctx sup.Context, conn net.Conn) error {
+			return errors.New("bad client")
+		}).Run(context.Background())
+		shouldEqual(t, unwrapChild(err).Error(), "bad client")
+	})
+	t.Run("temporary accept errors should be waited out, and others should end the task", func(t *testing.T) {
+		ln := localListener(t)
+		broken := errors.New("broken")
+		flaky := &flakyListener{Listener: ln, errs: []error{temporaryError{}, temporaryError{}, temporaryError{}, broken}}
+		start := time.Now()
+		err := sup.ListenerTask("listener", flaky, func(sup.Context, net.Conn) error { return nil }).Run(context.Background())
+		shouldEqual(t, err, broken)
+		shouldEqual(t, time.Since(start) >= 35*time.Millisecond, true) // 5ms, then 10ms, then 20ms.
+	})
+}
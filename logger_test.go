@@ -0,0 +1,64 @@
+package sup_test
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/warpfork/go-sup"
+)
+
+// textLogger returns a logger writing text lines (without timestamps) to buf.
+func textLogger(buf *bytes.Buffer) *slog.Logger {
+	return slog.New(slog.NewTextHandler(buf, &slog.HandlerOptions{
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey && len(groups) == 0 {
+				return slog.Attr{}
+			}
+			return a
+		},
+	}))
+}
+
+func TestLogger(t *testing.T) {
+	t.Run("should carry the task path, and be inherited", func(t *testing.T) {
+		var buf bytes.Buffer
+		ctx := sup.CtxWithLogger(context.Background(), textLogger(&buf))
+		sup.SuperviseRoot(ctx,
+			sup.SuperviseForkJoin("main", []sup.Task{
+				namedFunc{"worker", func(ctx context.Context) error {
+					sup.Logger(ctx).Info("hello")
+					return nil
+				}},
+			}),
+		)
+		shouldEqual(t, buf.String(), "level=INFO msg=hello sup.task=main/worker\n")
+	})
+	t.Run("should say unmanaged outside of a supervisor", func(t *testing.T) {
+		var buf bytes.Buffer
+		ctx := sup.CtxWithLogger(context.Background(), textLogger(&buf))
+		sup.Logger(ctx).Info("hello")
+		shouldEqual(t, buf.String(), "level=INFO msg=hello sup.task=[unmanaged]\n")
+		shouldEqual(t, sup.CtxTaskName(ctx), "")
+	})
+	t.Run("supervisor warnings should use it too", func(t *testing.T) {
+		var buf bytes.Buffer
+		ctx := sup.CtxWithLogger(context.Background(), textLogger(&buf))
+		sup.SuperviseRoot(ctx,
+			sup.SuperviseForkJoin("main", []sup.Task{
+				namedFunc{"slow", func(ctx context.Context) error {
+					<-ctx.Done()
+					time.Sleep(20 * time.Millisecond)
+					return nil
+				}},
+				namedFunc{"failer", func(ctx context.Context) error {
+					return context.Canceled
+				}},
+			}, sup.RunawayThreshold(time.Millisecond)),
+		)
+		shouldEqual(t, strings.Contains(buf.String(), "kind=slow-cancel"), true)
+	})
+}
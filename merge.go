@@ -0,0 +1,60 @@
+package sup
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// Merge forwards everything received from all the given channels into one
+// new channel, which it returns.  It's for when several producers each have
+// their own outbox, and one consumer wants a single inbox.
+//
+// Messages from any one input arrive in the order they were sent; there's
+// no ordering between inputs.  An input being closed just means there's
+// nothing more from it; the output is closed once every input is closed,
+// or once the context is done.
+//
+// Forwarding happens on one goroutine per input, which all return when
+// the output is closed.  They're plain goroutines, so do give Merge a
+// context that will end, or close all the inputs.
+//
+// If the context is done while a message has been received from an input
+// but not yet taken from the output, that message is dropped (so, at most
+// one per input).  It's not dropped silently, though: if the context belongs
+// to a supervised task, a dropped-message warning goes to the supervisor's
+// warning handler.
+func Merge[T any](ctx context.Context, ins ...ReceiverChannel[T]) ReceiverChannel[T] {
+	if StrictSupervision != StrictMode_off {
+		checkSupervised(ctx, "sup.Merge") // here, not in the forwarders: a panic there couldn't be recovered.
+	}
+	names := make([]string, len(ins))
+	for i, in := range ins {
+		names[i] = in.Name()
+	}
+	out := make(chan T)
+	info, _ := ctx.Value(ctxKey{}).(ctxInfo)
+	var wg sync.WaitGroup
+	wg.Add(len(ins))
+	for _, in := range ins {
+		go func(in ReceiverChannel[T]) {
+			defer wg.Done()
+			for {
+				v, ok, err := recv(ctx, in.Chan)
+				if err != nil || !ok {
+					return
+				}
+				if err := send(ctx, out, v); err != nil {
+					info.warn(WarningKind_dropped, fmt.Sprintf("merge dropped a message from channel %q at cancellation", in.Name()))
+					return
+				}
+			}
+		}(in)
+	}
+	go func() {
+		wg.Wait()
+		close(out)
+	}()
+	return ReceiverChannel[T]{out, &channelMeta{name: strings.Join(names, "+")}}
+}
@@ -0,0 +1,73 @@
+package sup_test
+
+import (
+	"context"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/warpfork/go-sup"
+)
+
+func TestMerge(t *testing.T) {
+	t.Run("should preserve per-input order, and close after all inputs close", func(t *testing.T) {
+		txA, rxA := sup.NewChannel[int]("a", 0)
+		txB, rxB := sup.NewChannel[int]("b", 0)
+		out := sup.Merge(context.Background(), rxA, rxB)
+		shouldEqual(t, out.Name(), "a+b")
+		go func() {
+			for i := 0; i < 100; i++ {
+				txA.Chan <- i
+			}
+			close(txA.Chan) // b carries on without a.
+		}()
+		go func() {
+			for i := 1000; i < 1100; i++ {
+				txB.Chan <- i
+			}
+			close(txB.Chan)
+		}()
+		nextA, nextB := 0, 1000
+		for v := range out.Chan {
+			if v < 1000 {
+				mustEqual(t, v, nextA)
+				nextA++
+			} else {
+				mustEqual(t, v, nextB)
+				nextB++
+			}
+		}
+		shouldEqual(t, nextA, 100)
+		shouldEqual(t, nextB, 1100)
+	})
+	t.Run("should close the output on cancellation, and warn about the message in flight", func(t *testing.T) {
+		warned := make(chan sup.SupervisionWarning, 1)
+		sup.SuperviseRoot(context.Background(),
+			sup.SuperviseForkJoin("main",
+				[]sup.Task{namedFunc{"consumer", func(ctx context.Context) error {
+					ctx, cancel := context.WithCancel(ctx)
+					in := make(chan int, 1)
+					in <- 1
+					out := sup.Merge(ctx, sup.ReceiverChannel[int]{Chan: in})
+					// Wait until the message has been taken from the input,
+					//  then cancel without anyone taking it from the output.
+					for len(in) > 0 {
+						runtime.Gosched()
+					}
+					cancel()
+					select {
+					case w := <-warned:
+						shouldEqual(t, w.Kind, sup.WarningKind_dropped)
+						shouldEqual(t, w.TaskPath, "main/consumer")
+					case <-time.After(time.Second):
+						t.Errorf("no warning")
+					}
+					_, ok := <-out.Chan
+					shouldEqual(t, ok, false)
+					return nil
+				}}},
+				sup.SetWarningHandler(func(w sup.SupervisionWarning) { warned <- w }),
+			),
+		)
+	})
+}
@@ -0,0 +1,299 @@
+package sup
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math/rand"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// NameStrategy fills in the placeholders in a task's requested name.
+//
+// A task asks for a generated name by putting "%" characters in the name
+// it gives (see NamedTask): "worker-%%", say.  When the supervisor launches
+// the task, it asks its name strategy for a name, and if that name is
+// already in use by another of its running tasks, asks again, with attempt
+// counting up from zero, until it gets one that isn't.  (If it's asked a
+// hundred times without luck, the supervisor gives up on the strategy,
+// emits a name collision warning, and uses the requested name followed by
+// "#" and a number.)  So names with placeholders are always unique among a
+// supervisor's running tasks.
+// (Names without placeholders are used just as they are, and if they
+// collide, the supervisor emits a name collision warning.  The same goes
+// for any name the strategy gives back unchanged: that's how a strategy
+// declines to fill in a name.)
+//
+// A blank name (from a NamedTask whose Name returns "") is given to the
+// strategy too; DefaultNameStrategy leaves it blank, but
+// PointerishNameStrategy fills it in.
+//
+// The strategy is called on the supervisor's own goroutine, one call at a
+// time; but a strategy given to several supervisors is called by all of
+// them, concurrently, so any state it keeps needs a lock.
+//
+// The default is DefaultNameStrategy; use SetNameStrategy to choose another.
+type NameStrategy func(requested string, attempt int) string
+
+// SetNameStrategy sets how the supervisor fills in placeholders in its
+// tasks' names.  A nil strategy means DefaultNameStrategy.
+func SetNameStrategy(strategy NameStrategy) SupervisionOptions {
+	return func(cfg *supervision) {
+		cfg.nameStrategy = strategy
+	}
+}
+
+// DefaultNameStrategy replaces each "%" with a random digit, so
+// "worker-%%" becomes something like "worker-37".  Each attempt draws new
+// digits.  (Do leave enough room: a pool of fifty workers needs more than
+// one digit.)
+//
+// The digits come from a package-wide random source, which can be seeded
+// with SeedNameStrategies.
+func DefaultNameStrategy(requested string, attempt int) string {
+	nameRand.Lock()
+	defer nameRand.Unlock()
+	return strings.Map(func(r rune) rune {
+		if r == '%' {
+			return '0' + rune(nameRand.r.Intn(10))
+		}
+		return r
+	}, requested)
+}
+
+// SeedNameStrategies seeds the random source used by the name strategies,
+// so that tests can get the same names every time.  By default, it's seeded
+// from the clock.
+func SeedNameStrategies(seed int64) {
+	nameRand.Lock()
+	defer nameRand.Unlock()
+	nameRand.r = rand.New(rand.NewSource(seed))
+}
+
+var nameRand = struct {
+	sync.Mutex
+	r *rand.Rand
+}{r: rand.New(rand.NewSource(time.Now().UnixNano()))}
+
+// maxNameAttempts is how many times selectName asks the strategy for a
+// name before giving up on it.
+const maxNameAttempts = 100
+
+// selectName fills in the placeholders in a requested name, using the
+// supervisor's name strategy, and retrying until the name is unique among
+// its running tasks.  If the strategy leaves the name unchanged, it's used
+// as it is, collisions and all.
+//
+// If the strategy keeps giving names that are already in use, selectName
+// gives up on it, emits a name collision warning, and makes up a name of
+// its own: the requested name, then "#" and a number.
+func (mgr *superviseCommon) selectName(requested string) string {
+	strategy := mgr.cfg.nameStrategy
+	if strategy == nil {
+		strategy = DefaultNameStrategy
+	}
+	for attempt := 0; attempt < maxNameAttempts; attempt++ {
+		if name := strategy(requested, attempt); name == requested || mgr.names[name] == 0 {
+			return name
+		}
+	}
+	var name string
+	for {
+		name = requested + "#" + strconv.FormatUint(atomic.AddUint64(&fallbackNameCounter, 1), 10)
+		if mgr.names[name] == 0 {
+			break
+		}
+	}
+	mgr.cfg.warn(SupervisionWarning{
+		Kind:           WarningKind_nameCollision,
+		SupervisorPath: mgr.path,
+		TaskPath:       joinTaskPath(mgr.path, name),
+		Message:        fmt.Sprintf("name strategy gave only names already in use in %d attempts, for task name %q; using %q instead", maxNameAttempts, requested, name),
+	})
+	return name
+}
+
+var fallbackNameCounter uint64
+
+// TimeOrderedNameStrategy replaces the placeholders in a name with a
+// suffix made of the current time, in milliseconds, and a random tail, so
+// "worker-%" becomes something like "worker-01JA2Z8KQM7H3XPV".  The
+// suffix is in Crockford's base32, and is always sixteen characters long,
+// so names made from the same template sort in the order they were made.
+//
+// Names are never repeated within a process: within the same millisecond
+// (or if the clock steps backwards), the tail counts up from the previous
+// name's, rather than being drawn again.  So a retry is never needed, and
+// if one happens anyway, it just takes the next value.
+//
+// If there are several "%" characters, the suffix goes where the first
+// is, and the others are dropped.
+func TimeOrderedNameStrategy(requested string, attempt int) string {
+	i := strings.IndexByte(requested, '%')
+	if i < 0 {
+		return requested
+	}
+	return requested[:i] + timeOrdered.next() + strings.ReplaceAll(requested[i+1:], "%", "")
+}
+
+// timeOrdered is the state of TimeOrderedNameStrategy: the last suffix it
+// gave, as a time in milliseconds and a random tail.
+var timeOrdered = &timeOrderedState{}
+
+type timeOrderedState struct {
+	sync.Mutex
+	ms   uint64
+	tail uint64
+}
+
+const (
+	timeOrderedTimeChars = 10 // 50 bits of milliseconds: good for a few thousand years.
+	timeOrderedTailChars = 6  // 30 bits of random tail.
+	timeOrderedTailMax   = 1<<(5*timeOrderedTailChars) - 1
+)
+
+// crockford32 is the digits of Crockford's base32, in order, so encoded
+// numbers of the same length sort as the numbers do.
+const crockford32 = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+func (to *timeOrderedState) next() string {
+	ms := uint64(time.Now().UnixMilli())
+	to.Lock()
+	defer to.Unlock()
+	if ms > to.ms {
+		to.ms = ms
+		nameRand.Lock()
+		to.tail = uint64(nameRand.r.Int63n(timeOrderedTailMax / 2)) // leaves room to count up.
+		nameRand.Unlock()
+	} else if to.tail++; to.tail > timeOrderedTailMax {
+		to.ms++ // borrow from the next millisecond; we'll wait for the clock to catch up.
+		to.tail = 0
+	}
+	var buf [timeOrderedTimeChars + timeOrderedTailChars]byte
+	v := to.tail
+	for i := len(buf) - 1; i >= 0; i-- {
+		if i == timeOrderedTimeChars-1 {
+			v = to.ms
+		}
+		buf[i] = crockford32[v&31]
+		v >>= 5
+	}
+	return string(buf[:])
+}
+
+// SequentialNameStrategy returns a name strategy which replaces the
+// placeholders in a name with a number, counting up from one for each
+// distinct requested name: the first "worker-%" becomes "worker-1", the
+// next "worker-2", and so on, while "reader-%" counts separately.  Numbers
+// which are already in use (by a task explicitly named "worker-2", say)
+// are skipped.  Numbers aren't reused when tasks exit.
+//
+// Each call returns a new strategy with its own counters; give each
+// supervisor its own, if each should count from one.  (Sharing one is
+// safe, too: it's self-locking.)
+//
+// If there are several "%" characters, the number goes where the first
+// is, and the others are dropped.
+func SequentialNameStrategy() NameStrategy {
+	var mu sync.Mutex
+	counters := map[string]int{}
+	return func(requested string, attempt int) string {
+		i := strings.IndexByte(requested, '%')
+		if i < 0 {
+			return requested
+		}
+		mu.Lock()
+		counters[requested]++
+		n := counters[requested]
+		mu.Unlock()
+		return requested[:i] + strconv.Itoa(n) + strings.ReplaceAll(requested[i+1:], "%", "")
+	}
+}
+
+// PointerishNameStrategy names tasks the way anonymous tasks (which aren't
+// a NamedTask at all) are named: with something that looks like a pointer,
+// such as "0x1f".  It applies to names which are blank, or nothing but
+// placeholders; other names with placeholders are filled in just as by
+// DefaultNameStrategy.
+//
+// The numbers come from a package-wide counter, rather than any real
+// address, so they're unique within the process, and are small enough to
+// read.
+func PointerishNameStrategy(requested string, attempt int) string {
+	if strings.Trim(requested, "%") != "" {
+		return DefaultNameStrategy(requested, attempt)
+	}
+	return "0x" + strconv.FormatUint(atomic.AddUint64(&pointerishCounter, 1), 16)
+}
+
+var pointerishCounter uint64
+
+// defaultMaxTaskNameLen is the default for SetMaxTaskNameLen.
+const defaultMaxTaskNameLen = 128
+
+// SetMaxTaskNameLen sets the longest name, in bytes, the supervisor will
+// give a task.  The default is 128 bytes; zero or less means no limit.
+//
+// A longer name is cut short, and a hash of the whole name is put on the
+// end, after a "~", so names which differ only past the limit still come
+// out different.  The supervisor emits a renamed warning when it does this,
+// just as when a name has control characters (or other unprintable
+// characters, like the escape character that starts a terminal escape
+// sequence), which it replaces with underscores, so that names are safe to
+// put in logs and metrics labels.  (A name containing the path separator is
+// fine as it is: it's escaped in the task's path; see TaskPathSeparator.)
+//
+// It panics if the limit is too short to fit the hash.
+func SetMaxTaskNameLen(n int) SupervisionOptions {
+	if n > 0 && n < taskNameHashLen {
+		panic("usage: SetMaxTaskNameLen must allow at least " + strconv.Itoa(taskNameHashLen) + " bytes")
+	}
+	return func(cfg *supervision) {
+		cfg.maxTaskNameLen = n
+	}
+}
+
+// taskNameHashLen is the length of the "~" and hash that sanitizeName puts
+// on the end of a name it cuts short.
+const taskNameHashLen = 1 + 8
+
+// sanitizeName replaces unprintable characters in a task's name, and cuts
+// it short if it's longer than the supervisor allows.  If that changes the
+// name, it emits a renamed warning.
+func (mgr *superviseCommon) sanitizeName(name string) string {
+	clean := cleanTaskName(name, mgr.cfg.maxTaskNameLen)
+	if clean != name {
+		mgr.cfg.warn(SupervisionWarning{
+			Kind:           WarningKind_renamed,
+			SupervisorPath: mgr.path,
+			TaskPath:       joinTaskPath(mgr.path, clean),
+			Message:        fmt.Sprintf("task name %.200q was unprintable or too long, so it was changed to %q", name, clean),
+		})
+	}
+	return clean
+}
+
+// cleanTaskName does the work of sanitizeName, without the warning.
+func cleanTaskName(name string, max int) string {
+	clean := strings.Map(func(r rune) rune {
+		if r == utf8.RuneError || !unicode.IsPrint(r) {
+			return '_'
+		}
+		return r
+	}, name)
+	if max > 0 && len(clean) > max {
+		h := fnv.New32a()
+		h.Write([]byte(name))
+		cut := max - taskNameHashLen
+		for cut > 0 && !utf8.RuneStart(clean[cut]) {
+			cut--
+		}
+		clean = fmt.Sprintf("%s~%08x", clean[:cut], h.Sum32())
+	}
+	return clean
+}
@@ -0,0 +1,268 @@
+package sup_test
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/warpfork/go-sup"
+)
+
+// poolNames runs n tasks with the given name under a stream supervisor with
+// the given options, all at once, and returns the names they got.
+func poolNames(n int, name string, opts ...sup.SupervisionOptions) []string {
+	names := make([]string, n)
+	for i := range names {
+		names[i] = name
+	}
+	return launchNames(names, opts...)
+}
+
+// launchNames runs tasks with the given names, in order, under a stream
+// supervisor with the given options, all at once, and returns the names
+// they got, sorted.
+func launchNames(requested []string, opts ...sup.SupervisionOptions) []string {
+	n := len(requested)
+	taskGen := make(chan sup.Task, n)
+	var wg sync.WaitGroup
+	wg.Add(n)
+	names := make(chan string, n)
+	for _, name := range requested {
+		taskGen <- namedFunc{name, func(ctx context.Context) error {
+			names <- sup.CtxTaskName(ctx)
+			wg.Done()
+			wg.Wait() // so they're all running at once, and can collide.
+			return nil
+		}}
+	}
+	close(taskGen)
+	sup.SuperviseRoot(context.Background(), sup.SuperviseStream("pool", taskGen, opts...))
+	close(names)
+	var result []string
+	for name := range names {
+		result = append(result, name)
+	}
+	sort.Strings(result)
+	return result
+}
+
+func TestDefaultNameStrategy(t *testing.T) {
+	sup.SeedNameStrategies(1)
+	names := poolNames(50, "worker-%%")
+	mustEqual(t, len(names), 50)
+	seen := map[string]bool{}
+	pattern := regexp.MustCompile(`^worker-[0-9][0-9]$`)
+	for _, name := range names {
+		shouldEqual(t, pattern.MatchString(name), true)
+		shouldEqual(t, seen[name], false)
+		seen[name] = true
+	}
+}
+
+func TestTimeOrderedNameStrategy(t *testing.T) {
+	t.Run("names should be unique and sorted", func(t *testing.T) {
+		prev := ""
+		pattern := regexp.MustCompile(`^job-[0-9A-HJKMNP-TV-Z]{16}-x$`)
+		for i := 0; i < 10000; i++ {
+			name := sup.TimeOrderedNameStrategy("job-%-x", 0)
+			mustEqual(t, pattern.MatchString(name), true)
+			mustEqual(t, name > prev, true)
+			prev = name
+		}
+	})
+	t.Run("extra placeholders should be dropped", func(t *testing.T) {
+		name := sup.TimeOrderedNameStrategy("a%b%%c", 0)
+		shouldEqual(t, len(name), len("abc")+16)
+		shouldEqual(t, name[:1]+name[17:], "abc")
+	})
+	t.Run("a supervisor should give unique names", func(t *testing.T) {
+		names := poolNames(50, "worker-%", sup.SetNameStrategy(sup.TimeOrderedNameStrategy))
+		mustEqual(t, len(names), 50)
+		seen := map[string]bool{}
+		for _, name := range names {
+			shouldEqual(t, seen[name], false)
+			seen[name] = true
+		}
+	})
+}
+
+func TestSequentialNameStrategy(t *testing.T) {
+	t.Run("each template should count separately", func(t *testing.T) {
+		names := launchNames([]string{"a-%", "b-%", "a-%", "b-%", "a-%"},
+			sup.SetNameStrategy(sup.SequentialNameStrategy()))
+		shouldEqual(t, fmt.Sprint(names), "[a-1 a-2 a-3 b-1 b-2]")
+	})
+	t.Run("numbers in use should be skipped", func(t *testing.T) {
+		names := launchNames([]string{"w-2", "w-%", "w-%", "w-%"},
+			sup.SetNameStrategy(sup.SequentialNameStrategy()))
+		shouldEqual(t, fmt.Sprint(names), "[w-1 w-2 w-3 w-4]")
+	})
+}
+
+func TestPointerishNameStrategy(t *testing.T) {
+	pointerish := sup.SetNameStrategy(sup.PointerishNameStrategy)
+	t.Run("blank names should be filled in", func(t *testing.T) {
+		names := poolNames(20, "", pointerish)
+		seen := map[string]bool{}
+		for _, name := range names {
+			shouldEqual(t, strings.HasPrefix(name, "0x"), true)
+			shouldEqual(t, seen[name], false)
+			seen[name] = true
+		}
+	})
+	t.Run("all-placeholder names should be filled in", func(t *testing.T) {
+		names := poolNames(2, "%%", pointerish)
+		shouldEqual(t, strings.HasPrefix(names[0], "0x"), true)
+		shouldEqual(t, names[0] != names[1], true)
+	})
+	t.Run("other names should be respected", func(t *testing.T) {
+		names := launchNames([]string{"plain", "w-%%"}, pointerish)
+		shouldEqual(t, names[0], "plain")
+		shouldEqual(t, regexp.MustCompile(`^w-[0-9][0-9]$`).MatchString(names[1]), true)
+	})
+	t.Run("blank names should stay blank by default", func(t *testing.T) {
+		names := poolNames(2, "")
+		shouldEqual(t, fmt.Sprintf("%q", names), `["" ""]`)
+	})
+}
+
+func TestBrokenNameStrategy(t *testing.T) {
+	warnings := make(chan sup.SupervisionWarning, 10)
+	broken := func(requested string, attempt int) string { return "same" }
+	names := poolNames(3, "w-%", sup.SetNameStrategy(broken), sup.SetWarningHandler(func(w sup.SupervisionWarning) {
+		warnings <- w
+	}))
+	mustEqual(t, len(names), 3)
+	shouldEqual(t, names[0], "same")
+	pattern := regexp.MustCompile(`^w-%#[0-9]+$`)
+	shouldEqual(t, pattern.MatchString(names[1]), true)
+	shouldEqual(t, pattern.MatchString(names[2]), true)
+	shouldEqual(t, names[1] != names[2], true)
+	close(warnings)
+	var kinds []sup.WarningKind
+	for w := range warnings {
+		kinds = append(kinds, w.Kind)
+	}
+	shouldEqual(t, fmt.Sprint(kinds), "[name-collision name-collision]")
+}
+
+func TestTaskNameSanitizing(t *testing.T) {
+	collect := func(warnings chan sup.SupervisionWarning) sup.SupervisionOptions {
+		return sup.SetWarningHandler(func(w sup.SupervisionWarning) { warnings <- w })
+	}
+	t.Run("unprintable characters should be replaced", func(t *testing.T) {
+		warnings := make(chan sup.SupervisionWarning, 10)
+		names := launchNames([]string{"line\nbreak", "\x1b[31mred\x1b[0m", "fine"}, collect(warnings))
+		shouldEqual(t, fmt.Sprint(names), "[_[31mred_[0m fine line_break]")
+		close(warnings)
+		n := 0
+		for w := range warnings {
+			shouldEqual(t, w.Kind, sup.WarningKind_renamed)
+			n++
+		}
+		shouldEqual(t, n, 2)
+	})
+	t.Run("long names should be cut short, and stay different", func(t *testing.T) {
+		warnings := make(chan sup.SupervisionWarning, 10)
+		long := strings.Repeat("x", 200)
+		names := launchNames([]string{long + "a", long + "b", "short"}, collect(warnings))
+		shouldEqual(t, names[0], "short")
+		pattern := regexp.MustCompile(`^x{119}~[0-9a-f]{8}$`)
+		shouldEqual(t, pattern.MatchString(names[1]), true)
+		shouldEqual(t, pattern.MatchString(names[2]), true)
+		shouldEqual(t, names[1] != names[2], true)
+		close(warnings)
+		shouldEqual(t, len(warnings), 2)
+	})
+	t.Run("the limit should be adjustable", func(t *testing.T) {
+		long := strings.Repeat("x", 200)
+		names := launchNames([]string{long}, sup.SetMaxTaskNameLen(500))
+		shouldEqual(t, names[0], long)
+		names = launchNames([]string{long}, sup.SetMaxTaskNameLen(0))
+		shouldEqual(t, names[0], long)
+		names = launchNames([]string{long}, sup.SetMaxTaskNameLen(20))
+		shouldEqual(t, len(names[0]), 20)
+	})
+	t.Run("a name with a separator shouldn't collide with a nested path", func(t *testing.T) {
+		paths := make(chan string, 2)
+		record := func(ctx context.Context) error { paths <- sup.CtxTaskPath(ctx); return nil }
+		root := sup.SuperviseForkJoin("root", []sup.Task{
+			namedFunc{"pool/worker", record},
+			sup.SuperviseForkJoin("pool", []sup.Task{namedFunc{"worker", record}}),
+		})
+		mustEqual(t, sup.SuperviseRoot(context.Background(), root), nil)
+		close(paths)
+		var got []string
+		for path := range paths {
+			got = append(got, path)
+		}
+		sort.Strings(got)
+		shouldEqual(t, fmt.Sprintf("%q", got), `["root/pool/worker" "root/pool\\/worker"]`)
+		shouldEqual(t, sup.TaskLeafName(got[1]), "pool/worker")
+		shouldEqual(t, sup.ParentPath(got[1]), "root")
+	})
+}
+
+func TestRequestedName(t *testing.T) {
+	infos := make(chan sup.TaskInfo, 10)
+	requested := make(chan string, 10)
+	record := func(ctx context.Context) error { requested <- sup.CtxRequestedTaskName(ctx); return nil }
+	root := sup.SuperviseForkJoin("root", []sup.Task{
+		namedFunc{"worker-%%", record},
+		namedFunc{"plain", record},
+	}, sup.SetChildExitHook(func(info sup.TaskInfo, err error) { infos <- info }))
+	mustEqual(t, sup.SuperviseRoot(context.Background(), root), nil)
+	close(infos)
+	close(requested)
+	byRequest := map[string]string{}
+	for info := range infos {
+		byRequest[info.RequestedName] = info.Name
+	}
+	shouldEqual(t, len(byRequest), 2)
+	shouldEqual(t, regexp.MustCompile(`^worker-[0-9][0-9]$`).MatchString(byRequest["worker-%%"]), true)
+	shouldEqual(t, byRequest["plain"], "plain")
+	var got []string
+	for name := range requested {
+		got = append(got, name)
+	}
+	sort.Strings(got)
+	shouldEqual(t, fmt.Sprint(got), "[plain worker-%%]")
+}
+
+// Both kinds of supervisor name their tasks the same way: by NamedTask.Name,
+// if the task has it, filled in by the name strategy.
+func TestNamingIsTheSameForAllSupervisors(t *testing.T) {
+	for _, kind := range []string{"forkjoin", "stream"} {
+		t.Run(kind, func(t *testing.T) {
+			names := make(chan string, 3)
+			record := func(ctx context.Context) error { names <- sup.CtxTaskName(ctx); return nil }
+			tasks := []sup.Task{
+				namedFunc{"plain", record},
+				namedFunc{"w-%", record},
+				sup.TaskFromFunc(record)[0],
+			}
+			var root sup.Supervisor
+			opt := sup.SetNameStrategy(sup.SequentialNameStrategy())
+			switch kind {
+			case "forkjoin":
+				root = sup.SuperviseForkJoin("root", tasks, opt)
+			case "stream":
+				root = sup.SuperviseStream("root", sup.TaskGenFromTasks(tasks), opt)
+			}
+			mustEqual(t, sup.SuperviseRoot(context.Background(), root), nil)
+			close(names)
+			var got []string
+			for name := range names {
+				got = append(got, name)
+			}
+			sort.Strings(got)
+			mustEqual(t, len(got), 3)
+			shouldEqual(t, strings.HasPrefix(got[0], "0x"), true) // the anonymous task.
+			shouldEqual(t, fmt.Sprint(got[1:]), "[plain w-1]")
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package sup
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"sync/atomic"
+)
+
+// Once wraps a task which mustn't be run more than once (because it keeps
+// state from its run in its own fields, say), so that a second Run fails
+// loudly, returning an ErrTaskReused, rather than quietly running on
+// leftover state.  Checking costs one atomic operation per Run.
+//
+// If the task is a NamedTask, the wrapper is too, with the same name.
+func Once(t Task) Task {
+	return keepName(t, &onceTask{task: t})
+}
+
+// Reusable wraps a task to declare that it's fine to run it more than once:
+// that each Run starts afresh.  It changes nothing about how the task runs;
+// it's there so that checks for tasks being reused by mistake (now or in
+// future) can leave it alone, and so that readers can see it's intended.
+//
+// If the task is a NamedTask, the wrapper is too, with the same name.
+func Reusable(t Task) Task {
+	return keepName(t, reusableTask{t})
+}
+
+// TaskReuseStacks, if set to true, makes tasks wrapped by Once record the
+// stack where they were first run, so an ErrTaskReused can say where that
+// was.  It's for debugging, since capturing a stack costs a little on every
+// first Run.  Set it before starting any tasks.
+var TaskReuseStacks bool
+
+// ErrTaskReused is the error returned when a task wrapped by Once is run a
+// second time.
+type ErrTaskReused struct {
+	// TaskName is the task's name, if it's a NamedTask; otherwise it's empty.
+	TaskName string
+
+	// FirstRunStack is the stack of the goroutine which first ran the task,
+	// if TaskReuseStacks was set then; otherwise it's empty.
+	FirstRunStack string
+}
+
+func (e ErrTaskReused) Error() string {
+	if e.TaskName == "" {
+		return "task was run more than once"
+	}
+	return fmt.Sprintf("task %q was run more than once", e.TaskName)
+}
+
+type onceTask struct {
+	task       Task
+	ran        uint32
+	firstStack atomic.Pointer[string]
+}
+
+func (t *onceTask) Run(ctx context.Context) error {
+	if !atomic.CompareAndSwapUint32(&t.ran, 0, 1) {
+		err := ErrTaskReused{}
+		if nt, ok := t.task.(NamedTask); ok {
+			err.TaskName = nt.Name()
+		}
+		if stack := t.firstStack.Load(); stack != nil {
+			err.FirstRunStack = *stack
+		}
+		return err
+	}
+	if TaskReuseStacks {
+		buf := make([]byte, 16<<10)
+		stack := string(buf[:runtime.Stack(buf, false)])
+		t.firstStack.Store(&stack)
+	}
+	return t.task.Run(ctx)
+}
+
+type reusableTask struct {
+	Task
+}
@@ -0,0 +1,48 @@
+package sup_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/warpfork/go-sup"
+)
+
+func TestOnce(t *testing.T) {
+	t.Run("a second run should fail", func(t *testing.T) {
+		runs := 0
+		task := sup.Once(sup.TaskFromFunc(func(context.Context) error { runs++; return nil })[0])
+		shouldEqual(t, task.Run(context.Background()), nil)
+		err := task.Run(context.Background())
+		var reused sup.ErrTaskReused
+		mustEqual(t, errors.As(err, &reused), true)
+		shouldEqual(t, reused.TaskName, "")
+		shouldEqual(t, reused.FirstRunStack, "")
+		shouldEqual(t, runs, 1)
+	})
+	t.Run("names should pass through", func(t *testing.T) {
+		task := sup.Once(namedFunc{"once", func(context.Context) error { return nil }})
+		shouldEqual(t, task.(sup.NamedTask).Name(), "once")
+		task.Run(context.Background())
+		shouldEqual(t, task.Run(context.Background()).Error(), `task "once" was run more than once`)
+	})
+	t.Run("the first run's stack should be kept, if asked for", func(t *testing.T) {
+		sup.TaskReuseStacks = true
+		defer func() { sup.TaskReuseStacks = false }()
+		task := sup.Once(sup.TaskFromFunc(func(context.Context) error { return nil })[0])
+		task.Run(context.Background())
+		var reused sup.ErrTaskReused
+		mustEqual(t, errors.As(task.Run(context.Background()), &reused), true)
+		shouldEqual(t, strings.Contains(reused.FirstRunStack, "TestOnce"), true)
+	})
+}
+
+func TestReusable(t *testing.T) {
+	runs := 0
+	task := sup.Reusable(namedFunc{"again", func(context.Context) error { runs++; return nil }})
+	shouldEqual(t, task.(sup.NamedTask).Name(), "again")
+	shouldEqual(t, task.Run(context.Background()), nil)
+	shouldEqual(t, task.Run(context.Background()), nil)
+	shouldEqual(t, runs, 2)
+}
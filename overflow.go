@@ -0,0 +1,160 @@
+package sup
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// OverflowPolicy says what an OverflowChannel does with a message sent
+// while its buffer is full.
+type OverflowPolicy uint8
+
+const (
+	OverflowPolicy_block      = OverflowPolicy(0) // Send waits for room, as with any channel (or until its context is done).
+	OverflowPolicy_dropNewest = OverflowPolicy(1) // the message being sent is dropped; what's already buffered is kept.
+	OverflowPolicy_dropOldest = OverflowPolicy(2) // the oldest buffered message is dropped, to make room for the new one.
+)
+
+func (p OverflowPolicy) String() string {
+	switch p {
+	case OverflowPolicy_block:
+		return "block"
+	case OverflowPolicy_dropNewest:
+		return "drop-newest"
+	case OverflowPolicy_dropOldest:
+		return "drop-oldest"
+	default:
+		return "unknown"
+	}
+}
+
+// OverflowChannel is the sending side of a channel which, under the drop
+// policies, never makes its producer wait: when the consumer falls behind
+// and the buffer fills up, messages are dropped instead.  It's for
+// telemetry-ish streams, where fresh data matters more than complete data.
+//
+// The receiving side is an ordinary ReceiverChannel; the buffer is the
+// channel's own.  There's no helper goroutine.
+//
+// Drops are counted (see Dropped), and can also be reported as warnings
+// (see SetDropWarnings).
+type OverflowChannel[T any] struct {
+	ch     chan T
+	tx     SenderChannel[T]
+	policy OverflowPolicy
+
+	dropped atomic.Uint64
+	mu      sync.Mutex // held while making room under OverflowPolicy_dropOldest, and for the warning state.
+
+	warnEvery time.Duration
+	lastWarn  time.Time
+	unwarned  uint64 // drops not yet mentioned in a warning.
+}
+
+// NewOverflowChannel makes a channel with the given buffer capacity, and
+// returns its sending side, which applies the given policy when the buffer
+// is full, and its receiving side.
+//
+// OverflowPolicy_dropOldest needs a buffer to drop from, so using it with a
+// capacity of zero is a usage error, and panics.  (With OverflowPolicy_dropNewest,
+// a capacity of zero is fine: a message is only delivered if the consumer is
+// already waiting for it.)
+func NewOverflowChannel[T any](name string, capacity int, policy OverflowPolicy) (*OverflowChannel[T], ReceiverChannel[T]) {
+	if policy == OverflowPolicy_dropOldest && capacity < 1 {
+		panic("usage: OverflowPolicy_dropOldest needs a capacity of at least one")
+	}
+	ch := make(chan T, capacity)
+	meta := newChannelMeta(name, capacity)
+	return &OverflowChannel[T]{ch: ch, tx: SenderChannel[T]{ch, meta}, policy: policy}, ReceiverChannel[T]{ch, meta}
+}
+
+// Name returns the channel's name.
+func (oc *OverflowChannel[T]) Name() string {
+	return oc.tx.Name()
+}
+
+// Send sends the value according to the channel's policy.  Under the drop
+// policies, it never blocks, and returns nil even if a message was dropped;
+// under OverflowPolicy_block, it waits for room, and returns the context's
+// error if the context is done first.  Under all policies, it returns an
+// ErrChannelClosed if the channel has been closed.
+//
+// The context is also used for drop warnings, which go to the supervisor of
+// the task it belongs to.
+func (oc *OverflowChannel[T]) Send(ctx context.Context, v T) error {
+	switch oc.policy {
+	case OverflowPolicy_dropNewest:
+		err := oc.tx.TrySend(v)
+		if err == Nonblock {
+			oc.drop(ctx)
+			return nil
+		}
+		return err
+	case OverflowPolicy_dropOldest:
+		oc.mu.Lock()
+		defer oc.mu.Unlock()
+		for {
+			err := oc.tx.TrySend(v)
+			if err != Nonblock {
+				return err
+			}
+			select {
+			case <-oc.ch:
+				oc.dropLocked(ctx)
+			default: // the consumer took one; there's room now.
+			}
+		}
+	default:
+		return sendCase[T]{oc.tx, v, nil}.selectAlone(ctx)
+	}
+}
+
+// Close closes the channel, just like SenderChannel.Close.
+func (oc *OverflowChannel[T]) Close() error {
+	return oc.tx.Close()
+}
+
+// Dropped returns how many messages have been dropped so far.
+func (oc *OverflowChannel[T]) Dropped() uint64 {
+	return oc.dropped.Load()
+}
+
+// SetDropWarnings turns on warnings about dropped messages: when a message
+// is dropped, and it's been at least the given interval since the last such
+// warning, a dropped-message warning saying how many were dropped since
+// then goes to the supervisor of the task whose context was given to Send.
+// (Drops after the last warning are only reported once another drop comes
+// along; there's no timer.)  An interval of zero turns the warnings off,
+// which is the default.
+//
+// Call it before the channel is in use.
+func (oc *OverflowChannel[T]) SetDropWarnings(interval time.Duration) {
+	oc.warnEvery = interval
+}
+
+func (oc *OverflowChannel[T]) drop(ctx context.Context) {
+	if oc.warnEvery <= 0 {
+		oc.dropped.Add(1)
+		return
+	}
+	oc.mu.Lock()
+	defer oc.mu.Unlock()
+	oc.dropLocked(ctx)
+}
+
+func (oc *OverflowChannel[T]) dropLocked(ctx context.Context) {
+	oc.dropped.Add(1)
+	if oc.warnEvery <= 0 {
+		return
+	}
+	oc.unwarned++
+	if now := time.Now(); now.Sub(oc.lastWarn) >= oc.warnEvery {
+		info, _ := ctx.Value(ctxKey{}).(ctxInfo)
+		info.warn(WarningKind_dropped, fmt.Sprintf("channel %q dropped %d messages (%s) since the last warning", oc.Name(), oc.unwarned, oc.policy))
+		oc.lastWarn = now
+		oc.unwarned = 0
+	}
+}
@@ -0,0 +1,81 @@
+package sup_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/warpfork/go-sup"
+)
+
+func TestOverflowChannel(t *testing.T) {
+	drain := func(rx sup.ReceiverChannel[int]) []int {
+		var got []int
+		for {
+			v, err := rx.TryRecv()
+			if err != nil {
+				return got
+			}
+			got = append(got, v)
+		}
+	}
+	for _, tr := range []struct {
+		policy  sup.OverflowPolicy
+		expect  string
+		dropped uint64
+	}{
+		{sup.OverflowPolicy_dropNewest, "[1 2 3]", 2},
+		{sup.OverflowPolicy_dropOldest, "[3 4 5]", 2},
+	} {
+		t.Run(tr.policy.String()+" should keep the right messages", func(t *testing.T) {
+			tx, rx := sup.NewOverflowChannel[int]("telemetry", 3, tr.policy)
+			for i := 1; i <= 5; i++ {
+				mustEqual(t, tx.Send(context.Background(), i), nil)
+			}
+			shouldEqual(t, fmt.Sprint(drain(rx)), tr.expect)
+			shouldEqual(t, tx.Dropped(), tr.dropped)
+		})
+	}
+	t.Run("block should wait for room", func(t *testing.T) {
+		tx, rx := sup.NewOverflowChannel[int]("telemetry", 3, sup.OverflowPolicy_block)
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+		defer cancel()
+		var err error
+		for i := 1; i <= 5 && err == nil; i++ {
+			err = tx.Send(ctx, i)
+		}
+		shouldEqual(t, errors.Is(err, context.DeadlineExceeded), true)
+		shouldEqual(t, fmt.Sprint(drain(rx)), "[1 2 3]")
+		shouldEqual(t, tx.Dropped(), uint64(0))
+	})
+	t.Run("sends after close should fail", func(t *testing.T) {
+		for _, policy := range []sup.OverflowPolicy{sup.OverflowPolicy_block, sup.OverflowPolicy_dropNewest, sup.OverflowPolicy_dropOldest} {
+			tx, _ := sup.NewOverflowChannel[int]("telemetry", 1, policy)
+			tx.Close()
+			shouldEqual(t, errors.Is(tx.Send(context.Background(), 1), sup.ErrClosed), true)
+		}
+	})
+	t.Run("drop warnings should be rate-limited", func(t *testing.T) {
+		var warnings []sup.SupervisionWarning
+		sup.SuperviseRoot(context.Background(),
+			sup.SuperviseForkJoin("main",
+				[]sup.Task{namedFunc{"producer", func(ctx context.Context) error {
+					tx, _ := sup.NewOverflowChannel[int]("telemetry", 1, sup.OverflowPolicy_dropOldest)
+					tx.SetDropWarnings(time.Hour)
+					for i := 0; i < 10; i++ {
+						tx.Send(ctx, i)
+					}
+					shouldEqual(t, tx.Dropped(), uint64(9))
+					return nil
+				}}},
+				sup.SetWarningHandler(func(w sup.SupervisionWarning) { warnings = append(warnings, w) }),
+			),
+		)
+		mustEqual(t, len(warnings), 1)
+		shouldEqual(t, warnings[0].Kind, sup.WarningKind_dropped)
+		shouldEqual(t, warnings[0].TaskPath, "main/producer")
+		shouldEqual(t, warnings[0].Message, `channel "telemetry" dropped 1 messages (drop-oldest) since the last warning`)
+	})
+}
@@ -0,0 +1,67 @@
+package sup
+
+import (
+	"context"
+	"strings"
+	"unicode"
+)
+
+// ExportPath returns the current task's path, for sending to another
+// process (in an RPC header, say), so that the other side can use
+// ImportPath to nest its own task paths under it.  It's the same as
+// CtxTaskPath.
+func ExportPath(ctx Context) string {
+	return CtxTaskPath(ctx)
+}
+
+// maxImportedPathLen caps the length of an imported path, in bytes.
+const maxImportedPathLen = 256
+
+// ImportPath returns a context in which the given path, received from
+// another process (see ExportPath), is the current task path.  Supervisors
+// run with that context put their tasks' paths under it, so a task which
+// serves a remote caller gets a path like "frontend/handler/backend/worker",
+// and traces across the two services line up.
+//
+// The remote path is untrusted input, so it's sanitized first: it's split
+// into names (see SplitTaskPath), and empty, "." and ".." segments are dropped (so it can't
+// climb out of where it's put, or smuggle in separators); control and other
+// unprintable characters are replaced with underscores; and if the result
+// is longer than 256 bytes, it's cut short after the last whole segment
+// that fits, and a "…" segment is added to show it.  If nothing is left,
+// the context is returned unchanged.
+func ImportPath(ctx Context, remotePath string) Context {
+	path := sanitizeImportedPath(remotePath)
+	if path == "" {
+		return ctx
+	}
+	info, _ := ctx.Value(ctxKey{}).(ctxInfo)
+	info.path = path
+	return context.WithValue(ctx, ctxKey{}, info)
+}
+
+func sanitizeImportedPath(remotePath string) string {
+	var sb strings.Builder
+	for _, seg := range SplitTaskPath(remotePath) {
+		if seg == "" || seg == "." || seg == ".." {
+			continue
+		}
+		seg = escapeTaskName(strings.Map(func(r rune) rune {
+			if r == unicode.ReplacementChar || !unicode.IsPrint(r) {
+				return '_'
+			}
+			return r
+		}, seg))
+		if sb.Len()+1+len(seg) > maxImportedPathLen {
+			if sb.Len() > 0 {
+				sb.WriteString(TaskPathSeparator + "…")
+			}
+			break
+		}
+		if sb.Len() > 0 {
+			sb.WriteString(TaskPathSeparator)
+		}
+		sb.WriteString(seg)
+	}
+	return sb.String()
+}
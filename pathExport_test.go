@@ -0,0 +1,46 @@
+package sup_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/warpfork/go-sup"
+)
+
+func TestImportPath(t *testing.T) {
+	pathUnder := func(remote string) string {
+		var path string
+		sup.SuperviseRoot(sup.ImportPath(context.Background(), remote),
+			sup.SuperviseForkJoin("backend", []sup.Task{
+				namedFunc{"worker", func(ctx context.Context) error {
+					path = sup.ExportPath(ctx)
+					return nil
+				}},
+			}),
+		)
+		return path
+	}
+	for _, tr := range []struct {
+		name   string
+		remote string
+		expect string
+	}{
+		{"plain paths should nest", "frontend/handler", "frontend/handler/backend/worker"},
+		{"an empty path should change nothing", "", "backend/worker"},
+		{"separators should be tidied", "/frontend//handler/", "frontend/handler/backend/worker"},
+		{"dot segments should be dropped", "../../etc/./passwd", "etc/passwd/backend/worker"},
+		{"nothing but dots should change nothing", "../..", "backend/worker"},
+		{"control characters should be replaced", "front\nend/hand\x00ler", "front_end/hand_ler/backend/worker"},
+		{"invalid utf-8 should be replaced", "front\xffend", "front_end/backend/worker"},
+	} {
+		t.Run(tr.name, func(t *testing.T) {
+			shouldEqual(t, pathUnder(tr.remote), tr.expect)
+		})
+	}
+	t.Run("long paths should be capped at a segment boundary", func(t *testing.T) {
+		seg := strings.Repeat("x", 100)
+		path := pathUnder(seg + "/" + seg + "/" + seg + "/" + seg)
+		shouldEqual(t, path, seg+"/"+seg+"/…/backend/worker")
+	})
+}
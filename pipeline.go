@@ -0,0 +1,204 @@
+package sup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Pipeline assembles a chain of stages (a source, any number of stages in
+// the middle, and a sink) into one supervisor, building the channels
+// between them, and closing each one when the stage feeding it finishes.
+//
+// Declare the stages with PipelineSource, PipelineStage, and PipelineSink,
+// in order (each takes the Pipe the one before it returned, so the types
+// carry through), then call Task to get the supervisor which runs the lot:
+//
+//	p := sup.NewPipeline("etl")
+//	lines := sup.PipelineSource(p, "read", readLines)
+//	records := sup.PipelineStage(lines, "parse", 4, parseLine)
+//	sup.PipelineSink(records, "store", 2, storeRecord)
+//	err := sup.SuperviseRoot(ctx, p.Task())
+//
+// The stages in the middle and the sink are each a WorkerPool, with the
+// declared number of workers, reading from the Pipe before them; the source
+// is a single task.  When the source returns, its Pipe is closed, and once
+// the next stage's workers have taken everything from it, they return, and
+// that stage's Pipe is closed in turn, and so on down the line, so the
+// pipeline finishes when the sink has handled everything.
+//
+// An error from any stage (or a panic) cancels the whole pipeline, and is
+// returned wrapped in an ErrPipelineStage, saying which stage it was.  A soft
+// stop ends the stages after the items they're on, and closes their Pipes
+// just as finishing does.
+//
+// A Pipeline can only be run once, since its channels can only be closed
+// once.
+type Pipeline struct {
+	name     string
+	capacity int
+	stages   []Task
+	pipes    int // the number of Pipes made so far; the newest is the only one which can be consumed.
+	sunk     bool
+}
+
+// Pipe is the channel between two stages of a Pipeline.  It's what each
+// stage returns, for the next stage to be declared with.
+type Pipe[T any] struct {
+	p  *Pipeline
+	id int
+	tx SenderChannel[T]
+	rx ReceiverChannel[T]
+}
+
+// ErrPipelineStage is the error returned by a Pipeline when one of its
+// stages fails.
+type ErrPipelineStage struct {
+	Pipeline string // the name of the Pipeline.
+	Stage    string // the name of the stage.
+	Err      error  // the stage's error.
+}
+
+func (e ErrPipelineStage) Error() string {
+	return fmt.Sprintf("pipeline %q failed at stage %q: %v", e.Pipeline, e.Stage, e.Err)
+}
+
+func (e ErrPipelineStage) Unwrap() error {
+	return e.Err
+}
+
+// NewPipeline returns an empty Pipeline with the given name, which is what
+// its supervisor is named.  The Pipes between its stages are unbuffered,
+// unless SetCapacity says otherwise.
+func NewPipeline(name string) *Pipeline {
+	return &Pipeline{name: name}
+}
+
+// SetCapacity sets the buffer capacity of the Pipes made by the stages
+// declared after it.  It returns the Pipeline, for chaining.
+func (p *Pipeline) SetCapacity(n int) *Pipeline {
+	if n < 0 {
+		panic("usage: pipe capacity cannot be negative")
+	}
+	p.capacity = n
+	return p
+}
+
+// Task returns the supervisor which runs the pipeline.  It panics if the
+// pipeline doesn't have a sink yet.
+func (p *Pipeline) Task() Supervisor {
+	if !p.sunk {
+		panic("usage: a pipeline needs a sink")
+	}
+	return SuperviseForkJoin(p.name, p.stages)
+}
+
+// PipelineSource declares the first stage of a pipeline: a task which calls
+// emit for each item it produces, and returns when there are no more (then,
+// the Pipe it returns is closed).  emit blocks while the next stage is busy
+// (and the Pipe is full), and returns an error if the context is done, or
+// ErrLoopDone if the pipeline is soft stopped; the source should return that
+// error.  (ErrLoopDone from the source is a clean finish, as in RunSteps.)
+//
+// It panics if the pipeline already has a source.
+func PipelineSource[T any](p *Pipeline, name string, fn func(ctx Context, emit func(T) error) error) Pipe[T] {
+	if p.pipes > 0 {
+		panic("usage: a pipeline can only have one source")
+	}
+	out := newPipe[T](p, name)
+	p.add(name, NamedFunc(name, func(ctx Context) error {
+		err := fn(ctx, func(v T) error {
+			return out.send(ctx, v)
+		})
+		if errors.Is(err, ErrLoopDone) {
+			return nil
+		}
+		return err
+	}), out.tx)
+	return out
+}
+
+// PipelineStage declares a stage in the middle of a pipeline: a pool of the
+// given number of workers, each taking items from the Pipe before it, and
+// calling fn with them.  fn returns the item to pass on, whether to pass it
+// on at all (false filters it out), and an error, which stops the pipeline.
+// (This is the same shape as Pump's transform.)
+//
+// Items may be passed on out of order, if there's more than one worker.
+//
+// It panics if the Pipe has already been consumed by another stage, or
+// isn't the newest in its pipeline.
+func PipelineStage[In, Out any](in Pipe[In], name string, workers int, fn func(Context, In) (Out, bool, error)) Pipe[Out] {
+	in.consume()
+	out := newPipe[Out](in.p, name)
+	in.p.add(name, newWorkerPool(name, workers, in.tx, in.rx, func(ctx Context, v In) error {
+		w, keep, err := fn(ctx, v)
+		if err != nil || !keep {
+			return err
+		}
+		return out.send(ctx, w)
+	}), out.tx)
+	return out
+}
+
+// PipelineSink declares the last stage of a pipeline: a pool of the given
+// number of workers, each taking items from the Pipe before it, and calling
+// fn with them.
+//
+// It panics if the Pipe has already been consumed by another stage, or
+// isn't the newest in its pipeline.
+func PipelineSink[T any](in Pipe[T], name string, workers int, fn func(Context, T) error) {
+	in.consume()
+	in.p.add(name, newWorkerPool(name, workers, in.tx, in.rx, fn), nil)
+	in.p.sunk = true
+}
+
+func newPipe[T any](p *Pipeline, stage string) Pipe[T] {
+	p.pipes++
+	tx, rx := NewChannel[T](p.name+"."+stage+".out", p.capacity)
+	return Pipe[T]{p, p.pipes, tx, rx}
+}
+
+// send passes an item down the Pipe, waiting for the next stage to take it.
+// If the pipeline is soft stopped meanwhile, it gives up, returning
+// ErrLoopDone: the next stage's workers stop taking items then, so nothing
+// would ever take it.
+func (out Pipe[T]) send(ctx Context, v T) error {
+	return Select(ctx, out.tx.SendAndThen(v, nil), softStopCase(ctx))
+}
+
+func (in Pipe[T]) consume() {
+	if in.p.sunk || in.id != in.p.pipes {
+		panic("usage: each pipe can only feed one stage, and stages must be declared in order")
+	}
+}
+
+func (p *Pipeline) add(name string, t Task, out io.Closer) {
+	p.stages = append(p.stages, pipelineStageTask{p.name, name, t, out})
+}
+
+// pipelineStageTask runs a stage, and when it finishes, closes its Pipe, so
+// the next stage finishes too once it's taken everything.  If the stage
+// fails, the Pipe is left open: the rest of the pipeline is being cancelled
+// anyway, and closing it would only make the next stage look finished.
+type pipelineStageTask struct {
+	pipeline string
+	name     string
+	task     Task
+	out      io.Closer
+}
+
+func (t pipelineStageTask) Name() string {
+	return t.name
+}
+
+func (t pipelineStageTask) Run(ctx context.Context) error {
+	if err := t.task.Run(ctx); err != nil {
+		return ErrPipelineStage{t.pipeline, t.name, err}
+	}
+	if t.out != nil {
+		t.out.Close()
+	}
+	return nil
+}
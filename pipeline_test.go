@@ -0,0 +1,110 @@
+package sup_test
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/warpfork/go-sup"
+)
+
+func TestPipeline(t *testing.T) {
+	t.Run("items should flow through every stage, and the pipeline should finish", func(t *testing.T) {
+		var mu sync.Mutex
+		var got []int
+		p := sup.NewPipeline("etl").SetCapacity(2)
+		lines := sup.PipelineSource(p, "read", func(ctx sup.Context, emit func(string) error) error {
+			for _, s := range []string{"1", "2", "skip", "3", "4"} {
+				if err := emit(s); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		nums := sup.PipelineStage(lines, "parse", 3, func(ctx sup.Context, s string) (int, bool, error) {
+			n, err := strconv.Atoi(s)
+			return n, err == nil, nil
+		})
+		squares := sup.PipelineStage(nums, "square", 2, func(ctx sup.Context, n int) (int, bool, error) {
+			return n * n, true, nil
+		})
+		sup.PipelineSink(squares, "collect", 1, func(ctx sup.Context, n int) error {
+			mu.Lock()
+			defer mu.Unlock()
+			got = append(got, n)
+			return nil
+		})
+		mustEqual(t, sup.SuperviseRoot(context.Background(), p.Task()), nil)
+		sort.Ints(got)
+		shouldEqual(t, len(got), 4)
+		shouldEqual(t, got[3], 16)
+	})
+	t.Run("an error should stop the pipeline, and say which stage it came from", func(t *testing.T) {
+		boom := errors.New("boom")
+		p := sup.NewPipeline("etl")
+		nums := sup.PipelineSource(p, "count", func(ctx sup.Context, emit func(int) error) error {
+			for i := 0; ; i++ {
+				if err := emit(i); err != nil {
+					return err
+				}
+			}
+		})
+		sup.PipelineSink(nums, "picky", 2, func(ctx sup.Context, n int) error {
+			if n == 5 {
+				return boom
+			}
+			return nil
+		})
+		err := unwrapChild(sup.SuperviseRoot(context.Background(), p.Task()))
+		var stageErr sup.ErrPipelineStage
+		mustEqual(t, errors.As(err, &stageErr), true)
+		shouldEqual(t, stageErr.Pipeline, "etl")
+		shouldEqual(t, stageErr.Stage, "picky")
+		shouldEqual(t, unwrapChild(stageErr.Err), boom)
+	})
+	t.Run("a soft stop should end an endless pipeline cleanly", func(t *testing.T) {
+		p := sup.NewPipeline("etl")
+		nums := sup.PipelineSource(p, "count", func(ctx sup.Context, emit func(int) error) error {
+			for i := 0; ; i++ {
+				if err := emit(i); err != nil {
+					return err
+				}
+			}
+		})
+		doubled := sup.PipelineStage(nums, "double", 2, func(ctx sup.Context, n int) (int, bool, error) {
+			return n * 2, true, nil
+		})
+		sunk := make(chan struct{})
+		var once sync.Once
+		sup.PipelineSink(doubled, "discard", 1, func(ctx sup.Context, n int) error {
+			once.Do(func() { close(sunk) })
+			return nil
+		})
+		svr := p.Task()
+		go func() {
+			<-sunk
+			svr.SoftStop()
+		}()
+		shouldEqual(t, sup.SuperviseRoot(context.Background(), svr), nil)
+	})
+	t.Run("stages declared out of order should panic", func(t *testing.T) {
+		p := sup.NewPipeline("etl")
+		src := sup.PipelineSource(p, "read", func(ctx sup.Context, emit func(int) error) error { return nil })
+		sup.PipelineStage(src, "a", 1, func(ctx sup.Context, n int) (int, bool, error) { return n, true, nil })
+		defer func() {
+			shouldEqual(t, recover(), "usage: each pipe can only feed one stage, and stages must be declared in order")
+		}()
+		sup.PipelineSink(src, "b", 1, func(ctx sup.Context, n int) error { return nil })
+	})
+	t.Run("a pipeline without a sink should panic", func(t *testing.T) {
+		p := sup.NewPipeline("etl")
+		sup.PipelineSource(p, "read", func(ctx sup.Context, emit func(int) error) error { return nil })
+		defer func() {
+			shouldEqual(t, recover(), "usage: a pipeline needs a sink")
+		}()
+		p.Task()
+	})
+}
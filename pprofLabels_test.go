@@ -0,0 +1,32 @@
+package sup_test
+
+import (
+	"context"
+	"runtime/pprof"
+	"testing"
+
+	"github.com/warpfork/go-sup"
+)
+
+func TestPprofLabels(t *testing.T) {
+	labelOf := func(opts ...sup.SupervisionOptions) string {
+		var label string
+		sup.SuperviseRoot(context.Background(),
+			sup.SuperviseForkJoin("main", []sup.Task{
+				sup.SuperviseForkJoin("inner", []sup.Task{
+					namedFunc{"worker", func(ctx context.Context) error {
+						label, _ = pprof.Label(ctx, "sup_task")
+						return nil
+					}},
+				}, opts...),
+			}),
+		)
+		return label
+	}
+	t.Run("tasks should be labeled with their path", func(t *testing.T) {
+		shouldEqual(t, labelOf(), "main/inner/worker")
+	})
+	t.Run("the option should turn the labels off", func(t *testing.T) {
+		shouldEqual(t, labelOf(sup.DisablePprofLabels()), "main/inner") // still the supervisor's own.
+	})
+}
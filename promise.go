@@ -3,18 +3,64 @@ package sup
 import (
 	"context"
 	"errors"
+	"fmt"
+	"runtime"
+	"strings"
 	"sync"
+	"time"
 )
 
+// Promise holds a value which will be resolved at some point in the future
+// (or, the promise may be canceled instead).
+//
+// Any number of WaitSelectably and WaitCallback registrations may be made,
+// of either kind.  Notifications happen on the goroutine that resolves the
+// promise; or, if the promise is already resolved when you register, on a
+// goroutine the promise starts for its late notifications (never on your
+// own goroutine, so it's safe to register while holding locks the callback
+// needs).  A late WaitSelectably whose channel can take the notification
+// straight away, without blocking, is simply sent to.  Either way, a slow
+// callback, or a channel nobody reads, holds up the notifications after it
+// for the same promise, but never those for other promises.  Calling the
+// returned remove function after the promise has been resolved does
+// nothing: the notification has happened (or is happening) already.
+//
+// Notifications are delivered in registration order (first in, first out),
+// one at a time, across both kinds of registration: each channel send or
+// callback finishes before the next begins.  This is a guarantee, so you
+// can rely on it for e.g. ordered event emission.  (The one caveat is that
+// a registration made while the promise is in the middle of being resolved
+// is delivered late, and so isn't ordered with respect to the registrations
+// the resolving goroutine is still working through.  Every
+// registration made before Resolve is ordered before every one made after
+// Resolve returns.)  Since late notifications are in order too, a late
+// callback which registers on the same promise again, and waits for that
+// notification, deadlocks: it's queued behind itself.
+//
+// ValueCh returns a channel which delivers the resolved value exactly once:
+// when the promise is resolved, the value is buffered in the channel and the
+// channel is closed.  So, the first receive gets the value (with ok true),
+// and every receive after that -- by anyone -- gets nil with ok false, just as
+// for any closed channel.  If the promise is canceled, the channel is closed
+// with no value in it.  Every call returns the same channel.  The channel is
+// only allocated if ValueCh is called, so promises which don't use it pay
+// nothing for it.
 type Promise interface {
-	Cancel()             // cancels the promise, effectively resolving it with nil.
-	Resolve(interface{}) // sets the value.  panics on repeat use.
+	Cancel()                     // cancels the promise, effectively resolving it with nil.
+	Resolve(interface{})         // sets the value.  panics on repeat use.
+	TryResolve(interface{}) bool // sets the value, if the promise isn't already resolved or canceled.  returns false (rather than panicking) if it was.
 
-	Get(Context) ResolvedPromise   // blocking.  waits and returns access to the resolved value.
-	GetNow() (interface{}, error)  // nonblocking.  returns (nil,promise.Nonblock) if not yet resolved; error may be context.Canceled or promise.Nonblock or nil if resolved.
-	Wait(Context)                  // blocking.
-	WaitSelectably(chan<- Promise) // nonblocking.  cause ourself to be sent to this channel when we become resolved.  multiple use panics.
-	WaitCallback(func(Promise))    // nonblocking.  alternative to WaitSelectably which you can use if e.g. you need to send to multiple chans without waiting on each other or otherwise control rejection.  multiple use panics.
+	Get(Context) ResolvedPromise                   // blocking.  waits and returns access to the resolved value.
+	GetNow() (interface{}, error)                  // nonblocking.  returns (nil,promise.Nonblock) if not yet resolved; error may be context.Canceled or promise.Nonblock or nil if resolved.
+	AwaitValue(Context) (interface{}, error)       // blocking.  returns the resolved value, or (nil,context.Canceled) if the promise was canceled, or (nil,ctx.Err()) if the context is done first.
+	TryValue() (interface{}, bool)                 // nonblocking.  returns the value and true if resolved with a value; (nil,false) if unresolved or canceled.  safe to call concurrently with resolution (this is how to peek).
+	MustValue() interface{}                        // nonblocking.  returns the value if resolved; panics if unresolved or canceled (see DebugPromises for a more useful panic message).
+	ResolvedCh() <-chan struct{}                   // nonblocking.  returns a channel which is closed when the promise is resolved or canceled.
+	ValueCh() <-chan interface{}                   // nonblocking.  returns a channel which yields the value once, then is closed.  see below.
+	Wait(Context)                                  // blocking.
+	AwaitTimeout(time.Duration) bool               // blocking.  waits up to the given duration; returns true if the promise is resolved (or canceled).
+	WaitSelectably(chan<- Promise) (remove func()) // nonblocking.  cause ourself to be sent to this channel when we become resolved.  call remove to deregister (a no-op if the notification has already happened).
+	WaitCallback(func(Promise)) (remove func())    // nonblocking.  alternative to WaitSelectably which you can use if e.g. you need to send to multiple chans without waiting on each other or otherwise control rejection.  remove works the same.
 }
 
 type ResolvedPromise struct {
@@ -28,7 +74,56 @@ var Nonblock = errors.New("promise nonblock")
 // You can start waiting on it immediately, and resolve it (or hand it off
 // to someone else to resolve) at your leisure.
 func NewPromise() Promise {
-	return &promise{waitCh: make(chan struct{})}
+	p := newPromise()
+	p.self = p
+	return p
+}
+
+// DebugPromises, if set to true, makes every new promise record the call site
+// that created it, so that MustValue can say where the promise came from when
+// it panics.  This costs a runtime.Callers on every promise creation, so it's
+// off by default; with it off, no caller info is collected at all.
+//
+// Set it before creating any promises (e.g. in an init func or TestMain),
+// and don't change it while promises are being made.
+var DebugPromises bool
+
+func newPromise() *promise {
+	p := &promise{waitCh: make(chan struct{})}
+	if DebugPromises {
+		p.createdAt = creationSite()
+	}
+	return p
+}
+
+// creationSite returns "file:line" for the first caller outside this package.
+func creationSite() string {
+	pcs := make([]uintptr, 16)
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(3, pcs)])
+	for {
+		frame, more := frames.Next()
+		if !strings.HasPrefix(frame.Function, "github.com/warpfork/go-sup.") || !more {
+			return fmt.Sprintf("%s:%d", frame.File, frame.Line)
+		}
+	}
+}
+
+// InstrumentedPromise is a Promise which also records when it was created
+// and when it was resolved, for latency measurement.
+type InstrumentedPromise interface {
+	Promise
+	Age() time.Duration                    // time since the promise was created.
+	ResolveLatency() (time.Duration, bool) // time from creation to resolution (or cancellation); false if not yet resolved.
+}
+
+// NewInstrumentedPromise returns a new unresolved promise, just like
+// NewPromise, except it also records timestamps (so it costs a bit more).
+func NewInstrumentedPromise() InstrumentedPromise {
+	p := newPromise()
+	p.times = &promiseTimes{created: time.Now()}
+	ip := instrumentedPromise{p}
+	p.self = ip
+	return ip
 }
 
 // NewDiscardingPromise returns a dummy promise where resolved values are
@@ -40,35 +135,54 @@ func NewDiscardingPromise() Promise {
 
 type promise struct {
 	ResolvedPromise
-	mu      sync.Mutex
-	waitCh  chan struct{}
-	afterCh chan<- Promise
-	afterFn func(Promise)
+	self         Promise       // what we hand to waiters; usually ourself, but may be a wrapper.
+	times        *promiseTimes // only for instrumented promises.
+	mu           sync.Mutex
+	done         bool // set (under mu) by the first Resolve or Cancel.
+	waitCh       chan struct{}
+	valueCh      chan interface{} // only allocated if ValueCh is called.
+	waiters      []promiseWaiter
+	lastWaiterID uint64
+	late         []promiseWaiter // registrations made after resolution, waiting for delivery.
+	lateRunning  bool            // whether a goroutine is delivering late.
+	createdAt    string          // "file:line"; only recorded if DebugPromises was set.
 }
 
 func (p *promise) Cancel() {
 	p.mu.Lock()
-	if p.Value != nil || p.Error != nil {
+	if p.done {
 		p.mu.Unlock()
 		return
 	}
+	p.done = true
 	p.Error = context.Canceled
 	p.notifyAndUnlock()
 }
 func (p *promise) Resolve(v interface{}) {
 	p.mu.Lock()
-	if p.Error != nil {
-		// i've been raced.  drop my effect.
+	if p.done {
 		p.mu.Unlock()
-		return
-	}
-	if p.Value != nil {
+		if p.Error != nil {
+			// i've been raced.  drop my effect.
+			return
+		}
 		// i've been misused!  rage.
-		p.mu.Unlock()
 		panic("multiple Resolve() calls on Promise")
 	}
+	p.done = true
+	p.Value = v
+	p.notifyAndUnlock()
+}
+func (p *promise) TryResolve(v interface{}) bool {
+	p.mu.Lock()
+	if p.done {
+		p.mu.Unlock()
+		return false
+	}
+	p.done = true
 	p.Value = v
 	p.notifyAndUnlock()
+	return true
 }
 func (p *promise) Get(ctx Context) ResolvedPromise {
 	select {
@@ -87,46 +201,213 @@ func (p *promise) GetNow() (v interface{}, err error) {
 	}
 	return
 }
-func (p *promise) Wait(ctx Context) {
+func (p *promise) AwaitValue(ctx Context) (interface{}, error) {
 	select {
 	case <-p.waitCh:
+		return p.Value, p.Error
 	case <-ctx.Done():
+		return nil, ctx.Err()
 	}
 }
-func (p *promise) WaitSelectably(afterCh chan<- Promise) {
+func (p *promise) TryValue() (interface{}, bool) {
+	select {
+	case <-p.waitCh:
+		return p.Value, p.Error == nil
+	default:
+		return nil, false
+	}
+}
+func (p *promise) MustValue() interface{} {
+	select {
+	case <-p.waitCh:
+		if p.Error == nil {
+			return p.Value
+		}
+		panic("promise read after cancellation: " + p.describeCreation())
+	default:
+		panic("promise read before resolution: " + p.describeCreation())
+	}
+}
+func (p *promise) describeCreation() string {
+	if p.createdAt == "" {
+		return "created at unknown site (set sup.DebugPromises to record it)"
+	}
+	return "created at " + p.createdAt
+}
+func (p *promise) ResolvedCh() <-chan struct{} {
+	return p.waitCh
+}
+func (p *promise) ValueCh() <-chan interface{} {
 	p.mu.Lock()
-	if p.afterCh != nil {
-		p.mu.Unlock()
-		panic("multiple WaitSelectably() calls on Promise")
+	defer p.mu.Unlock()
+	if p.valueCh == nil {
+		p.valueCh = make(chan interface{}, 1)
+		if p.done {
+			p.fillValueCh()
+		}
+	}
+	return p.valueCh
+}
+
+// fillValueCh delivers the result into valueCh and closes it.
+// Call only with mu held, once done is set and if valueCh is allocated.
+func (p *promise) fillValueCh() {
+	if p.Error == nil {
+		p.valueCh <- p.Value
+	}
+	close(p.valueCh)
+}
+func (p *promise) Wait(ctx Context) {
+	select {
+	case <-p.waitCh:
+	case <-ctx.Done():
 	}
-	p.afterCh = afterCh
-	if p.Value != nil || p.Error != nil {
-		afterCh <- p
+}
+func (p *promise) AwaitTimeout(d time.Duration) bool {
+	select {
+	case <-p.waitCh:
+		return true
+	default:
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-p.waitCh:
+		return true
+	case <-timer.C:
+		return false
 	}
+}
+func (p *promise) WaitSelectably(afterCh chan<- Promise) (remove func()) {
+	return p.register(promiseWaiter{ch: afterCh})
+}
+func (p *promise) WaitCallback(afterFn func(Promise)) (remove func()) {
+	return p.register(promiseWaiter{fn: afterFn})
+}
+
+// promiseWaiter is one registration from WaitSelectably or WaitCallback.
+// Exactly one of ch or fn is set.
+type promiseWaiter struct {
+	id uint64
+	ch chan<- Promise
+	fn func(Promise)
+}
+
+func (w promiseWaiter) notify(p Promise) {
+	if w.ch != nil {
+		w.ch <- p
+	} else {
+		w.fn(p)
+	}
+}
+
+// register adds a waiter, or if we're already resolved, delivers its
+// notification late.
+func (p *promise) register(w promiseWaiter) (remove func()) {
+	p.mu.Lock()
+	if p.done {
+		p.notifyLateAndUnlock(w)
+		return func() {}
+	}
+	p.lastWaiterID++
+	w.id = p.lastWaiterID
+	p.waiters = append(p.waiters, w)
 	p.mu.Unlock()
+	return func() { p.unregister(w.id) }
 }
-func (p *promise) WaitCallback(afterFn func(Promise)) {
+
+// unregister removes a waiter, if it's still present.  The list is compacted
+// (and the vacated slot zeroed) so nothing keeps the removed waiter alive.
+func (p *promise) unregister(id uint64) {
 	p.mu.Lock()
-	if p.afterFn != nil {
-		p.mu.Unlock()
-		panic("multiple WaitCallback() calls on Promise")
+	defer p.mu.Unlock()
+	for i, w := range p.waiters {
+		if w.id == id {
+			copy(p.waiters[i:], p.waiters[i+1:])
+			p.waiters[len(p.waiters)-1] = promiseWaiter{}
+			p.waiters = p.waiters[:len(p.waiters)-1]
+			return
+		}
 	}
-	p.afterFn = afterFn
-	if p.Value != nil || p.Error != nil {
-		afterFn(p)
+}
+
+// notifyLateAndUnlock delivers the notification for a waiter registered
+// after the promise was resolved.  A channel which can take it at once, with
+// nothing queued ahead of it, gets it here; otherwise, it's queued, for a
+// goroutine which works through the queue and exits when it's empty.  (So,
+// fan-in of many resolved promises costs a goroutine per promise with
+// notifications pending, not per registration; and one waiter which blocks
+// only holds up the rest of its own promise's queue.)  Call with mu held.
+func (p *promise) notifyLateAndUnlock(w promiseWaiter) {
+	if w.ch != nil && !p.lateRunning {
+		select {
+		case w.ch <- p.self:
+			p.mu.Unlock()
+			return
+		default:
+		}
 	}
+	p.late = append(p.late, w)
+	start := !p.lateRunning
+	p.lateRunning = true
 	p.mu.Unlock()
+	if start {
+		go p.deliverLate()
+	}
+}
+
+func (p *promise) deliverLate() {
+	for {
+		p.mu.Lock()
+		batch := p.late
+		p.late = nil
+		if len(batch) == 0 {
+			p.lateRunning = false
+			p.mu.Unlock()
+			return
+		}
+		p.mu.Unlock()
+		for _, w := range batch {
+			w.notify(p.self)
+		}
+	}
 }
+
 func (p *promise) notifyAndUnlock() {
-	afterCh, afterFn := p.afterCh, p.afterFn
+	if p.times != nil {
+		p.times.resolvedAt = time.Now()
+	}
+	if p.valueCh != nil {
+		p.fillValueCh()
+	}
+	waiters := p.waiters
+	p.waiters = nil
 	p.mu.Unlock()
 	close(p.waitCh)
-	if afterCh != nil {
-		afterCh <- p
+	for _, w := range waiters {
+		w.notify(p.self)
 	}
-	if afterFn != nil {
-		afterFn(p)
+}
+
+type promiseTimes struct {
+	created    time.Time
+	resolvedAt time.Time // set (under the promise's mu) when resolved.
+}
+
+type instrumentedPromise struct {
+	*promise
+}
+
+func (p instrumentedPromise) Age() time.Duration {
+	return time.Since(p.times.created)
+}
+func (p instrumentedPromise) ResolveLatency() (time.Duration, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !p.done {
+		return 0, false
 	}
+	return p.times.resolvedAt.Sub(p.times.created), true
 }
 
 type discardPromise struct {
@@ -144,8 +425,23 @@ func (p *discardPromise) Resolve(interface{}) {
 	p.resolved = true
 	p.mu.Unlock()
 }
-func (p discardPromise) Get(Context) ResolvedPromise   { panic("discardpromise") }
-func (p discardPromise) GetNow() (interface{}, error)  { panic("discardpromise") }
-func (p discardPromise) Wait(Context)                  { panic("discardpromise") }
-func (p discardPromise) WaitSelectably(chan<- Promise) { panic("discardpromise") }
-func (p discardPromise) WaitCallback(func(Promise))    { panic("discardpromise") }
+func (p *discardPromise) TryResolve(interface{}) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.resolved {
+		return false
+	}
+	p.resolved = true
+	return true
+}
+func (p *discardPromise) Get(Context) ResolvedPromise             { panic("discardpromise") }
+func (p *discardPromise) GetNow() (interface{}, error)            { panic("discardpromise") }
+func (p *discardPromise) AwaitValue(Context) (interface{}, error) { panic("discardpromise") }
+func (p *discardPromise) TryValue() (interface{}, bool)           { panic("discardpromise") }
+func (p *discardPromise) MustValue() interface{}                  { panic("discardpromise") }
+func (p *discardPromise) ResolvedCh() <-chan struct{}             { panic("discardpromise") }
+func (p *discardPromise) ValueCh() <-chan interface{}             { panic("discardpromise") }
+func (p *discardPromise) Wait(Context)                            { panic("discardpromise") }
+func (p *discardPromise) AwaitTimeout(time.Duration) bool         { panic("discardpromise") }
+func (p *discardPromise) WaitSelectably(chan<- Promise) func()    { panic("discardpromise") }
+func (p *discardPromise) WaitCallback(func(Promise)) func()       { panic("discardpromise") }
@@ -0,0 +1,156 @@
+package sup
+
+import (
+	"context"
+	"reflect"
+)
+
+// AwaitAll blocks until every one of the given promises is resolved (or
+// canceled), or until the context is done.  Returns true if all the promises
+// were resolved, or false if the context ended the wait first.
+//
+// The promises are waited on in order, on the calling goroutine; no
+// goroutines are spawned and no callbacks are registered on the promises,
+// so this is fine to use on large numbers of promises.  The same promise
+// may appear more than once.  An empty list returns true immediately.
+func AwaitAll(ctx Context, ps ...Promise) bool {
+	for _, p := range ps {
+		select {
+		case <-p.ResolvedCh():
+			continue
+		default:
+		}
+		select {
+		case <-p.ResolvedCh():
+		case <-ctx.Done():
+			return false
+		}
+	}
+	return true
+}
+
+// CollectAll waits for all the given promises as per AwaitAll, and then
+// returns their values, in the same order as the promises were given.
+//
+// If the context ends the wait first, the returned slice is nil and the
+// error is the context's error.  If any of the promises was canceled, its
+// value in the slice is nil, and the error is context.Canceled (the other
+// values are still returned).
+func CollectAll(ctx Context, ps ...Promise) ([]interface{}, error) {
+	if !AwaitAll(ctx, ps...) {
+		return nil, ctx.Err()
+	}
+	var err error
+	vs := make([]interface{}, len(ps))
+	for i, p := range ps {
+		v, ok := p.TryValue()
+		if !ok {
+			err = context.Canceled
+		}
+		vs[i] = v
+	}
+	return vs, err
+}
+
+// AwaitAny blocks until any one of the given promises is resolved (or
+// canceled), or until the context is done.  Returns the index of the first
+// promise seen to be resolved, and its value, and true; or (-1,nil,false) if
+// the context ended the wait first.  An empty list returns (-1,nil,false)
+// immediately, since there's nothing that could ever win.
+//
+// A canceled promise counts as resolved (with a nil value); use TryValue on
+// ps[idx] if you need to tell the difference.  If several promises are
+// already resolved when AwaitAny is called, the one with the lowest index
+// wins; after that, it's whichever the runtime notices first.
+//
+// AwaitAny registers nothing on the promises -- it selects over their
+// ResolvedCh channels directly -- so there's nothing left behind on the
+// promises that didn't win, and no goroutines are spawned.  (Except that
+// reflect.Select can't take more than 65536 cases; so for more promises
+// than that, it registers a WaitCallback on each instead, and removes them
+// all again before it returns.)
+func AwaitAny(ctx Context, ps ...Promise) (idx int, v interface{}, ok bool) {
+	if len(ps) == 0 {
+		return -1, nil, false
+	}
+	for i, p := range ps {
+		select {
+		case <-p.ResolvedCh():
+			v, _ := p.TryValue()
+			return i, v, true
+		default:
+		}
+	}
+	if len(ps) >= maxSelectCases {
+		return awaitAnyByCallback(ctx, ps)
+	}
+	cases := make([]reflect.SelectCase, len(ps)+1)
+	for i, p := range ps {
+		cases[i] = reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(p.ResolvedCh())}
+	}
+	cases[len(ps)] = reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(ctx.Done())}
+	chosen, _, _ := reflect.Select(cases)
+	if chosen == len(ps) {
+		return -1, nil, false
+	}
+	v, _ = ps[chosen].TryValue()
+	return chosen, v, true
+}
+
+// maxSelectCases is the most cases reflect.Select will take.
+const maxSelectCases = 1 << 16
+
+// awaitAnyByCallback is AwaitAny for too many promises to select over.
+func awaitAnyByCallback(ctx Context, ps []Promise) (idx int, v interface{}, ok bool) {
+	won := make(chan int, 1)
+	removes := make([]func(), len(ps))
+	for i, p := range ps {
+		i := i
+		removes[i] = p.WaitCallback(func(Promise) {
+			select {
+			case won <- i:
+			default: // someone else won already.
+			}
+		})
+	}
+	defer func() {
+		for _, remove := range removes {
+			remove()
+		}
+	}()
+	select {
+	case i := <-won:
+		v, _ = ps[i].TryValue()
+		return i, v, true
+	case <-ctx.Done():
+		return -1, nil, false
+	}
+}
+
+// Then returns a new promise which will be resolved with fn(v) when the
+// given promise is resolved with v.  If the given promise is canceled,
+// the derived promise is canceled too (and fn is not called).
+//
+// The derived promise is an ordinary promise (as from NewPromise), so all
+// of the usual ways of waiting on it work, and it can be canceled on its own.
+//
+// Then registers a WaitCallback on the source promise, and fn is called from
+// it: so, on the goroutine which resolves the source promise (or the one
+// which delivers its late notifications, if it's resolved already), and no
+// goroutine is left waiting on a source promise which is never resolved.  fn should not block; the
+// source promise's other waiters, and anyone waiting on the derived
+// promise, are waiting on it.  If the derived promise is canceled first, the
+// registration is removed, and fn is never called.
+func Then(p Promise, fn func(interface{}) interface{}) Promise {
+	derived := NewPromise()
+	remove := p.WaitCallback(func(p Promise) {
+		v, ok := p.TryValue()
+		if !ok {
+			derived.Cancel()
+			return
+		}
+		derived.Resolve(fn(v))
+	})
+	derived.WaitCallback(func(Promise) { remove() })
+	return derived
+}
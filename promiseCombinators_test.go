@@ -0,0 +1,188 @@
+package sup_test
+
+import (
+	"context"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/warpfork/go-sup"
+)
+
+func TestAwaitAll(t *testing.T) {
+	t.Run("empty should return immediately", func(t *testing.T) {
+		shouldEqual(t, sup.AwaitAll(context.Background()), true)
+		vs, err := sup.CollectAll(context.Background())
+		shouldEqual(t, len(vs), 0)
+		shouldEqual(t, err, nil)
+	})
+	t.Run("mixed resolved and pending, with duplicates", func(t *testing.T) {
+		p1, p2, p3 := sup.NewPromise(), sup.NewPromise(), sup.NewPromise()
+		p2.Resolve(2)
+		go p3.Resolve(3)
+		go p1.Resolve(1)
+		vs, err := sup.CollectAll(context.Background(), p1, p2, p3, p1)
+		shouldEqual(t, err, nil)
+		mustEqual(t, len(vs), 4)
+		shouldEqual(t, vs[0], 1)
+		shouldEqual(t, vs[1], 2)
+		shouldEqual(t, vs[2], 3)
+		shouldEqual(t, vs[3], 1)
+	})
+	t.Run("canceled promise should be reported", func(t *testing.T) {
+		p1, p2 := sup.NewPromise(), sup.NewPromise()
+		p1.Resolve(1)
+		p2.Cancel()
+		vs, err := sup.CollectAll(context.Background(), p1, p2)
+		shouldEqual(t, err, context.Canceled)
+		shouldEqual(t, vs[0], 1)
+		shouldEqual(t, vs[1], nil)
+	})
+	t.Run("context should end the wait", func(t *testing.T) {
+		p1, p2 := sup.NewPromise(), sup.NewPromise()
+		p1.Resolve(1)
+		ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+		defer cancel()
+		shouldEqual(t, sup.AwaitAll(ctx, p1, p2), false)
+		vs, err := sup.CollectAll(ctx, p1, p2)
+		shouldEqual(t, vs == nil, true)
+		shouldEqual(t, err, context.DeadlineExceeded)
+	})
+}
+
+func TestAwaitAny(t *testing.T) {
+	t.Run("already resolved should win by lowest index", func(t *testing.T) {
+		p1, p2, p3 := sup.NewPromise(), sup.NewPromise(), sup.NewPromise()
+		p3.Resolve(3)
+		p2.Resolve(2)
+		idx, v, ok := sup.AwaitAny(context.Background(), p1, p2, p3)
+		shouldEqual(t, idx, 1)
+		shouldEqual(t, v, 2)
+		shouldEqual(t, ok, true)
+	})
+	t.Run("pending should wait for the first", func(t *testing.T) {
+		p1, p2 := sup.NewPromise(), sup.NewPromise()
+		go p2.Resolve("second")
+		idx, v, ok := sup.AwaitAny(context.Background(), p1, p2)
+		shouldEqual(t, idx, 1)
+		shouldEqual(t, v, "second")
+		shouldEqual(t, ok, true)
+	})
+	t.Run("context should end the wait", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+		defer cancel()
+		idx, v, ok := sup.AwaitAny(ctx, sup.NewPromise(), sup.NewPromise())
+		shouldEqual(t, idx, -1)
+		shouldEqual(t, v, nil)
+		shouldEqual(t, ok, false)
+		idx, _, ok = sup.AwaitAny(ctx)
+		shouldEqual(t, idx, -1)
+		shouldEqual(t, ok, false)
+	})
+	t.Run("no promises should return at once", func(t *testing.T) {
+		idx, v, ok := sup.AwaitAny(context.Background())
+		shouldEqual(t, idx, -1)
+		shouldEqual(t, v, nil)
+		shouldEqual(t, ok, false)
+	})
+	t.Run("more promises than reflect.Select takes should work", func(t *testing.T) {
+		ps := make([]sup.Promise, 70000)
+		for i := range ps {
+			ps[i] = sup.NewPromise()
+		}
+		go ps[66000].Resolve("late")
+		idx, v, ok := sup.AwaitAny(context.Background(), ps...)
+		shouldEqual(t, idx, 66000)
+		shouldEqual(t, v, "late")
+		shouldEqual(t, ok, true)
+		ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+		defer cancel()
+		idx, _, ok = sup.AwaitAny(ctx, ps[:66000]...)
+		shouldEqual(t, idx, -1)
+		shouldEqual(t, ok, false)
+	})
+	t.Run("resolve storm should not leak", func(t *testing.T) {
+		before := runtime.NumGoroutine()
+		const n = 1000
+		for round := 0; round < 10; round++ {
+			ps := make([]sup.Promise, n)
+			for i := range ps {
+				ps[i] = sup.NewPromise()
+			}
+			var wg sync.WaitGroup
+			for w := 0; w < 8; w++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					idx, v, ok := sup.AwaitAny(context.Background(), ps...)
+					if !ok || v != idx {
+						t.Errorf("awaitAny yielded mismatch: idx %d, v %v, ok %v", idx, v, ok)
+					}
+				}()
+			}
+			for i := range ps {
+				wg.Add(1)
+				go func(i int) {
+					defer wg.Done()
+					ps[i].Resolve(i)
+				}(i)
+			}
+			wg.Wait()
+		}
+		// Give exited goroutines a moment to be accounted for.
+		time.Sleep(10 * time.Millisecond)
+		if after := runtime.NumGoroutine(); after > before+2 {
+			t.Errorf("goroutines leaked: %d before, %d after", before, after)
+		}
+	})
+}
+
+func TestThen(t *testing.T) {
+	t.Run("derived promise should resolve with the mapped value", func(t *testing.T) {
+		p := sup.NewPromise()
+		derived := sup.Then(p, func(v interface{}) interface{} { return v.(int) * 2 })
+		gather := make(chan sup.Promise, 1)
+		derived.WaitSelectably(gather)
+		var called sup.Promise
+		var wg sync.WaitGroup
+		wg.Add(1)
+		derived.WaitCallback(func(p sup.Promise) { called = p; wg.Done() })
+		p.Resolve(21)
+		res := derived.Get(context.Background())
+		shouldEqual(t, res.Value, 42)
+		shouldEqual(t, res.Error, nil)
+		shouldEqual(t, <-gather, derived)
+		wg.Wait()
+		shouldEqual(t, called, derived)
+	})
+	t.Run("derived promise should cancel with its source", func(t *testing.T) {
+		p := sup.NewPromise()
+		derived := sup.Then(p, func(v interface{}) interface{} {
+			t.Errorf("fn should not be called")
+			return nil
+		})
+		p.Cancel()
+		v, err := derived.AwaitValue(context.Background())
+		shouldEqual(t, v, nil)
+		shouldEqual(t, err, context.Canceled)
+	})
+	t.Run("canceling the derived promise should stop fn being called", func(t *testing.T) {
+		p := sup.NewPromise()
+		derived := sup.Then(p, func(v interface{}) interface{} {
+			t.Errorf("fn should not be called")
+			return nil
+		})
+		derived.Cancel()
+		p.Resolve(1)
+	})
+	t.Run("pending sources should not hold goroutines", func(t *testing.T) {
+		before := runtime.NumGoroutine()
+		for i := 0; i < 1000; i++ {
+			sup.Then(sup.NewPromise(), func(v interface{}) interface{} { return v })
+		}
+		if after := runtime.NumGoroutine(); after > before {
+			t.Errorf("goroutines grew from %d to %d", before, after)
+		}
+	})
+}
@@ -0,0 +1,127 @@
+package sup
+
+import (
+	"sync"
+)
+
+// PromiseGroup collects promises, and yields them back in the order they
+// become resolved.  It's the easy way to fan in results from many tasks.
+//
+// Add promises to the group with Add; then call Next repeatedly to get
+// each one as it becomes resolved (or canceled).  Adding more promises while
+// consuming is fine.  Call Close when there will be no more additions, so
+// that Next can report when everything has been delivered.
+//
+// The zero value is ready to use.  A PromiseGroup must not be copied after
+// first use.
+type PromiseGroup struct {
+	mu        sync.Mutex
+	wake      chan struct{} // holds a token when ready or closed may have changed; capacity of one.
+	ready     []Promise     // resolved, but not yet delivered by Next.
+	added     int
+	delivered int
+	closed    bool
+}
+
+func (g *PromiseGroup) init() {
+	if g.wake == nil {
+		g.wake = make(chan struct{}, 1)
+	}
+}
+
+func (g *PromiseGroup) poke() {
+	select {
+	case g.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Add registers a promise with the group.  It panics if the group is closed.
+// Promises which are already resolved when they're added are yielded in the
+// order they were added.
+func (g *PromiseGroup) Add(p Promise) {
+	g.mu.Lock()
+	g.init()
+	if g.closed {
+		g.mu.Unlock()
+		panic("Add() on closed PromiseGroup")
+	}
+	g.added++
+	select {
+	case <-p.ResolvedCh():
+		g.ready = append(g.ready, p)
+		g.mu.Unlock()
+		g.poke()
+		return
+	default:
+	}
+	g.mu.Unlock()
+	p.WaitCallback(func(p Promise) {
+		g.mu.Lock()
+		g.ready = append(g.ready, p)
+		g.mu.Unlock()
+		g.poke()
+	})
+}
+
+// Close declares that no more promises will be added.
+// After this, Next returns false once every promise has been delivered.
+func (g *PromiseGroup) Close() {
+	g.mu.Lock()
+	g.init()
+	g.closed = true
+	g.mu.Unlock()
+	g.poke()
+}
+
+// Next blocks until one of the group's promises is resolved (or canceled),
+// and returns it.  Each promise is returned exactly once.
+//
+// Returns (nil,false) if the context is done first, or if the group has been
+// closed and every promise added to it has already been delivered.
+// (If the group isn't closed, Next waits for more promises to be added.)
+func (g *PromiseGroup) Next(ctx Context) (Promise, bool) {
+	for {
+		g.mu.Lock()
+		g.init()
+		if len(g.ready) > 0 {
+			p := g.ready[0]
+			g.ready[0] = nil
+			g.ready = g.ready[1:]
+			g.delivered++
+			more := len(g.ready) > 0
+			g.mu.Unlock()
+			if more {
+				g.poke() // in case someone else is waiting in Next, too.
+			}
+			return p, true
+		}
+		if g.closed && g.delivered == g.added {
+			g.mu.Unlock()
+			g.poke()
+			return nil, false
+		}
+		wake := g.wake
+		g.mu.Unlock()
+		select {
+		case <-wake:
+		case <-ctx.Done():
+			return nil, false
+		}
+	}
+}
+
+// Len returns the number of promises that have been added to the group.
+func (g *PromiseGroup) Len() int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.added
+}
+
+// Remaining returns the number of promises that have been added to the group
+// but not yet delivered by Next.
+func (g *PromiseGroup) Remaining() int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.added - g.delivered
+}
@@ -0,0 +1,81 @@
+package sup_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/warpfork/go-sup"
+)
+
+func TestPromiseGroup(t *testing.T) {
+	for _, tr := range []struct {
+		name         string
+		preResolved  []int // indexes of promises resolved before being added.
+		resolveOrder []int // indexes of the rest, in the order they're resolved.
+	}{
+		{"in order", nil, []int{0, 1, 2}},
+		{"out of order", nil, []int{2, 0, 1}},
+		{"already resolved", []int{0, 1, 2}, nil},
+		{"mixed", []int{1}, []int{2, 0}},
+	} {
+		t.Run(tr.name, func(t *testing.T) {
+			ps := []sup.Promise{sup.NewPromise(), sup.NewPromise(), sup.NewPromise()}
+			for _, i := range tr.preResolved {
+				ps[i].Resolve(i)
+			}
+			var g sup.PromiseGroup
+			for _, p := range ps {
+				g.Add(p)
+			}
+			g.Close()
+			shouldEqual(t, g.Len(), 3)
+			shouldEqual(t, g.Remaining(), 3)
+			expect := append(append([]int{}, tr.preResolved...), tr.resolveOrder...)
+			for n, i := range expect {
+				if n >= len(tr.preResolved) {
+					ps[i].Resolve(i)
+				}
+				p, ok := g.Next(context.Background())
+				mustEqual(t, ok, true)
+				shouldEqual(t, p, ps[i])
+			}
+			shouldEqual(t, g.Remaining(), 0)
+			p, ok := g.Next(context.Background())
+			shouldEqual(t, p, nil)
+			shouldEqual(t, ok, false)
+		})
+	}
+	t.Run("adding while consuming", func(t *testing.T) {
+		var g sup.PromiseGroup
+		p1, p2 := sup.NewPromise(), sup.NewPromise()
+		g.Add(p1)
+		go func() {
+			p1.Resolve(1)
+			g.Add(p2)
+			p2.Resolve(2)
+			g.Close()
+		}()
+		var got []sup.Promise
+		for {
+			p, ok := g.Next(context.Background())
+			if !ok {
+				break
+			}
+			got = append(got, p)
+		}
+		mustEqual(t, len(got), 2)
+		shouldEqual(t, got[0], p1)
+		shouldEqual(t, got[1], p2)
+	})
+	t.Run("next should be cancellable", func(t *testing.T) {
+		var g sup.PromiseGroup
+		g.Add(sup.NewPromise())
+		ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+		defer cancel()
+		p, ok := g.Next(ctx)
+		shouldEqual(t, p, nil)
+		shouldEqual(t, ok, false)
+		shouldEqual(t, g.Remaining(), 1)
+	})
+}
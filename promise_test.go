@@ -2,8 +2,12 @@ package sup_test
 
 import (
 	"context"
+	"fmt"
+	"runtime"
+	"strings"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/warpfork/go-sup"
 )
@@ -53,6 +57,147 @@ func TestPromises(t *testing.T) {
 		shouldEqual(t, res.Value, 14)
 		shouldEqual(t, res.Error, nil)
 	})
+	t.Run("awaitValue should return after resolve", func(t *testing.T) {
+		p := sup.NewPromise()
+		go p.Resolve(14)
+		v, err := p.AwaitValue(context.Background())
+		shouldEqual(t, v, 14)
+		shouldEqual(t, err, nil)
+	})
+	t.Run("awaitValue should report cancel", func(t *testing.T) {
+		p := sup.NewPromise()
+		go p.Cancel()
+		v, err := p.AwaitValue(context.Background())
+		shouldEqual(t, v, nil)
+		shouldEqual(t, err, context.Canceled)
+	})
+	t.Run("awaitValue should return the ctx error if interrupted", func(t *testing.T) {
+		p := sup.NewPromise()
+		ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+		defer cancel()
+		v, err := p.AwaitValue(ctx)
+		shouldEqual(t, v, nil)
+		shouldEqual(t, err, context.DeadlineExceeded)
+	})
+	t.Run("awaitTimeout should time out or return", func(t *testing.T) {
+		p := sup.NewPromise()
+		shouldEqual(t, p.AwaitTimeout(time.Millisecond), false)
+		go p.Resolve(1)
+		shouldEqual(t, p.AwaitTimeout(time.Minute), true)
+		shouldEqual(t, p.AwaitTimeout(0), true)
+	})
+	t.Run("tryValue should only yield resolved values", func(t *testing.T) {
+		p := sup.NewPromise()
+		v, ok := p.TryValue()
+		shouldEqual(t, v, nil)
+		shouldEqual(t, ok, false)
+		p.Resolve(14)
+		v, ok = p.TryValue()
+		shouldEqual(t, v, 14)
+		shouldEqual(t, ok, true)
+		p = sup.NewPromise()
+		p.Cancel()
+		v, ok = p.TryValue()
+		shouldEqual(t, v, nil)
+		shouldEqual(t, ok, false)
+	})
+	t.Run("tryValue should be race-free against resolve", func(t *testing.T) {
+		for round := 0; round < 100; round++ {
+			p := sup.NewPromise()
+			var wg sync.WaitGroup
+			for i := 0; i < 8; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					for {
+						if v, ok := p.TryValue(); ok {
+							if v != "done" {
+								t.Errorf("peeked %v", v)
+							}
+							return
+						}
+						runtime.Gosched()
+					}
+				}()
+			}
+			p.Resolve("done")
+			wg.Wait()
+		}
+	})
+	t.Run("valueCh should deliver once then be closed", func(t *testing.T) {
+		p := sup.NewPromise()
+		ch := p.ValueCh()
+		shouldEqual(t, p.ValueCh(), ch)
+		go p.Resolve(14)
+		v, ok := <-ch
+		shouldEqual(t, v, 14)
+		shouldEqual(t, ok, true)
+		v, ok = <-ch
+		shouldEqual(t, v, nil)
+		shouldEqual(t, ok, false)
+	})
+	t.Run("valueCh should work if asked for after resolution", func(t *testing.T) {
+		p := sup.NewPromise()
+		p.Resolve(14)
+		v, ok := <-p.ValueCh()
+		shouldEqual(t, v, 14)
+		shouldEqual(t, ok, true)
+	})
+	t.Run("valueCh should be closed empty on cancel", func(t *testing.T) {
+		p := sup.NewPromise()
+		ch := p.ValueCh()
+		p.Cancel()
+		v, ok := <-ch
+		shouldEqual(t, v, nil)
+		shouldEqual(t, ok, false)
+	})
+	t.Run("instrumented promises should measure latency", func(t *testing.T) {
+		p := sup.NewInstrumentedPromise()
+		_, ok := p.ResolveLatency()
+		shouldEqual(t, ok, false)
+		time.Sleep(5 * time.Millisecond)
+		ch := make(chan sup.Promise, 1)
+		p.WaitSelectably(ch)
+		p.Resolve(1)
+		shouldEqual(t, <-ch, sup.Promise(p)) // waiters should see the same promise we have.
+		latency, ok := p.ResolveLatency()
+		shouldEqual(t, ok, true)
+		if latency < 5*time.Millisecond || p.Age() < latency {
+			t.Errorf("implausible timings: latency %v, age %v", latency, p.Age())
+		}
+	})
+	t.Run("tryResolve should yield to the first resolution", func(t *testing.T) {
+		p := sup.NewPromise()
+		shouldEqual(t, p.TryResolve(1), true)
+		shouldEqual(t, p.TryResolve(2), false)
+		p = sup.NewPromise()
+		p.Cancel()
+		shouldEqual(t, p.TryResolve(1), false)
+		p = sup.NewPromise()
+		shouldEqual(t, p.TryResolve(nil), true)
+		shouldEqual(t, p.TryResolve(nil), false)
+	})
+	t.Run("tryResolve race should have exactly one winner", func(t *testing.T) {
+		for round := 0; round < 50; round++ {
+			p := sup.NewPromise()
+			winners := make(chan int, 64)
+			var wg sync.WaitGroup
+			for i := 0; i < 64; i++ {
+				wg.Add(1)
+				go func(i int) {
+					defer wg.Done()
+					if p.TryResolve(i) {
+						winners <- i
+					}
+				}(i)
+			}
+			wg.Wait()
+			close(winners)
+			mustEqual(t, len(winners), 1)
+			v, _ := p.TryValue()
+			shouldEqual(t, v, <-winners)
+		}
+	})
 	t.Run("waitSelectably should fan-in", func(t *testing.T) {
 		p1, p2, p3 := sup.NewPromise(), sup.NewPromise(), sup.NewPromise()
 		gatherCh := make(chan sup.Promise)
@@ -89,4 +234,198 @@ func TestPromises(t *testing.T) {
 		shouldEqual(t, r3.Value, 3)
 		shouldEqual(t, r3.Error, nil)
 	})
+	t.Run("removed registrations should never fire", func(t *testing.T) {
+		p := sup.NewPromise()
+		var fired []string
+		removeA := p.WaitCallback(func(sup.Promise) { fired = append(fired, "a") })
+		p.WaitCallback(func(sup.Promise) { fired = append(fired, "b") })
+		ch := make(chan sup.Promise, 1)
+		removeC := p.WaitSelectably(ch)
+		removeA()
+		removeC()
+		removeA() // repeat removal is harmless.
+		p.Resolve(1)
+		removeC() // as is removal after resolution.
+		shouldEqual(t, len(fired), 1)
+		shouldEqual(t, fired[0], "b")
+		shouldEqual(t, len(ch), 0)
+	})
+	t.Run("registering after resolution should notify, but not on our stack", func(t *testing.T) {
+		p := sup.NewPromise()
+		p.Resolve(1)
+		var mu sync.Mutex
+		mu.Lock() // a callback run on our stack would deadlock on this.
+		vCh := make(chan interface{})
+		p.WaitCallback(func(p sup.Promise) {
+			mu.Lock()
+			defer mu.Unlock()
+			v, _ := p.GetNow()
+			vCh <- v
+		})
+		pCh := make(chan sup.Promise)
+		p.WaitSelectably(pCh) // an unbuffered channel we haven't started reading yet!
+		mu.Unlock()
+		shouldEqual(t, <-vCh, 1)
+		shouldEqual(t, <-pCh, p)
+	})
+	t.Run("removed registrations should be released", func(t *testing.T) {
+		p := sup.NewPromise()
+		defer runtime.KeepAlive(p) // the promise must outlive the check, or it proves nothing.
+		released := make(chan struct{})
+		func() {
+			obj := new([64]byte)
+			runtime.SetFinalizer(obj, func(*[64]byte) { close(released) })
+			remove := p.WaitCallback(func(sup.Promise) { obj[0]++ })
+			remove()
+		}()
+		for i := 0; i < 10; i++ {
+			runtime.GC()
+			select {
+			case <-released:
+				return
+			case <-time.After(10 * time.Millisecond):
+			}
+		}
+		t.Errorf("removed callback is still reachable")
+	})
+	t.Run("notifications should be delivered in registration order", func(t *testing.T) {
+		const n = 1000
+		check := func(t *testing.T, seen []int) {
+			mustEqual(t, len(seen), n)
+			for i, v := range seen {
+				if v != i {
+					t.Fatalf("notification %d was for registration %d", i, v)
+				}
+			}
+		}
+		t.Run("at resolution", func(t *testing.T) {
+			p := sup.NewPromise()
+			var seen []int // only touched by the resolving goroutine, which is us.
+			var removes []func()
+			for i := 0; i < n; i++ {
+				i := i
+				removes = append(removes, p.WaitCallback(func(sup.Promise) { seen = append(seen, i) }))
+				if i%10 == 5 { // removals must not disturb the order of the others.
+					removes = append(removes, p.WaitCallback(func(sup.Promise) { t.Errorf("removed callback fired") }))
+					removes[len(removes)-1]()
+				}
+			}
+			p.Resolve(1)
+			check(t, seen)
+		})
+		t.Run("late", func(t *testing.T) {
+			p := sup.NewPromise()
+			p.Resolve(1)
+			var seen []int
+			var wg sync.WaitGroup
+			wg.Add(n)
+			for i := 0; i < n; i++ {
+				i := i
+				p.WaitCallback(func(sup.Promise) { seen = append(seen, i); wg.Done() })
+			}
+			wg.Wait()
+			check(t, seen)
+		})
+	})
+}
+
+func TestMustValue(t *testing.T) {
+	panicMsg := func(fn func()) (msg string) {
+		defer func() { msg, _ = recover().(string) }()
+		fn()
+		return ""
+	}
+	t.Run("resolved", func(t *testing.T) {
+		p := sup.NewPromise()
+		p.Resolve(4)
+		shouldEqual(t, p.MustValue(), 4)
+	})
+	t.Run("unresolved", func(t *testing.T) {
+		p := sup.NewPromise()
+		shouldEqual(t, panicMsg(func() { p.MustValue() }),
+			"promise read before resolution: created at unknown site (set sup.DebugPromises to record it)")
+	})
+	t.Run("cancelled, with creation site", func(t *testing.T) {
+		sup.DebugPromises = true
+		p, _, _ := sup.NewCompletable[int]() // the site should be here, not inside the package.
+		_, _, line, _ := runtime.Caller(0)
+		sup.DebugPromises = false
+		p.Cancel()
+		msg := panicMsg(func() { p.MustValue() })
+		want := fmt.Sprintf("promise_test.go:%d", line-1)
+		if !strings.HasPrefix(msg, "promise read after cancellation: created at ") || !strings.HasSuffix(msg, want) {
+			t.Errorf("unexpected panic message %q; should end with %q", msg, want)
+		}
+	})
+}
+
+func BenchmarkAwaitTimeoutResolved(b *testing.B) {
+	p := sup.NewPromise()
+	p.Resolve(1)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if !p.AwaitTimeout(time.Second) {
+			b.Fatal("should be resolved")
+		}
+	}
+}
+
+func TestLateNotificationIsolation(t *testing.T) {
+	// A late waiter which blocks should only hold up its own promise.
+	release := make(chan struct{})
+	defer close(release)
+	a := sup.NewPromise()
+	a.Resolve(1)
+	a.WaitSelectably(make(chan sup.Promise)) // never read.
+	a.WaitCallback(func(sup.Promise) { <-release })
+	b := sup.NewPromise()
+	b.Resolve(2)
+	t.Run("channel", func(t *testing.T) {
+		ch := make(chan sup.Promise, 1)
+		b.WaitSelectably(ch)
+		select {
+		case got := <-ch:
+			shouldEqual(t, got, b)
+		case <-time.After(time.Second):
+			t.Fatal("notification held up by another promise's waiter")
+		}
+	})
+	t.Run("callback", func(t *testing.T) {
+		done := make(chan struct{})
+		b.WaitCallback(func(sup.Promise) { close(done) })
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatal("notification held up by another promise's waiter")
+		}
+	})
+	t.Run("callback waiting on another resolved promise", func(t *testing.T) {
+		c := sup.NewPromise()
+		c.Resolve(3)
+		done := make(chan struct{})
+		b.WaitCallback(func(sup.Promise) {
+			inner := make(chan struct{})
+			c.WaitCallback(func(sup.Promise) { close(inner) })
+			<-inner
+			close(done)
+		})
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatal("nested late notification deadlocked")
+		}
+	})
+}
+
+func BenchmarkLateRegistration(b *testing.B) {
+	p := sup.NewPromise()
+	p.Resolve(1)
+	var wg sync.WaitGroup
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		wg.Add(1)
+		p.WaitCallback(func(sup.Promise) { wg.Done() })
+	}
+	wg.Wait()
 }
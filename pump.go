@@ -0,0 +1,84 @@
+package sup
+
+import (
+	"context"
+	"fmt"
+)
+
+// Pump returns a Task which forwards every message from the input channel
+// to the output channel, so one stage's outbox can be plumbed into another
+// stage's inbox.  Since it's a Task, it goes into a supervisor alongside the
+// stages it connects, and is cancelled with them.
+//
+// If transform is not nil, each message is passed through it first: it
+// returns the message to send on (which may be changed), whether to send it
+// at all (false filters it out), and an error, which stops the pump.
+//
+// The pump runs until one of these happens:
+//
+//   - the input is closed: the pump closes the output, and returns nil (or
+//     ErrAlreadyClosed, if something else closed the output already);
+//   - the transform returns an error: the pump returns it;
+//   - the output is closed: the pump returns an ErrChannelClosed;
+//   - the context is done: the pump returns the context's error.
+//
+// Only when the input is closed is the output closed; in the other cases,
+// it's left alone, since the stage downstream is presumably stopping anyway.
+//
+// Backpressure carries through: the pump receives the next message only
+// once the previous one has been sent.  That means a pump can be holding one
+// message, received but not yet sent.  If the context is done then, that
+// message is lost: it's gone from the input, and never reaches the output.
+// (If the context belongs to a supervised task, as it will when the pump is
+// run by a supervisor, a dropped-message warning is sent to the supervisor's
+// warning handler, so the loss is at least noticed.)
+func Pump[T any](name string, in ReceiverChannel[T], out SenderChannel[T], transform func(T) (T, bool, error)) NamedTask {
+	return pumpTask[T]{name, in, out, transform}
+}
+
+type pumpTask[T any] struct {
+	name      string
+	in        ReceiverChannel[T]
+	out       SenderChannel[T]
+	transform func(T) (T, bool, error)
+}
+
+func (p pumpTask[T]) Name() string {
+	return p.name
+}
+
+func (p pumpTask[T]) Run(ctx context.Context) error {
+	var v T
+	var open bool
+	recv := NewSelectSet(p.in.RecvOrClosed(func(v_ T, ok bool) error {
+		v, open = v_, ok
+		return nil
+	}))
+	send := p.out.SettableSend(nil)
+	sendSet := NewSelectSet(send)
+	for {
+		if err := recv.Wait(ctx); err != nil {
+			return err
+		}
+		if !open {
+			return p.out.Close()
+		}
+		if p.transform != nil {
+			var keep bool
+			var err error
+			if v, keep, err = p.transform(v); err != nil {
+				return err
+			} else if !keep {
+				continue
+			}
+		}
+		send.Set(v)
+		if err := sendSet.Wait(ctx); err != nil {
+			if ctx.Err() != nil {
+				info, _ := ctx.Value(ctxKey{}).(ctxInfo)
+				info.warn(WarningKind_dropped, fmt.Sprintf("pump %q dropped a message from channel %q at cancellation", p.name, p.in.Name()))
+			}
+			return err
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package sup_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/warpfork/go-sup"
+)
+
+func TestPump(t *testing.T) {
+	ctx := context.Background()
+	t.Run("should forward everything, then close the output", func(t *testing.T) {
+		inTx, inRx := sup.NewChannel[int]("a", 0)
+		outTx, outRx := sup.NewChannel[int]("b", 0)
+		go func() {
+			for i := 0; i < 5; i++ {
+				inTx.Chan <- i
+			}
+			inTx.Close()
+		}()
+		errCh := make(chan error, 1)
+		go func() { errCh <- sup.Pump("a→b", inRx, outTx, nil).Run(ctx) }()
+		var got []int
+		for v := range outRx.Chan {
+			got = append(got, v)
+		}
+		shouldEqual(t, fmt.Sprint(got), "[0 1 2 3 4]")
+		shouldEqual(t, <-errCh, nil)
+	})
+	t.Run("the transform should map and filter", func(t *testing.T) {
+		inTx, inRx := sup.NewChannel[int]("a", 10)
+		outTx, outRx := sup.NewChannel[int]("b", 10)
+		for i := 0; i < 6; i++ {
+			inTx.TrySend(i)
+		}
+		inTx.Close()
+		err := sup.Pump("a→b", inRx, outTx, func(v int) (int, bool, error) {
+			return v * 10, v%2 == 0, nil
+		}).Run(ctx)
+		shouldEqual(t, err, nil)
+		var got []int
+		for v := range outRx.Chan {
+			got = append(got, v)
+		}
+		shouldEqual(t, fmt.Sprint(got), "[0 20 40]")
+	})
+	t.Run("the transform's error should stop the pump, and leave the output open", func(t *testing.T) {
+		inTx, inRx := sup.NewChannel[int]("a", 10)
+		outTx, _ := sup.NewChannel[int]("b", 10)
+		inTx.TrySend(1)
+		boom := errors.New("boom")
+		err := sup.Pump("a→b", inRx, outTx, func(v int) (int, bool, error) {
+			return 0, false, boom
+		}).Run(ctx)
+		shouldEqual(t, err, boom)
+		shouldEqual(t, outTx.Closed(), false)
+	})
+	t.Run("a message in flight at cancellation should be lost, with a warning", func(t *testing.T) {
+		warned := make(chan sup.SupervisionWarning, 1)
+		inTx, inRx := sup.NewChannel[int]("a", 1)
+		outTx, outRx := sup.NewChannel[int]("b", 0)
+		inTx.TrySend(1)
+		err := sup.SuperviseRoot(ctx,
+			sup.SuperviseForkJoin("main",
+				[]sup.Task{
+					sup.Pump("pump", inRx, outTx, nil),
+					namedFunc{"canceller", func(ctx context.Context) error {
+						// Wait until the pump has taken the message, then fail,
+						//  so the pump is cancelled with nobody having received it.
+						for n, _ := inRx.Depth(); n > 0; n, _ = inRx.Depth() {
+							runtime.Gosched()
+						}
+						return errors.New("stop")
+					}},
+				},
+				sup.SetWarningHandler(func(w sup.SupervisionWarning) { warned <- w }),
+			),
+		)
+		shouldEqual(t, err.Error(), "stop")
+		select {
+		case w := <-warned:
+			shouldEqual(t, w.Kind, sup.WarningKind_dropped)
+			shouldEqual(t, w.TaskPath, "main/pump")
+		case <-time.After(time.Second):
+			t.Errorf("no warning")
+		}
+		n, _ := inRx.Depth()
+		shouldEqual(t, n, 0)
+		_, err = outRx.TryRecv()
+		shouldEqual(t, err, sup.Nonblock)
+	})
+}
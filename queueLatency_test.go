@@ -0,0 +1,36 @@
+package sup_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/warpfork/go-sup"
+)
+
+func TestQueueLatency(t *testing.T) {
+	latencies := make(chan time.Duration, 10)
+	svr := sup.SuperviseForkJoin("main", sup.TasksFromMap(
+		map[int]int{1: 1, 2: 2, 3: 3},
+		func(ctx context.Context, k, v interface{}) error {
+			latencies <- sup.QueueLatency(ctx)
+			return nil
+		},
+	))
+	max, mean := svr.QueueLatency()
+	shouldEqual(t, max, time.Duration(0))
+	shouldEqual(t, mean, time.Duration(0))
+	mustEqual(t, sup.SuperviseRoot(context.Background(), svr), nil)
+	close(latencies)
+	var longest time.Duration
+	for d := range latencies {
+		shouldEqual(t, d > 0, true)
+		if d > longest {
+			longest = d
+		}
+	}
+	max, mean = svr.QueueLatency()
+	shouldEqual(t, max, longest)
+	shouldEqual(t, mean > 0 && mean <= max, true)
+	shouldEqual(t, sup.QueueLatency(context.Background()), time.Duration(0))
+}
@@ -0,0 +1,209 @@
+package sup
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+)
+
+// Result pairs a value with an error.  It's handy as the payload for
+// promises whose producer can fail: resolve with Ok(v) or Fail(err),
+// and consumers unpack it with Get.
+type Result[T any] struct {
+	Value T
+	Err   error
+}
+
+// Ok returns a successful Result holding v.
+func Ok[T any](v T) Result[T] {
+	return Result[T]{Value: v}
+}
+
+// Fail returns a failed Result holding err.
+func Fail[T any](err error) Result[T] {
+	return Result[T]{Err: err}
+}
+
+// Get returns the value and error.
+func (r Result[T]) Get() (T, error) {
+	return r.Value, r.Err
+}
+
+// NewCompletable returns a new unresolved promise, plus a pair of functions
+// for completing it: resolve completes it with Ok(v), and reject completes
+// it with Fail(err).  The promise's value is always a Result[V]; consumers
+// can use AwaitResult to get it unpacked.
+//
+// Only one completion is allowed: calling resolve or reject after either of
+// them has already been called panics, just like a repeated Resolve does.
+// (As usual, completing a promise that's already been canceled is a no-op.)
+func NewCompletable[V any]() (p Promise, resolve func(V), reject func(error)) {
+	p = NewPromise()
+	resolve = func(v V) { p.Resolve(Ok(v)) }
+	reject = func(err error) { p.Resolve(Fail[V](err)) }
+	return
+}
+
+// NewPromiseCtx returns a new unresolved promise which is guaranteed to be
+// resolved one way or another: either by calling the returned resolve
+// function, which resolves it with Ok(v), or else when the given context
+// is done, in which case it's resolved with Fail(context.Cause(ctx)).
+//
+// Whichever happens first wins, and the other is a no-op (so unlike with
+// NewCompletable, resolving late doesn't panic; and calling resolve more than
+// once is also a no-op).  This makes these promises safe to collect (e.g. in
+// a PromiseGroup) from tasks which might be cancelled partway through their
+// work: nobody is left waiting forever.
+func NewPromiseCtx[V any](ctx Context) (p Promise, resolve func(V)) {
+	p = NewPromise()
+	stop := context.AfterFunc(ctx, func() {
+		p.TryResolve(Fail[V](context.Cause(ctx)))
+	})
+	resolve = func(v V) {
+		if p.TryResolve(Ok(v)) {
+			stop()
+		}
+	}
+	return
+}
+
+// AwaitResult waits for a promise whose value is a Result[V] (as made by
+// NewCompletable or Async), and returns the unpacked value and error.
+// A rejected promise yields its rejection error.  If the promise was
+// canceled, or the context is done first, the error is as per AwaitValue.
+//
+// A promise resolved with a plain V (rather than a Result[V]) is also
+// accepted; so is one resolved with nil, if V is a type which can be nil
+// (an interface, pointer, map, and so on), which yields V's nil.  Any other
+// value type panics.
+func AwaitResult[V any](ctx Context, p Promise) (V, error) {
+	v, err := p.AwaitValue(ctx)
+	if err != nil {
+		var zero V
+		return zero, err
+	}
+	return asResult[V](v).Get()
+}
+
+// AwaitAllSettled waits for all the given promises to settle (that is, be
+// resolved or canceled), and returns all their outcomes as Results, in the
+// same order as the promises were given.  Failed outcomes don't stop the
+// wait; inspect each Result.  A canceled promise yields a Result whose Err is
+// context.Canceled.  (Promise values must be Result[V] or V, as per
+// AwaitResult.)
+//
+// The error is non-nil only if the context was done before everything
+// settled; it's then the context's error.  In that case, the slice still
+// holds the outcomes of every promise that had settled, and the Results for
+// promises that hadn't settled have an Err of Nonblock (and a zero Value).
+func AwaitAllSettled[V any](ctx Context, ps ...Promise) ([]Result[V], error) {
+	var err error
+	if !AwaitAll(ctx, ps...) {
+		err = ctx.Err()
+	}
+	results := make([]Result[V], len(ps))
+	for i, p := range ps {
+		select {
+		case <-p.ResolvedCh():
+		default:
+			results[i] = Fail[V](Nonblock)
+			continue
+		}
+		if v, ok := p.TryValue(); ok {
+			results[i] = asResult[V](v)
+		} else {
+			results[i] = Fail[V](context.Canceled)
+		}
+	}
+	return results, err
+}
+
+// asResult converts a promise's value to a Result[V].
+// The value must be either a Result[V] or a V.  (A nil V, stored in an
+// interface{}, loses its type, so plain nil is taken as one, if V can be
+// nil.)
+func asResult[V any](v interface{}) Result[V] {
+	switch v2 := v.(type) {
+	case Result[V]:
+		return v2
+	case V:
+		return Ok(v2)
+	case nil:
+		if nilable(reflect.TypeOf((*V)(nil)).Elem()) {
+			var zero V
+			return Ok(zero)
+		}
+	}
+	panic(fmt.Sprintf("usage: promise value of type %T is not a %T", v, Result[V]{}))
+}
+
+// nilable reports whether nil is a value of the type.
+func nilable(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Interface, reflect.Pointer, reflect.Map, reflect.Slice, reflect.Chan, reflect.Func, reflect.UnsafePointer:
+		return true
+	}
+	return false
+}
+
+// TaskWithResult makes a Task from a function that returns a value as well as
+// an error, and returns it together with a promise for its Result[T].
+// When the task is run, the promise is resolved with the function's result.
+// Give the task to a supervisor as usual; the function's error still goes to
+// the supervisor exactly as if it were a plain Task.
+//
+// If the function panics, the promise is resolved with a failed Result
+// carrying an *ErrChild (with WasPanic set), and then the panic continues
+// on up to the supervisor.
+//
+// The task may only be run once (a second run panics, since the promise
+// can only be resolved once).  If the task is never run -- say, because its
+// supervisor was cancelled first -- the promise is never resolved, so wait
+// on it with a context.
+func TaskWithResult[T any](fn func(Context) (T, error)) (Task, Promise) {
+	p := NewPromise()
+	return resultTask[T]{fn, p}, p
+}
+
+type resultTask[T any] struct {
+	fn func(Context) (T, error)
+	p  Promise
+}
+
+func (t resultTask[T]) Run(ctx Context) error {
+	var res Result[T]
+	defer func() {
+		if rcvr := recover(); rcvr != nil {
+			t.p.Resolve(Fail[T](siftError(nil, rcvr)))
+			panic(rcvr)
+		}
+		t.p.Resolve(res)
+	}()
+	res.Value, res.Err = t.fn(ctx)
+	return res.Err
+}
+
+// Async calls fn on a new goroutine, and returns a promise which will be
+// resolved with fn's Result[T] when it returns.
+//
+// If fn panics, the panic is caught and the promise is resolved with a
+// failed Result carrying an *ErrChild (with WasPanic set), just as if the
+// function had been a supervised task.
+//
+// The goroutine is not supervised: nothing waits for it but whoever waits
+// on the promise.  Prefer running work as a Task under a supervisor where
+// you can; this is for bridging into code that just wants a future.
+func Async[T any](ctx Context, fn func(Context) (T, error)) Promise {
+	p := NewPromise()
+	go func() {
+		var res Result[T]
+		defer func() {
+			if rcvr := recover(); rcvr != nil {
+				res = Fail[T](siftError(nil, rcvr))
+			}
+			p.Resolve(res)
+		}()
+		res.Value, res.Err = fn(ctx)
+	}()
+	return p
+}
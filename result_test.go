@@ -0,0 +1,187 @@
+package sup_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/warpfork/go-sup"
+)
+
+func TestResult(t *testing.T) {
+	v, err := sup.Ok(4).Get()
+	shouldEqual(t, v, 4)
+	shouldEqual(t, err, nil)
+	boom := errors.New("boom")
+	v, err = sup.Fail[int](boom).Get()
+	shouldEqual(t, v, 0)
+	shouldEqual(t, err, boom)
+}
+
+func TestAsync(t *testing.T) {
+	t.Run("value", func(t *testing.T) {
+		p := sup.Async(context.Background(), func(context.Context) (string, error) {
+			return "hi", nil
+		})
+		v, err := p.AwaitValue(context.Background())
+		shouldEqual(t, err, nil)
+		shouldEqual(t, v, sup.Ok("hi"))
+	})
+	t.Run("panic", func(t *testing.T) {
+		p := sup.Async(context.Background(), func(context.Context) (string, error) {
+			panic("oh no")
+		})
+		v, _ := p.AwaitValue(context.Background())
+		_, err := v.(sup.Result[string]).Get()
+		mustEqual(t, err != nil, true)
+		shouldEqual(t, err.(*sup.ErrChild).WasPanic, true)
+		shouldEqual(t, err.Error(), "oh no")
+	})
+}
+
+func TestCompletable(t *testing.T) {
+	boom := errors.New("boom")
+	t.Run("resolve", func(t *testing.T) {
+		p, resolve, _ := sup.NewCompletable[int]()
+		go resolve(4)
+		v, err := sup.AwaitResult[int](context.Background(), p)
+		shouldEqual(t, v, 4)
+		shouldEqual(t, err, nil)
+	})
+	t.Run("reject", func(t *testing.T) {
+		p, _, reject := sup.NewCompletable[int]()
+		go reject(boom)
+		v, err := sup.AwaitResult[int](context.Background(), p)
+		shouldEqual(t, v, 0)
+		shouldEqual(t, err, boom)
+	})
+	t.Run("plain nil", func(t *testing.T) {
+		p := sup.NewPromise()
+		p.Resolve(nil)
+		v, err := sup.AwaitResult[error](context.Background(), p)
+		shouldEqual(t, v, nil)
+		shouldEqual(t, err, nil)
+		p2 := sup.NewPromise()
+		p2.Resolve(nil)
+		ptr, err := sup.AwaitResult[*int](context.Background(), p2)
+		shouldEqual(t, ptr == nil, true)
+		shouldEqual(t, err, nil)
+		defer func() {
+			shouldEqual(t, recover(), "usage: promise value of type <nil> is not a sup.Result[int]")
+		}()
+		sup.AwaitResult[int](context.Background(), p2) // int can't be nil.
+	})
+	t.Run("canceled", func(t *testing.T) {
+		p, resolve, _ := sup.NewCompletable[int]()
+		p.Cancel()
+		resolve(4) // no-op.
+		_, err := sup.AwaitResult[int](context.Background(), p)
+		shouldEqual(t, err, context.Canceled)
+	})
+	for _, tr := range []struct {
+		name          string
+		first, second func(resolve func(int), reject func(error))
+	}{
+		{"resolve twice", func(res func(int), _ func(error)) { res(1) }, func(res func(int), _ func(error)) { res(2) }},
+		{"resolve then reject", func(res func(int), _ func(error)) { res(1) }, func(_ func(int), rej func(error)) { rej(boom) }},
+		{"reject then resolve", func(_ func(int), rej func(error)) { rej(boom) }, func(res func(int), _ func(error)) { res(2) }},
+		{"reject twice", func(_ func(int), rej func(error)) { rej(boom) }, func(_ func(int), rej func(error)) { rej(boom) }},
+	} {
+		t.Run(tr.name+" should panic", func(t *testing.T) {
+			_, resolve, reject := sup.NewCompletable[int]()
+			tr.first(resolve, reject)
+			defer func() {
+				if recover() == nil {
+					t.Errorf("second completion should have panicked")
+				}
+			}()
+			tr.second(resolve, reject)
+		})
+	}
+}
+
+func TestTaskWithResult(t *testing.T) {
+	boom := errors.New("boom")
+	task, result := sup.TaskWithResult(func(context.Context) (string, error) {
+		return "partial", boom
+	})
+	err := sup.SuperviseForkJoin("main", []sup.Task{task}).Run(context.Background())
+	shouldEqual(t, unwrapChild(err), boom)
+	v, err := result.AwaitValue(context.Background())
+	shouldEqual(t, err, nil)
+	shouldEqual(t, v, sup.Result[string]{"partial", boom})
+}
+
+func TestPromiseCtx(t *testing.T) {
+	t.Run("resolve should win if first", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		p, resolve := sup.NewPromiseCtx[int](ctx)
+		resolve(1)
+		cancel()
+		resolve(2) // no-op.
+		v, err := sup.AwaitResult[int](context.Background(), p)
+		shouldEqual(t, v, 1)
+		shouldEqual(t, err, nil)
+	})
+	t.Run("context should resolve with its cause if first", func(t *testing.T) {
+		boom := errors.New("boom")
+		ctx, cancel := context.WithCancelCause(context.Background())
+		p, resolve := sup.NewPromiseCtx[int](ctx)
+		cancel(boom)
+		v, err := sup.AwaitResult[int](context.Background(), p)
+		shouldEqual(t, v, 0)
+		shouldEqual(t, err, boom)
+		resolve(1) // no-op, and no panic.
+		v, err = sup.AwaitResult[int](context.Background(), p)
+		shouldEqual(t, err, boom)
+	})
+}
+
+func TestAwaitAllSettled(t *testing.T) {
+	boom := errors.New("boom")
+	t.Run("all settled", func(t *testing.T) {
+		p1, resolve, _ := sup.NewCompletable[int]()
+		p2, _, reject := sup.NewCompletable[int]()
+		p3 := sup.NewPromise()
+		p4 := sup.NewPromise()
+		go resolve(1)
+		go reject(boom)
+		go p3.Cancel()
+		go p4.Resolve(4) // plain values are fine too.
+		results, err := sup.AwaitAllSettled[int](context.Background(), p1, p2, p3, p4)
+		shouldEqual(t, err, nil)
+		mustEqual(t, len(results), 4)
+		shouldEqual(t, results[0], sup.Ok(1))
+		shouldEqual(t, results[1], sup.Fail[int](boom))
+		shouldEqual(t, results[2], sup.Fail[int](context.Canceled))
+		shouldEqual(t, results[3], sup.Ok(4))
+	})
+	t.Run("partially settled when cancelled", func(t *testing.T) {
+		p1, resolve1, _ := sup.NewCompletable[int]()
+		p2, _, _ := sup.NewCompletable[int]()
+		p3, _, reject3 := sup.NewCompletable[int]()
+		resolve1(1)
+		reject3(boom) // settled, even though it's after an unsettled one.
+		ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+		defer cancel()
+		results, err := sup.AwaitAllSettled[int](ctx, p1, p2, p3)
+		shouldEqual(t, err, context.DeadlineExceeded)
+		mustEqual(t, len(results), 3)
+		shouldEqual(t, results[0], sup.Ok(1))
+		shouldEqual(t, results[1], sup.Fail[int](sup.Nonblock))
+		shouldEqual(t, results[2], sup.Fail[int](boom))
+	})
+	t.Run("nil for a type which can be nil", func(t *testing.T) {
+		p := sup.NewPromise()
+		p.Resolve(nil)
+		results, err := sup.AwaitAllSettled[error](context.Background(), p)
+		shouldEqual(t, err, nil)
+		shouldEqual(t, results[0], sup.Ok[error](nil))
+	})
+	t.Run("empty", func(t *testing.T) {
+		results, err := sup.AwaitAllSettled[int](context.Background())
+		shouldEqual(t, len(results), 0)
+		shouldEqual(t, err, nil)
+	})
+}
@@ -0,0 +1,127 @@
+package sup
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"time"
+)
+
+// RetryPolicy says how Retry retries a task.
+type RetryPolicy struct {
+	// MaxAttempts is how many times the task is run, at most, counting the
+	// first.  Zero or less means no limit: the task is retried until it
+	// succeeds, or the context is done.
+	MaxAttempts int
+
+	// InitialBackoff is how long to wait after the first failure.
+	InitialBackoff time.Duration
+
+	// MaxBackoff caps the wait between attempts.  Zero means no cap.
+	MaxBackoff time.Duration
+
+	// Multiplier is what the wait is multiplied by after each failure.
+	// Zero means 2.
+	Multiplier float64
+
+	// Jitter makes each wait longer by a random amount, of up to this
+	// fraction of it, as with EveryJitter.
+	Jitter float64
+
+	// RetryIf decides whether an error is worth retrying.  If it returns
+	// false, Retry returns the error at once.  Nil means every error is.
+	RetryIf func(error) bool
+}
+
+// RebootableTask is a task which can be reset to run again afresh, after a
+// failed Run.  Retry calls Reset between attempts, for tasks which have it.
+type RebootableTask interface {
+	Task
+	Reset()
+}
+
+// ErrRetriesExhausted is the error Retry returns when the task has failed
+// on every one of the attempts its policy allows.  It unwraps to the last
+// attempt's error.
+type ErrRetriesExhausted struct {
+	Attempts int
+	Err      error
+}
+
+func (e ErrRetriesExhausted) Error() string {
+	return fmt.Sprintf("gave up after %d attempts: %v", e.Attempts, e.Err)
+}
+
+func (e ErrRetriesExhausted) Unwrap() error {
+	return e.Err
+}
+
+// Retry wraps a task so that if it fails, it's run again, after a backoff,
+// according to the policy.  This is within the one task: its supervisor
+// sees only the final result.  The waits between attempts end early if the
+// context is done, and then Retry returns the context's error.  (If an
+// attempt itself fails because the context is done, that error is returned
+// without a retry.)
+//
+// A task wrapped by Once can't be retried, of course; a RebootableTask is
+// Reset before each retry.
+//
+// If the task is a NamedTask, the wrapper is too, with the same name.
+func Retry(t Task, policy RetryPolicy) Task {
+	return keepName(t, retryTask{t, policy})
+}
+
+type retryTask struct {
+	task   Task
+	policy RetryPolicy
+}
+
+func (t retryTask) Run(ctx context.Context) error {
+	p := t.policy
+	multiplier := p.Multiplier
+	if multiplier == 0 {
+		multiplier = 2
+	}
+	backoff := p.InitialBackoff
+	var timer *time.Timer
+	defer func() {
+		if timer != nil {
+			timer.Stop()
+		}
+	}()
+	for attempt := 1; ; attempt++ {
+		err := t.task.Run(ctx)
+		switch {
+		case err == nil:
+			return nil
+		case ctx.Err() != nil:
+			return err
+		case p.RetryIf != nil && !p.RetryIf(err):
+			return err
+		case p.MaxAttempts > 0 && attempt >= p.MaxAttempts:
+			return ErrRetriesExhausted{attempt, err}
+		}
+
+		wait := backoff
+		if p.Jitter > 0 {
+			wait += time.Duration(rand.Float64() * p.Jitter * float64(wait))
+		}
+		if timer == nil {
+			timer = time.NewTimer(wait)
+		} else {
+			timer.Reset(wait)
+		}
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		backoff = time.Duration(float64(backoff) * multiplier)
+		if p.MaxBackoff > 0 && backoff > p.MaxBackoff {
+			backoff = p.MaxBackoff
+		}
+		if rt, ok := t.task.(RebootableTask); ok {
+			rt.Reset()
+		}
+	}
+}
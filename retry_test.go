@@ -0,0 +1,78 @@
+package sup_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/warpfork/go-sup"
+)
+
+// flaky is a task which fails the given number of times, and then succeeds.
+// It's rebootable, and counts its resets.
+type flaky struct {
+	failures int
+	runs     int
+	resets   int
+}
+
+func (f *flaky) Run(ctx context.Context) error {
+	if f.runs++; f.runs <= f.failures {
+		return fmt.Errorf("failure %d", f.runs)
+	}
+	return nil
+}
+
+func (f *flaky) Reset() {
+	f.resets++
+}
+
+func TestRetry(t *testing.T) {
+	policy := sup.RetryPolicy{MaxAttempts: 4, InitialBackoff: time.Millisecond, MaxBackoff: 4 * time.Millisecond}
+	t.Run("a task which succeeds in time should succeed", func(t *testing.T) {
+		f := &flaky{failures: 3}
+		shouldEqual(t, sup.Retry(f, policy).Run(context.Background()), nil)
+		shouldEqual(t, f.runs, 4)
+		shouldEqual(t, f.resets, 3)
+	})
+	t.Run("a task which keeps failing should be given up on", func(t *testing.T) {
+		f := &flaky{failures: 10}
+		err := sup.Retry(f, policy).Run(context.Background())
+		shouldEqual(t, fmt.Sprint(err), "gave up after 4 attempts: failure 4")
+		var exhausted sup.ErrRetriesExhausted
+		shouldEqual(t, errors.As(err, &exhausted), true)
+		shouldEqual(t, exhausted.Attempts, 4)
+		shouldEqual(t, f.runs, 4)
+	})
+	t.Run("errors not worth retrying should be returned at once", func(t *testing.T) {
+		f := &flaky{failures: 10}
+		p := policy
+		p.RetryIf = func(err error) bool { return err.Error() != "failure 2" }
+		err := sup.Retry(f, p).Run(context.Background())
+		shouldEqual(t, fmt.Sprint(err), "failure 2")
+		shouldEqual(t, f.runs, 2)
+	})
+	t.Run("cancellation during a backoff should return promptly", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		f := &flaky{failures: 10}
+		start := time.Now()
+		err := sup.Retry(f, sup.RetryPolicy{InitialBackoff: time.Hour}).Run(ctx)
+		shouldEqual(t, errors.Is(err, context.DeadlineExceeded), true)
+		shouldEqual(t, time.Since(start) < time.Second, true)
+		shouldEqual(t, f.runs, 1)
+	})
+	t.Run("backoff should grow, up to the cap", func(t *testing.T) {
+		f := &flaky{failures: 4}
+		start := time.Now()
+		p := sup.RetryPolicy{InitialBackoff: 10 * time.Millisecond, MaxBackoff: 20 * time.Millisecond}
+		shouldEqual(t, sup.Retry(f, p).Run(context.Background()), nil)
+		shouldEqual(t, time.Since(start) >= 70*time.Millisecond, true) // 10 + 20 + 20 + 20.
+	})
+	t.Run("names should pass through", func(t *testing.T) {
+		task := sup.Retry(namedFunc{"retried", nil}, policy)
+		shouldEqual(t, task.(sup.NamedTask).Name(), "retried")
+	})
+}
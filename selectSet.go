@@ -0,0 +1,124 @@
+package sup
+
+import (
+	"context"
+	"errors"
+	"reflect"
+)
+
+// ErrLoopDone can be returned by a callback to end a loop (such as
+// SelectLoop, or RunSteps) normally: the loop then returns nil, rather than
+// the error.
+// It's the usual way for an actor to say "my inbox is closed; I'm finished".
+var ErrLoopDone = errors.New("loop done")
+
+// SelectSet is a set of Select cases which is prepared once, and then
+// waited on repeatedly, for loops which select over the same cases every
+// time around.  Select has to unpack its cases and build a case list every
+// time it's called, which makes garbage; a SelectSet does that work once,
+// and each Wait reuses it.
+//
+// The cases are fixed when the set is made.  To send a different value each
+// time around the loop, use a SettableSend case, and Set its value before
+// each Wait.
+//
+// A SelectSet must not be waited on by more than one goroutine at a time.
+type SelectSet struct {
+	plan selectPlan
+}
+
+// NewSelectSet prepares a SelectSet with the given cases.  As for Select,
+// more than one Default case is a usage error, and panics.
+func NewSelectSet(doThese ...Selectable) *SelectSet {
+	set := &SelectSet{}
+	set.plan.init(append([]Selectable(nil), doThese...))
+	return set
+}
+
+// Wait does a Select over the set's cases, with exactly the same behavior
+// as Select would have.
+func (set *SelectSet) Wait(ctx context.Context) error {
+	if StrictSupervision != StrictMode_off {
+		checkSupervised(ctx, "sup.SelectSet.Wait")
+	}
+	if len(set.plan.selectables) == 1 {
+		if ls, ok := set.plan.selectables[0].(loneSelectable); ok {
+			return ls.selectAlone(ctx)
+		}
+	}
+	return set.plan.run(ctx)
+}
+
+// SelectLoop does a Select over the given cases, again and again, until
+// something ends it: a callback returning an error (which SelectLoop
+// returns), a callback returning ErrLoopDone (then, SelectLoop returns nil),
+// or the context being done (then, it returns the context's error).
+//
+// It's for tasks whose whole body is a loop around one Select.  The cases
+// are prepared once, as with a SelectSet, so nothing is rebuilt each time
+// around; use SettableSend for sends whose value changes.
+func SelectLoop(ctx context.Context, doThese ...Selectable) error {
+	set := NewSelectSet(doThese...)
+	for {
+		if err := set.Wait(ctx); err != nil {
+			if errors.Is(err, ErrLoopDone) {
+				return nil
+			}
+			return err
+		}
+	}
+}
+
+// SettableSend is a send case whose value can be changed between Selects.
+// It's meant for use in a SelectSet (though it works anywhere a Selectable
+// does).  Get one from SenderChannel.SettableSend.
+//
+// Set must not be called while a Select that the case is part of is running.
+type SettableSend[T any] struct {
+	c    SenderChannel[T]
+	v    T
+	then func() error
+}
+
+// SettableSend returns a send case whose value is set by calling its Set
+// method.  Until Set is called, it sends the zero value.
+// The callback works the same as for SendAndThen.
+func (c SenderChannel[T]) SettableSend(then func() error) *SettableSend[T] {
+	return &SettableSend[T]{c: c, then: then}
+}
+
+// Set sets the value that will be sent.
+func (ss *SettableSend[T]) Set(v T) {
+	ss.v = v
+}
+
+func (ss *SettableSend[T]) selectCase() reflect.SelectCase {
+	return reflect.SelectCase{
+		Dir:  reflect.SelectSend,
+		Chan: reflect.ValueOf(ss.c.Chan),
+		Send: reflect.ValueOf(&ss.v).Elem(), // refers to the field, so Set is seen by later selects.
+	}
+}
+
+func (ss *SettableSend[T]) closedErr() error {
+	return ss.c.closedErr()
+}
+
+func (ss *SettableSend[T]) Name() string {
+	return ss.c.label()
+}
+
+func (ss *SettableSend[T]) selectOp() string {
+	return "send"
+}
+
+func (ss *SettableSend[T]) selectAlone(ctx context.Context) (err error) {
+	return sendCase[T]{ss.c, ss.v, ss.then}.selectAlone(ctx)
+}
+
+func (ss *SettableSend[T]) fire(reflect.Value, bool) error {
+	if ss.then == nil {
+		return nil
+	}
+	return ss.then()
+}
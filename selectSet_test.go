@@ -0,0 +1,117 @@
+package sup_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/warpfork/go-sup"
+)
+
+func TestSelectSet(t *testing.T) {
+	t.Run("settable sends should send the latest value", func(t *testing.T) {
+		tx, rx := sup.NewChannel[int]("ch", 1)
+		send := tx.SettableSend(nil)
+		set := sup.NewSelectSet(send, sup.ReceiverChannel[int]{}.RecvAndThen(nil))
+		var got []int
+		for i := 1; i <= 3; i++ {
+			send.Set(i * 10)
+			mustEqual(t, set.Wait(context.Background()), nil)
+			v, _ := rx.TryRecv()
+			got = append(got, v)
+		}
+		shouldEqual(t, fmt.Sprint(got), "[10 20 30]")
+	})
+	t.Run("should behave like Select", func(t *testing.T) {
+		ch := make(chan int, 1)
+		boom := errors.New("boom")
+		var got int
+		set := sup.NewSelectSet(
+			sup.ReceiverChannel[int]{Chan: ch}.RecvAndThen(func(v int) error { got = v; return boom }),
+			sup.Default(func() error { return sup.Nonblock }),
+		)
+		shouldEqual(t, set.Wait(context.Background()), sup.Nonblock)
+		ch <- 4
+		shouldEqual(t, set.Wait(context.Background()), boom)
+		shouldEqual(t, got, 4)
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		shouldEqual(t, errors.Is(set.Wait(ctx), context.Canceled), true)
+	})
+	t.Run("a lone settable send should take the fast path", func(t *testing.T) {
+		tx, rx := sup.NewChannel[int]("ch", 1)
+		send := tx.SettableSend(nil)
+		set := sup.NewSelectSet(send)
+		send.Set(7)
+		mustEqual(t, set.Wait(context.Background()), nil)
+		v, _ := rx.TryRecv()
+		shouldEqual(t, v, 7)
+		close(tx.Chan)
+		shouldEqual(t, set.Wait(context.Background()), sup.ErrChannelClosed{ChannelName: "ch"})
+	})
+}
+
+// BenchmarkSelectSetPingPong and BenchmarkSelectRebuiltPingPong do the same
+// loop -- a select with a send and a receive case -- the former prepared
+// once as a SelectSet, the latter building its Selectables every time.
+func BenchmarkSelectSetPingPong(b *testing.B) {
+	ctx := context.Background()
+	tx, rx := sup.NewChannel[int]("ch", 1)
+	send := tx.SettableSend(nil)
+	set := sup.NewSelectSet(send, rx.RecvAndThen(nil))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		send.Set(i)
+		set.Wait(ctx)
+	}
+}
+
+func BenchmarkSelectRebuiltPingPong(b *testing.B) {
+	ctx := context.Background()
+	tx, rx := sup.NewChannel[int]("ch", 1)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		sup.Select(ctx, tx.SendAndThen(i, nil), rx.RecvAndThen(nil))
+	}
+}
+
+func TestSelectLoop(t *testing.T) {
+	ctx := context.Background()
+	t.Run("ErrLoopDone should end the loop with no error", func(t *testing.T) {
+		tx, rx := sup.NewChannel[int]("inbox", 3)
+		for i := 0; i < 3; i++ {
+			tx.TrySend(i)
+		}
+		tx.Close()
+		sum := 0
+		err := sup.SelectLoop(ctx, rx.RecvOrClosed(func(v int, ok bool) error {
+			if !ok {
+				return sup.ErrLoopDone
+			}
+			sum += v
+			return nil
+		}))
+		shouldEqual(t, err, nil)
+		shouldEqual(t, sum, 3)
+	})
+	t.Run("other errors should end the loop and be returned", func(t *testing.T) {
+		boom := errors.New("boom")
+		n := 0
+		err := sup.SelectLoop(ctx, sup.Default(func() error {
+			if n++; n == 5 {
+				return boom
+			}
+			return nil
+		}))
+		shouldEqual(t, err, boom)
+		shouldEqual(t, n, 5)
+	})
+	t.Run("cancellation should end the loop", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(ctx, time.Millisecond)
+		defer cancel()
+		err := sup.SelectLoop(ctx, sup.After(time.Hour, nil))
+		shouldEqual(t, errors.Is(err, context.DeadlineExceeded), true)
+	})
+}
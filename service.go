@@ -0,0 +1,77 @@
+package sup
+
+import (
+	"context"
+	"fmt"
+)
+
+// Service is the shape of a service as github.com/thejerf/suture (v4)
+// runs them: Serve runs until the context is done, or it fails.
+type Service interface {
+	Serve(ctx context.Context) error
+}
+
+// FromService returns a task which runs a suture-style service, for moving
+// services under supervision without rewriting them.  Serve's error is
+// returned as it is.  (There's nothing like suture's restarting here, of
+// course: wrap the task with Retry for that.)
+//
+// If the service has a Name method, or failing that, a String method, the
+// task is a NamedTask, and is named by it.
+func FromService(s Service) Task {
+	switch n := s.(type) {
+	case interface{ Name() string }:
+		return namedWrapperTask{serviceTask{s}, n.Name}
+	case fmt.Stringer:
+		return namedWrapperTask{serviceTask{s}, n.String}
+	}
+	return serviceTask{s}
+}
+
+type serviceTask struct {
+	s Service
+}
+
+func (t serviceTask) Run(ctx context.Context) error {
+	return t.s.Serve(ctx)
+}
+
+// FromRunPair returns a task which runs an actor in the style of
+// github.com/oklog/run's Group: execute runs until it's done, or until
+// interrupt is called, which should make it return promptly.
+//
+// The task calls interrupt, with the context's error, when its context is
+// done.  As run.Group does, it also calls interrupt when execute returns by
+// itself (with execute's error), so an actor which cleans up in interrupt
+// always gets to.  Either way, interrupt is called exactly once, and the
+// task doesn't return until that call has.
+//
+// If execute is a method value (like srv.Run), the task is a NamedTask,
+// named for the method and its receiver's type, as with TaskFromFunc.
+func FromRunPair(execute func() error, interrupt func(error)) Task {
+	t := runPairTask{execute, interrupt}
+	if name := methodValueName(execute); name != "" {
+		return namedWrapperTask{t, func() string { return name }}
+	}
+	return t
+}
+
+type runPairTask struct {
+	execute   func() error
+	interrupt func(error)
+}
+
+func (t runPairTask) Run(ctx context.Context) error {
+	interrupted := make(chan struct{})
+	stop := context.AfterFunc(ctx, func() {
+		t.interrupt(ctx.Err())
+		close(interrupted)
+	})
+	err := t.execute()
+	if stop() {
+		t.interrupt(err) // the context's not done; so it's ours to call.
+	} else {
+		<-interrupted // the context's call may still be running.
+	}
+	return err
+}
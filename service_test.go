@@ -0,0 +1,95 @@
+package sup_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+
+	"github.com/warpfork/go-sup"
+)
+
+type fakeService struct {
+	err error
+}
+
+func (s fakeService) Serve(ctx context.Context) error {
+	if s.err != nil {
+		return s.err
+	}
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+type namedService struct {
+	fakeService
+}
+
+func (namedService) Name() string { return "named" }
+
+type stringerService struct {
+	fakeService
+}
+
+func (stringerService) String() string { return "stringer" }
+
+// fakeActor is a run.Group-style actor: Execute runs until Interrupt.
+type fakeActor struct {
+	stop       chan struct{}
+	interrupts atomic.Int32
+	err        error
+}
+
+func (a *fakeActor) Execute() error {
+	if a.err != nil {
+		return a.err
+	}
+	<-a.stop
+	return nil
+}
+
+func (a *fakeActor) Interrupt(error) {
+	if a.interrupts.Add(1) == 1 {
+		close(a.stop)
+	}
+}
+
+func TestFromService(t *testing.T) {
+	t.Run("Serve should run with the task's context, and its error be returned", func(t *testing.T) {
+		boom := errors.New("boom")
+		shouldEqual(t, sup.FromService(fakeService{boom}).Run(context.Background()), boom)
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		shouldEqual(t, sup.FromService(fakeService{}).Run(ctx), context.Canceled)
+	})
+	t.Run("names should pass through", func(t *testing.T) {
+		_, named := sup.FromService(fakeService{}).(sup.NamedTask)
+		shouldEqual(t, named, false)
+		shouldEqual(t, sup.FromService(namedService{}).(sup.NamedTask).Name(), "named")
+		shouldEqual(t, sup.FromService(stringerService{}).(sup.NamedTask).Name(), "stringer")
+	})
+}
+
+func TestFromRunPair(t *testing.T) {
+	t.Run("cancellation should interrupt the actor, once", func(t *testing.T) {
+		actor := &fakeActor{stop: make(chan struct{})}
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		shouldEqual(t, sup.FromRunPair(actor.Execute, actor.Interrupt).Run(ctx), nil)
+		shouldEqual(t, actor.interrupts.Load(), int32(1))
+	})
+	t.Run("an actor which returns by itself should still be interrupted, once", func(t *testing.T) {
+		boom := errors.New("boom")
+		actor := &fakeActor{stop: make(chan struct{}), err: boom}
+		ctx, cancel := context.WithCancel(context.Background())
+		shouldEqual(t, sup.FromRunPair(actor.Execute, actor.Interrupt).Run(ctx), boom)
+		cancel()
+		shouldEqual(t, actor.interrupts.Load(), int32(1))
+	})
+	t.Run("names should come from execute, if it's a method value", func(t *testing.T) {
+		actor := &fakeActor{}
+		shouldEqual(t, sup.FromRunPair(actor.Execute, actor.Interrupt).(sup.NamedTask).Name(), "fakeActor.Execute")
+		_, named := sup.FromRunPair(func() error { return nil }, func(error) {}).(sup.NamedTask)
+		shouldEqual(t, named, false)
+	})
+}
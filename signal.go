@@ -0,0 +1,85 @@
+package sup
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// SignalTask returns a task which turns signals into an orderly shutdown of
+// the supervisor it's run by: the first of the given signals soft stops the
+// supervisor (see Supervisor.SoftStop), so all its tasks, recursively, are
+// asked to finish up; a second one calls QuitAggressively, cancelling them.
+// If no signals are given, it's SIGINT and SIGTERM.
+//
+// Run it alongside the program's other tasks, under the root supervisor, so
+// a signal reaches them all; MainSupervisor does that.  It returns once
+// it's the only task left in its supervisor (so it doesn't keep the
+// supervisor from finishing when the work is done), or when its context is
+// done.  It stops listening for the signals when it returns (with
+// signal.Stop), so it can be run again, in tests, for example.
+//
+// Signals arriving while the supervisor is already winding down are
+// harmless: SoftStop and QuitAggressively can be called any number of
+// times.
+//
+// It panics if it isn't run by a supervisor.
+func SignalTask(signals ...os.Signal) NamedTask {
+	if len(signals) == 0 {
+		signals = []os.Signal{os.Interrupt, syscall.SIGTERM}
+	}
+	return signalTask{signals}
+}
+
+type signalTask struct {
+	signals []os.Signal
+}
+
+func (signalTask) Name() string {
+	return "signals"
+}
+
+func (t signalTask) Run(ctx context.Context) error {
+	info, _ := ctx.Value(ctxKey{}).(ctxInfo)
+	mgr := info.mgr
+	if mgr == nil {
+		panic("usage: SignalTask must be run by a supervisor")
+	}
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, t.signals...)
+	defer signal.Stop(sigs)
+
+	// Watch for being the last task standing.
+	done := make(chan struct{})
+	defer close(done)
+	alone := make(chan struct{})
+	go func() {
+		mgr.live.waitFewer(1, done)
+		close(alone)
+	}()
+
+	for _, react := range []func(){mgr.SoftStop, mgr.QuitAggressively} {
+		select {
+		case <-sigs:
+			react()
+		case <-alone:
+			return nil
+		case <-ctx.Done():
+			return nil
+		}
+	}
+	return nil
+}
+
+// MainSupervisor returns the supervisor for the top of a program: a
+// fork-join supervisor of the given tasks, with a SignalTask for SIGINT and
+// SIGTERM alongside them.  Run it with SuperviseRoot, from main:
+//
+//	err := sup.SuperviseRoot(context.Background(), sup.MainSupervisor("main", tasks))
+//
+// Then, the first Ctrl-C asks the tasks to finish up, and the second
+// cancels them.
+func MainSupervisor(name string, tasks []Task, opts ...SupervisionOptions) Supervisor {
+	return SuperviseForkJoin(name, append(tasks[:len(tasks):len(tasks)], SignalTask()), opts...)
+}
@@ -0,0 +1,89 @@
+//go:build unix
+
+package sup_test
+
+import (
+	"context"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/warpfork/go-sup"
+)
+
+// signalUntil sends a signal to this process until the channel is closed.
+// (The first few may come before the SignalTask is listening; SIGWINCH is
+// ignored by default, so they're harmless.)
+func signalUntil(t *testing.T, sig os.Signal, until <-chan struct{}) {
+	self, err := os.FindProcess(os.Getpid())
+	mustEqual(t, err, nil)
+	for {
+		self.Signal(sig)
+		select {
+		case <-until:
+			return
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+}
+
+func TestSignalTask(t *testing.T) {
+	t.Run("without a signal, it should return once the other tasks are done", func(t *testing.T) {
+		err := sup.SuperviseRoot(context.Background(), sup.SuperviseForkJoin("main", []sup.Task{
+			sup.SignalTask(syscall.SIGWINCH),
+			sup.NamedFunc("work", func(ctx context.Context) error { return nil }),
+		}))
+		shouldEqual(t, err, nil)
+	})
+	t.Run("the first signal should soft stop the supervisor, every time it's run", func(t *testing.T) {
+		for i := 0; i < 3; i++ {
+			stopped := make(chan struct{})
+			err := sup.SuperviseRoot(context.Background(), sup.SuperviseForkJoin("main", []sup.Task{
+				sup.SignalTask(syscall.SIGWINCH),
+				sup.NamedFunc("work", func(ctx context.Context) error {
+					go signalUntil(t, syscall.SIGWINCH, stopped)
+					<-sup.SoftStopCh(ctx)
+					close(stopped)
+					return ctx.Err()
+				}),
+			}))
+			shouldEqual(t, err, nil)
+		}
+	})
+	t.Run("a second signal should cancel the supervisor", func(t *testing.T) {
+		stopped, cancelled := make(chan struct{}), make(chan struct{})
+		svr := sup.SuperviseForkJoin("main", []sup.Task{
+			sup.SignalTask(syscall.SIGWINCH),
+			sup.NamedFunc("stubborn", func(ctx context.Context) error {
+				go signalUntil(t, syscall.SIGWINCH, stopped)
+				<-sup.SoftStopCh(ctx)
+				close(stopped)
+				go signalUntil(t, syscall.SIGWINCH, cancelled)
+				<-ctx.Done()
+				close(cancelled)
+				return ctx.Err()
+			}),
+		})
+		err := sup.SuperviseRoot(context.Background(), svr)
+		shouldEqual(t, unwrapChild(err), context.Canceled)
+	})
+	t.Run("it should panic if it isn't supervised", func(t *testing.T) {
+		defer func() {
+			shouldEqual(t, recover(), "usage: SignalTask must be run by a supervisor")
+		}()
+		sup.SignalTask().Run(context.Background())
+	})
+}
+
+func TestQuitAggressively(t *testing.T) {
+	svr := sup.SuperviseForkJoin("main", []sup.Task{
+		sup.NamedFunc("wait", func(ctx context.Context) error {
+			<-ctx.Done()
+			return nil
+		}),
+	})
+	svr.QuitAggressively()
+	svr.QuitAggressively()
+	shouldEqual(t, sup.SuperviseRoot(context.Background(), svr), context.Canceled)
+}
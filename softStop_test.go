@@ -0,0 +1,50 @@
+package sup_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/warpfork/go-sup"
+)
+
+func TestSoftStop(t *testing.T) {
+	t.Run("a stream supervisor should stop taking tasks, and wait for its children", func(t *testing.T) {
+		taskGen := make(chan sup.Task)
+		svr := sup.SuperviseStream("main", taskGen)
+		finished := false
+		go func() {
+			taskGen <- namedFunc{"worker", func(ctx context.Context) error {
+				<-sup.SoftStopCh(ctx)
+				time.Sleep(time.Millisecond) // finishing up the current work item...
+				finished = true
+				return ctx.Err() // ... which, importantly, isn't cancelled.
+			}}
+			svr.SoftStop()
+		}()
+		err := sup.SuperviseRoot(context.Background(), svr)
+		shouldEqual(t, err, nil)
+		shouldEqual(t, finished, true)
+		shouldEqual(t, svr.ExitReason(), sup.ExitReason_drained)
+	})
+	t.Run("should reach tasks under nested supervisors", func(t *testing.T) {
+		svr := sup.SuperviseForkJoin("outer", []sup.Task{
+			sup.SuperviseForkJoin("inner", []sup.Task{
+				namedFunc{"worker", func(ctx context.Context) error {
+					select {
+					case <-sup.SoftStopCh(ctx):
+						return nil
+					case <-ctx.Done():
+						return ctx.Err()
+					}
+				}},
+			}),
+		})
+		svr.SoftStop() // before Run is fine too.
+		err := sup.SuperviseRoot(context.Background(), svr)
+		shouldEqual(t, err, nil)
+	})
+	t.Run("unsupervised contexts should get a nil channel", func(t *testing.T) {
+		shouldEqual(t, sup.SoftStopCh(context.Background()) == nil, true)
+	})
+}
@@ -0,0 +1,172 @@
+package sup
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// SteppedTask is a task written as a single step, which TaskOfSteppedTask
+// calls over and over: for an actor, say, each step handles one message.
+// The loop is RunSteps, so it ends as that describes.  (Calling RunSteps
+// from a task's own Run method is usually simpler, though; SteppedTask is
+// for when there are setup and teardown steps, too.)
+//
+// A SteppedTask can also implement SteppedTaskSetup and
+// SteppedTaskTeardown, to have steps run once before the loop and once
+// after it.  If it has a Name method, as a NamedTask does, the task
+// keeps its name.
+type SteppedTask interface {
+	RunStep(ctx Context) error
+}
+
+// SteppedTaskSetup can be implemented by a SteppedTask which needs to do
+// something before its first step, like sending the message that starts a
+// conversation.  If FirstStep returns an error, the loop is skipped: the
+// teardown step (if any) runs, and then the task returns the error.
+type SteppedTaskSetup interface {
+	FirstStep(ctx Context) error
+}
+
+// SteppedTaskTeardown can be implemented by a SteppedTask which needs to
+// clean up after its last step.  LastStep runs however the loop ended,
+// even if the context was cancelled: it gets a context of its own, with
+// the same values, which is only cancelled a short grace period (a second)
+// after the task's context is.  Its error is returned if the loop didn't
+// already end with one.
+type SteppedTaskTeardown interface {
+	LastStep(ctx Context) error
+}
+
+// steppedTaskTeardownGrace is how long LastStep has after the task's
+// context is done.
+const steppedTaskTeardownGrace = time.Second
+
+// RunSteps calls step over and over, until it returns an error, or the
+// context is done, or the supervisor asks for a soft stop (see SoftStopCh);
+// those are checked between steps.  It returns the step's error, except
+// that ErrLoopDone means the loop ended normally, and RunSteps returns nil
+// for it; it returns the context's error if the context is done, and nil
+// on a soft stop.
+//
+// It's the loop TaskOfSteppedTask uses, for a task to call in its own Run
+// method, which is the recommended way to write a stepped task:
+//
+//	func (t *myTask) Run(ctx context.Context) error {
+//		return sup.RunSteps(ctx, t.step)
+//	}
+func RunSteps(ctx Context, step func(Context) error) error {
+	softStop := SoftStopCh(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-softStop:
+			return nil
+		default:
+		}
+		if err := step(ctx); err != nil {
+			if errors.Is(err, ErrLoopDone) {
+				return nil
+			}
+			return err
+		}
+	}
+}
+
+// softStopCase is a Select case which proceeds when the supervisor asks for
+// a soft stop, and returns ErrLoopDone.  It's for steps which wait in a
+// Select, so that a soft stop ends the loop even while they're waiting,
+// not only between steps.  (If the context isn't supervised, it never
+// proceeds.)
+func softStopCase(ctx Context) Selectable {
+	return ReceiverChannel[struct{}]{Chan: SoftStopCh(ctx)}.RecvAndThen(func(struct{}) error {
+		return ErrLoopDone
+	})
+}
+
+// RunStepsEvery is RunSteps, with a pause of the given interval after each
+// step.  (The interval is between the end of one step and the start of the
+// next, so a slow step delays the ones after it.)  The pause ends early,
+// returning, if the context is done or a soft stop is asked for.
+func RunStepsEvery(ctx Context, interval time.Duration, step func(Context) error) error {
+	softStop := SoftStopCh(ctx)
+	timer := time.NewTimer(interval)
+	defer timer.Stop()
+	return RunSteps(ctx, func(ctx Context) error {
+		if err := step(ctx); err != nil {
+			return err
+		}
+		if !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+		timer.Reset(interval)
+		select {
+		case <-timer.C:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-softStop:
+			return ErrLoopDone
+		}
+	})
+}
+
+// TaskOfSteppedTask returns a Task which runs the SteppedTask: its
+// FirstStep, if it has one, then RunStep in a loop, then its LastStep, if
+// it has one.  See SteppedTask for how the loop ends.
+func TaskOfSteppedTask(st SteppedTask) Task {
+	// A SteppedTask has RunStep rather than Run, so it can't be a NamedTask
+	//  itself; a Name method of its own still names the task.
+	if n, ok := st.(interface{ Name() string }); ok {
+		return namedWrapperTask{steppedTask{st}, n.Name}
+	}
+	return steppedTask{st}
+}
+
+type steppedTask struct {
+	st SteppedTask
+}
+
+func (t steppedTask) Run(ctx context.Context) error {
+	var err error
+	if setup, ok := t.st.(SteppedTaskSetup); ok {
+		err = setup.FirstStep(ctx)
+	}
+	if err == nil {
+		err = RunSteps(ctx, t.st.RunStep)
+	}
+	if teardown, ok := t.st.(SteppedTaskTeardown); ok {
+		graceCtx, cancel := withGrace(ctx, steppedTaskTeardownGrace)
+		teardownErr := teardown.LastStep(graceCtx)
+		cancel()
+		if err == nil {
+			err = teardownErr
+		}
+	}
+	return err
+}
+
+// withGrace returns a context with the same values as ctx, which is
+// cancelled the given grace period after ctx is (or when the cancel
+// function is called).
+func withGrace(ctx Context, grace time.Duration) (Context, context.CancelFunc) {
+	graceCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	go func() {
+		select {
+		case <-ctx.Done():
+			timer := time.NewTimer(grace)
+			defer timer.Stop()
+			select {
+			case <-timer.C:
+				cancel()
+			case <-graceCtx.Done():
+			}
+		case <-graceCtx.Done():
+		}
+	}()
+	return graceCtx, cancel
+}
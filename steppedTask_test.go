@@ -0,0 +1,190 @@
+package sup_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/warpfork/go-sup"
+)
+
+// pinger serves first: its FirstStep sends the ball, and then each step
+// waits for it to come back and sends it again, until it's done enough
+// rounds.  If FirstStep weren't called, the two would wait for each other
+// forever.
+type pinger struct {
+	out    sup.SenderChannel[int]
+	in     sup.ReceiverChannel[int]
+	rounds int
+	log    *[]string
+}
+
+func (p *pinger) Name() string { return "pinger" }
+
+func (p *pinger) FirstStep(ctx context.Context) error {
+	*p.log = append(*p.log, "serve")
+	return sup.Send(ctx, p.out.Chan, 0)
+}
+
+func (p *pinger) RunStep(ctx context.Context) error {
+	return sup.Select(ctx, p.in.RecvAndThen(func(n int) error {
+		if n >= p.rounds {
+			return sup.ErrLoopDone
+		}
+		*p.log = append(*p.log, fmt.Sprint("ping ", n+1))
+		return sup.Send(ctx, p.out.Chan, n+1)
+	}))
+}
+
+func (p *pinger) LastStep(ctx context.Context) error {
+	*p.log = append(*p.log, "close")
+	p.out.Close()
+	return nil
+}
+
+type ponger struct {
+	in  sup.ReceiverChannel[int]
+	out sup.SenderChannel[int]
+}
+
+func (p *ponger) RunStep(ctx context.Context) error {
+	return sup.Select(ctx, p.in.RecvOrClosed(func(n int, ok bool) error {
+		if !ok {
+			return sup.ErrLoopDone
+		}
+		return sup.Send(ctx, p.out.Chan, n)
+	}))
+}
+
+func TestSteppedTask(t *testing.T) {
+	t.Run("setup, steps, and teardown should all run", func(t *testing.T) {
+		pingTx, pingRx := sup.NewChannel[int]("ping", 0)
+		pongTx, pongRx := sup.NewChannel[int]("pong", 0)
+		var log []string
+		err := sup.SuperviseRoot(context.Background(), sup.SuperviseForkJoin("game", []sup.Task{
+			sup.TaskOfSteppedTask(&pinger{pingTx, pongRx, 3, &log}),
+			sup.TaskOfSteppedTask(&ponger{pingRx, pongTx}),
+		}))
+		shouldEqual(t, err, nil)
+		shouldEqual(t, fmt.Sprint(log), "[serve ping 1 ping 2 ping 3 close]")
+	})
+	t.Run("a setup error should skip the loop, but not the teardown", func(t *testing.T) {
+		var log []string
+		task := sup.TaskOfSteppedTask(&funcSteps{
+			first: func(context.Context) error { log = append(log, "first"); return errors.New("setup failed") },
+			step:  func(context.Context) error { log = append(log, "step"); return sup.ErrLoopDone },
+			last:  func(context.Context) error { log = append(log, "last"); return nil },
+		})
+		err := task.Run(context.Background())
+		shouldEqual(t, fmt.Sprint(err), "setup failed")
+		shouldEqual(t, fmt.Sprint(log), "[first last]")
+	})
+	t.Run("teardown should run with a live context after cancellation", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		var teardownErr error
+		task := sup.TaskOfSteppedTask(&funcSteps{
+			step: func(context.Context) error { cancel(); return nil },
+			last: func(ctx context.Context) error { teardownErr = ctx.Err(); return nil },
+		})
+		err := task.Run(ctx)
+		shouldEqual(t, errors.Is(err, context.Canceled), true)
+		shouldEqual(t, teardownErr, nil)
+	})
+	t.Run("a soft stop should end the loop between steps", func(t *testing.T) {
+		steps := 0
+		var svr sup.Supervisor
+		svr = sup.SuperviseForkJoin("main", []sup.Task{sup.TaskOfSteppedTask(&funcSteps{
+			step: func(context.Context) error {
+				if steps++; steps == 3 {
+					svr.SoftStop()
+				}
+				return nil
+			},
+		})})
+		shouldEqual(t, sup.SuperviseRoot(context.Background(), svr), nil)
+		shouldEqual(t, steps, 3)
+	})
+	t.Run("a named stepped task should keep its name", func(t *testing.T) {
+		task := sup.TaskOfSteppedTask(&pinger{})
+		shouldEqual(t, task.(sup.NamedTask).Name(), "pinger")
+	})
+}
+
+// funcSteps is a SteppedTask with all the optional steps, each of which
+// calls a function, if it's set.
+type funcSteps struct {
+	first, step, last func(context.Context) error
+}
+
+func (f *funcSteps) FirstStep(ctx context.Context) error { return call(f.first, ctx) }
+func (f *funcSteps) RunStep(ctx context.Context) error   { return call(f.step, ctx) }
+func (f *funcSteps) LastStep(ctx context.Context) error  { return call(f.last, ctx) }
+
+func call(fn func(context.Context) error, ctx context.Context) error {
+	if fn == nil {
+		return nil
+	}
+	return fn(ctx)
+}
+
+func TestRunSteps(t *testing.T) {
+	t.Run("ErrLoopDone should end the loop without an error", func(t *testing.T) {
+		steps := 0
+		err := sup.RunSteps(context.Background(), func(context.Context) error {
+			if steps++; steps == 5 {
+				return sup.ErrLoopDone
+			}
+			return nil
+		})
+		shouldEqual(t, err, nil)
+		shouldEqual(t, steps, 5)
+	})
+	t.Run("other errors should end the loop with the error", func(t *testing.T) {
+		err := sup.RunSteps(context.Background(), func(context.Context) error { return errors.New("boom") })
+		shouldEqual(t, fmt.Sprint(err), "boom")
+	})
+	t.Run("cancellation should be checked between steps", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		steps := 0
+		err := sup.RunSteps(ctx, func(context.Context) error {
+			if steps++; steps == 2 {
+				cancel()
+			}
+			return nil
+		})
+		shouldEqual(t, errors.Is(err, context.Canceled), true)
+		shouldEqual(t, steps, 2)
+	})
+}
+
+func TestRunStepsEvery(t *testing.T) {
+	t.Run("steps should be paced", func(t *testing.T) {
+		var times []time.Time
+		err := sup.RunStepsEvery(context.Background(), 10*time.Millisecond, func(context.Context) error {
+			if times = append(times, time.Now()); len(times) == 3 {
+				return sup.ErrLoopDone
+			}
+			return nil
+		})
+		shouldEqual(t, err, nil)
+		mustEqual(t, len(times), 3)
+		shouldEqual(t, times[1].Sub(times[0]) >= 10*time.Millisecond, true)
+		shouldEqual(t, times[2].Sub(times[1]) >= 10*time.Millisecond, true)
+	})
+	t.Run("cancellation should end the pause", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		start := time.Now()
+		err := sup.RunStepsEvery(ctx, time.Hour, func(context.Context) error { cancel(); return nil })
+		shouldEqual(t, errors.Is(err, context.Canceled), true)
+		shouldEqual(t, time.Since(start) < time.Second, true)
+	})
+	t.Run("a soft stop should end the pause", func(t *testing.T) {
+		var svr sup.Supervisor
+		svr = sup.SuperviseForkJoin("main", sup.TaskFromFunc(func(ctx context.Context) error {
+			return sup.RunStepsEvery(ctx, time.Hour, func(context.Context) error { svr.SoftStop(); return nil })
+		}))
+		shouldEqual(t, sup.SuperviseRoot(context.Background(), svr), nil)
+	})
+}
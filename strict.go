@@ -0,0 +1,72 @@
+package sup
+
+import (
+	"fmt"
+	"runtime"
+	"sync"
+)
+
+// IsSupervised returns true if the context belongs to a task launched by a
+// supervisor (or is derived from such a context).  Code handed a bare
+// context.Background() instead gets false; go-sup still works with such a
+// context, but quietly does less: there's no task path, and there's no
+// supervisor for warnings to go to.
+func IsSupervised(ctx Context) bool {
+	info, _ := ctx.Value(ctxKey{}).(ctxInfo)
+	return info.task != nil
+}
+
+// StrictMode says what go-sup does when it's given a context which isn't
+// supervised (see IsSupervised and StrictSupervision).
+type StrictMode uint8
+
+const (
+	StrictMode_off   = StrictMode(0) // nothing; unsupervised contexts are fine.
+	StrictMode_warn  = StrictMode(1) // log an unsupervised warning, once per calling line of code.
+	StrictMode_panic = StrictMode(2) // panic.  Useful in tests.
+)
+
+// StrictSupervision, if set to something other than StrictMode_off, makes
+// go-sup's channel helpers (Select, SelectValue, SelectSet.Wait, Send, Recv,
+// Merge, Ask, and WorkerPool.Submit) check that the context they're given is
+// supervised, and complain when it isn't.  The check happens on the calling goroutine, as
+// the helper is called, so a panic can be recovered by the caller.  It's for development, to make it loud when a bare
+// context.Background() has sneaked in somewhere supervision was expected.
+//
+// It's off by default, and production behavior is unchanged while it is.
+//
+// Under StrictMode_warn, the warning is a SupervisionWarning of kind
+// WarningKind_unsupervised, with a stack sample showing the caller.  Since
+// there's no supervisor to send it to, it's logged with
+// SlogWarningHandler, to the logger set by CtxWithLogger (or
+// slog.Default).  Each line of code calling a helper is warned about only
+// once, however many times it's called.
+//
+// Set it before starting any goroutines which use go-sup (e.g. in main, or
+// in TestMain).
+var StrictSupervision StrictMode
+
+var unsupervisedCallers sync.Map // of program counters which have been warned about.
+
+// checkSupervised is called by the helpers, when StrictSupervision is on.
+// The helpers are the ones calling it, so their caller is two frames up.
+func checkSupervised(ctx Context, helper string) {
+	if IsSupervised(ctx) {
+		return
+	}
+	message := fmt.Sprintf("%s called with a context that doesn't belong to a supervised task", helper)
+	if StrictSupervision == StrictMode_panic {
+		panic(message)
+	}
+	pc, _, _, _ := runtime.Caller(2)
+	if _, warned := unsupervisedCallers.LoadOrStore(pc, struct{}{}); warned {
+		return
+	}
+	stack := make([]byte, 4<<10)
+	stack = stack[:runtime.Stack(stack, false)]
+	SlogWarningHandler(ctxLogger(ctx))(SupervisionWarning{
+		Kind:    WarningKind_unsupervised,
+		Message: message,
+		Stack:   string(stack),
+	})
+}
@@ -0,0 +1,103 @@
+package sup_test
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/warpfork/go-sup"
+)
+
+func TestIsSupervised(t *testing.T) {
+	shouldEqual(t, sup.IsSupervised(context.Background()), false)
+	var supervised bool
+	sup.SuperviseRoot(context.Background(),
+		sup.SuperviseForkJoin("main", []sup.Task{
+			namedFunc{"worker", func(ctx context.Context) error {
+				ctx, cancel := context.WithCancel(ctx) // derived contexts count too.
+				defer cancel()
+				supervised = sup.IsSupervised(ctx)
+				return nil
+			}},
+		}),
+	)
+	shouldEqual(t, supervised, true)
+}
+
+func TestStrictSupervision(t *testing.T) {
+	t.Run("should warn once per calling line", func(t *testing.T) {
+		sup.ResetStrictWarnings()
+		sup.StrictSupervision = sup.StrictMode_warn
+		defer func() { sup.StrictSupervision = sup.StrictMode_off }()
+		var buf bytes.Buffer
+		ctx := sup.CtxWithLogger(context.Background(), textLogger(&buf))
+		for i := 0; i < 3; i++ {
+			sup.Select(ctx, sup.Default(nil))
+		}
+		shouldEqual(t, strings.Count(buf.String(), "kind=unsupervised"), 1)
+		sup.Select(ctx, sup.Default(nil)) // a different line.
+		shouldEqual(t, strings.Count(buf.String(), "kind=unsupervised"), 2)
+		shouldEqual(t, strings.Contains(buf.String(), "msg=\"sup.Select called with a context that doesn't belong to a supervised task\""), true)
+	})
+	t.Run("supervised contexts should be left alone", func(t *testing.T) {
+		sup.StrictSupervision = sup.StrictMode_panic
+		defer func() { sup.StrictSupervision = sup.StrictMode_off }()
+		err := sup.SuperviseRoot(context.Background(),
+			sup.SuperviseForkJoin("main", []sup.Task{
+				namedFunc{"worker", func(ctx context.Context) error {
+					return sup.Select(ctx, sup.Default(nil))
+				}},
+			}),
+		)
+		shouldEqual(t, err, nil)
+	})
+	t.Run("panic mode should panic", func(t *testing.T) {
+		sup.StrictSupervision = sup.StrictMode_panic
+		defer func() { sup.StrictSupervision = sup.StrictMode_off }()
+		defer func() {
+			shouldEqual(t, recover(), "sup.Send called with a context that doesn't belong to a supervised task")
+		}()
+		sup.Send(context.Background(), make(chan int, 1), 1)
+	})
+	t.Run("merge should be checked on the caller's goroutine", func(t *testing.T) {
+		sup.StrictSupervision = sup.StrictMode_panic
+		defer func() { sup.StrictSupervision = sup.StrictMode_off }()
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		defer func() {
+			shouldEqual(t, recover(), "sup.Merge called with a context that doesn't belong to a supervised task")
+		}()
+		_, rx := sup.NewChannel[int]("in", 0)
+		sup.Merge(ctx, rx)
+	})
+	t.Run("merge warnings should point at the caller", func(t *testing.T) {
+		sup.ResetStrictWarnings()
+		sup.StrictSupervision = sup.StrictMode_warn
+		defer func() { sup.StrictSupervision = sup.StrictMode_off }()
+		var buf bytes.Buffer
+		ctx := sup.CtxWithLogger(context.Background(), textLogger(&buf))
+		tx, rx := sup.NewChannel[int]("in", 1)
+		tx.TrySend(1)
+		tx.Close()
+		merged := sup.Merge(ctx, rx)
+		for range merged.Chan {
+		}
+		shouldEqual(t, strings.Count(buf.String(), "msg=\"sup.Merge called"), 1)
+		shouldEqual(t, strings.Contains(buf.String(), "strict_test.go"), true)
+		shouldEqual(t, strings.Contains(buf.String(), "msg=\"sup.Recv called"), false)
+		shouldEqual(t, strings.Contains(buf.String(), "msg=\"sup.Send called"), false)
+	})
+	t.Run("submit warnings should name submit, and point at the caller", func(t *testing.T) {
+		sup.ResetStrictWarnings()
+		sup.StrictSupervision = sup.StrictMode_warn
+		defer func() { sup.StrictSupervision = sup.StrictMode_off }()
+		var buf bytes.Buffer
+		ctx := sup.CtxWithLogger(context.Background(), textLogger(&buf))
+		pool := sup.NewWorkerPool("pool", 1, func(context.Context, int) error { return nil })
+		pool.Close()
+		pool.Submit(ctx, 1)
+		shouldEqual(t, strings.Count(buf.String(), "msg=\"sup.WorkerPool.Submit called"), 1)
+		shouldEqual(t, strings.Contains(buf.String(), "msg=\"sup.Select called"), false)
+	})
+}
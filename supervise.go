@@ -2,6 +2,8 @@ package sup
 
 import (
 	"context"
+	"errors"
+	"time"
 )
 
 // Supervisor is a marker interface for supervisor implementations.
@@ -21,8 +23,49 @@ import (
 // Cancellation of one supervisor will automatically fan out to all children
 // (including, of course, recursively through other supervisors).
 type Supervisor interface {
-	NamedTask     // All supervisors are themselves tasks that can be submitted to another supervisor.
-	Phase() Phase // Return the current phase the supervisor is in (advisory/monitoring only).
+	NamedTask               // All supervisors are themselves tasks that can be submitted to another supervisor.
+	Phase() Phase           // Return the current phase the supervisor is in (advisory/monitoring only).
+	ExitReason() ExitReason // Return why the supervisor stopped (or ExitReason_notFinished); set as it leaves the running/collecting phases.
+
+	// Await blocks until the supervisor's Run has finished (that is, it's
+	// reached Phase_halt), and returns the same error Run returned.
+	// If the context is done first, Await returns the context's error instead.
+	// It's safe to call from any goroutine, any number of times, before or
+	// after Run is called.
+	Await(ctx context.Context) error
+
+	// SoftStop asks the supervisor's tasks to finish what they're doing and
+	// return, without cancelling them: it closes the channel SoftStopCh
+	// returns for them (and for the tasks of any supervisors among them,
+	// recursively).  A stream supervisor also stops taking new tasks, and
+	// returns once the ones it has are done.  Cancelling the context is
+	// still the way to insist.
+	// It's safe to call from any goroutine, any number of times, before or
+	// after Run is called.
+	SoftStop()
+
+	// QuitAggressively cancels the supervisor, just as if its parent's
+	// context were done: its tasks' contexts are cancelled, and it returns
+	// context.Canceled once they've returned.  It's the insistent follow-up
+	// to SoftStop, for when the parent's context isn't at hand (as when a
+	// SignalTask sees a second signal).
+	// It's safe to call from any goroutine, any number of times, before or
+	// after Run is called.
+	QuitAggressively()
+
+	// QueueLatency returns the longest and the mean time that the
+	// supervisor's children have waited between being launched and starting
+	// to run (see the QueueLatency function), so far.  They're zero until a
+	// child has started.
+	QueueLatency() (max, mean time.Duration)
+
+	// Find looks up a running task by its full path (as seen by
+	// CtxTaskPath, or in a log line), among the supervisor's children, and
+	// theirs, recursively.  It returns false if there's no such task
+	// running: if it has returned, or was never launched, or is under a
+	// supervisor which has halted, or isn't under this supervisor at all.
+	// It's safe to call from any goroutine, at any time.
+	Find(path string) (TaskInfo, bool)
 }
 
 // SuperviseRoot takes a supervisor and runs it in the current goroutine.
@@ -45,9 +88,44 @@ func SuperviseForkJoin(
 	tasks []Task,
 	opts ...SupervisionOptions,
 ) Supervisor {
-	return superviseFJ{name: taskGroupName}.init(tasks)
+	return superviseFJ{superviseCommon: superviseCommon{
+		name: taskGroupName,
+		cfg:  applyOptions(opts),
+	}}.init(tasks)
+}
+
+// SuperviseForkJoinLimited is SuperviseForkJoin, except that at most limit
+// of the tasks run at once: the first limit are launched, and then the next
+// each time one finishes, in the order given.  Errors, panics, and
+// cancellation are handled just as they are by SuperviseForkJoin.
+//
+// If the supervisor starts winding down (because a task failed, or the
+// context is done) before every task has been launched, the rest are never
+// run; they're recorded as skipped, with ErrTaskSkipped, and an unlaunched
+// warning says how many there were.  A soft stop also stops any more tasks
+// being launched (while the running ones finish).
+//
+// It panics if the limit isn't positive.
+func SuperviseForkJoinLimited(
+	taskGroupName string,
+	limit int,
+	tasks []Task,
+	opts ...SupervisionOptions,
+) Supervisor {
+	if limit <= 0 {
+		panic("usage: a fork-join supervisor's limit must be positive")
+	}
+	return superviseFJ{superviseCommon: superviseCommon{
+		name: taskGroupName,
+		cfg:  applyOptions(opts),
+	}, limit: limit}.init(tasks)
 }
 
+// ErrTaskSkipped is what a supervisor records as the result of a task which
+// was never launched, because the supervisor was winding down by the time
+// it would have been.  (See SuperviseForkJoinLimited.)
+var ErrTaskSkipped = errors.New("task skipped")
+
 // SuperviseStream creates a Supervisor which will launch and handle
 // a goroutine for each of the tasks supplied by the given TaskGen channel.
 // When run, the supervisor will not return until the TaskGen channel is closed
@@ -57,13 +135,54 @@ func SuperviseStream(
 	taskSrc TaskGen,
 	opts ...SupervisionOptions,
 ) Supervisor {
-	return superviseStream{name: taskGroupName}.init(taskSrc)
+	return superviseStream{superviseCommon: superviseCommon{
+		name: taskGroupName,
+		cfg:  applyOptions(opts),
+	}}.init(taskSrc)
 }
 
-// Placeholder.
+// SupervisionOptions configure a supervisor.  Any number of them may be
+// passed when constructing a supervisor; later options win.
 //
 // ex:
-//   - goroutineBucketSize(10)
-//   - convertPanics(false)
-//   - logRunaways(os.Stderr, 2*time.Second)
-type SupervisionOptions func()
+//   - SetLogger(logger)
+//   - SetWarningHandler(fn)
+//   - RunawayThreshold(2*time.Second)
+type SupervisionOptions func(*supervision)
+
+// RunawayThreshold sets how long a supervisor waits for its children to
+// return after cancelling them before it emits a slow-cancel warning.
+// The default is two seconds.  Zero disables the warning.
+func RunawayThreshold(d time.Duration) SupervisionOptions {
+	return func(cfg *supervision) {
+		cfg.runawayThreshold = d
+	}
+}
+
+// DisablePprofLabels stops the supervisor from setting pprof labels on its
+// children.  By default, each child runs with a "sup_task" label set to its
+// task path, so CPU profiles and goroutine dumps can be filtered by task.
+// Setting the labels costs a little for each task launched, which may
+// matter for supervisors running very many tiny tasks.
+//
+// It only affects this supervisor's own children: a supervisor among them
+// sets labels for its own children, unless it's given this option too.
+// (The labels of a task with labels turned off are still those of its
+// supervisor, since pprof labels are inherited by new goroutines.)
+func DisablePprofLabels() SupervisionOptions {
+	return func(cfg *supervision) {
+		cfg.noPprofLabels = true
+	}
+}
+
+// ExposeTaskPath makes the supervisor attach each child's task path to the
+// child's context under TaskPathContextKey, as well as in the usual way, so
+// code which doesn't import this package can find it.  It's off by
+// default, since it's a second context value for every task.
+//
+// Like DisablePprofLabels, it only affects this supervisor's own children.
+func ExposeTaskPath() SupervisionOptions {
+	return func(cfg *supervision) {
+		cfg.exposeTaskPath = true
+	}
+}
@@ -0,0 +1,127 @@
+package sup_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/warpfork/go-sup"
+)
+
+func TestExitReason(t *testing.T) {
+	t.Run("drained", func(t *testing.T) {
+		svr := sup.SuperviseForkJoin("main", sup.TaskFromFunc(func(context.Context) error { return nil }))
+		shouldEqual(t, svr.ExitReason(), sup.ExitReason_notFinished)
+		shouldEqual(t, svr.Run(context.Background()), nil)
+		shouldEqual(t, svr.ExitReason(), sup.ExitReason_drained)
+	})
+	t.Run("parent cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		svr := sup.SuperviseForkJoin("main", sup.TaskFromFunc(func(ctx context.Context) error {
+			cancel()
+			<-ctx.Done()
+			return ctx.Err()
+		}))
+		shouldEqual(t, svr.Run(ctx), context.Canceled)
+		shouldEqual(t, svr.ExitReason(), sup.ExitReason_parentCancelled)
+	})
+	t.Run("child error, even if the error is a cancel", func(t *testing.T) {
+		svr := sup.SuperviseStream("main", sup.TaskGenFromTasks(sup.TaskFromFunc(func(ctx context.Context) error {
+			return context.Canceled
+		})))
+		err := svr.Run(context.Background())
+		shouldEqual(t, errors.Is(unwrapChild(err), context.Canceled), true)
+		shouldEqual(t, svr.ExitReason(), sup.ExitReason_childError)
+	})
+}
+
+func TestAwait(t *testing.T) {
+	t.Run("before and after run", func(t *testing.T) {
+		boom := errors.New("boom")
+		release := make(chan struct{})
+		svr := sup.SuperviseForkJoin("main", sup.TaskFromFunc(func(context.Context) error {
+			<-release
+			return boom
+		}))
+		awaited := make(chan error, 1)
+		go func() { awaited <- svr.Await(context.Background()) }()
+		ranErr := make(chan error, 1)
+		go func() { ranErr <- svr.Run(context.Background()) }()
+		close(release)
+		err := <-awaited
+		shouldEqual(t, err, <-ranErr)
+		shouldEqual(t, svr.Await(context.Background()), err) // again, now that it's halted.
+	})
+	t.Run("context done first", func(t *testing.T) {
+		svr := sup.SuperviseForkJoin("main", sup.TaskFromFunc(func(context.Context) error { return nil }))
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		shouldEqual(t, svr.Await(ctx), context.Canceled)
+	})
+}
+
+func TestSuperviseForkJoinLimited(t *testing.T) {
+	t.Run("no more than the limit should run at once, and all should run", func(t *testing.T) {
+		var running, most, ran atomic.Int64
+		tasks := make([]sup.Task, 20)
+		for i := range tasks {
+			tasks[i] = namedFunc{fmt.Sprintf("t%d", i), func(ctx context.Context) error {
+				n := running.Add(1)
+				for m := most.Load(); n > m && !most.CompareAndSwap(m, n); m = most.Load() {
+				}
+				time.Sleep(time.Millisecond)
+				running.Add(-1)
+				ran.Add(1)
+				return nil
+			}}
+		}
+		svr := sup.SuperviseForkJoinLimited("limited", 3, tasks)
+		shouldEqual(t, sup.SuperviseRoot(context.Background(), svr), nil)
+		shouldEqual(t, ran.Load(), int64(20))
+		shouldEqual(t, most.Load() <= 3, true)
+		shouldEqual(t, svr.ExitReason(), sup.ExitReason_drained)
+	})
+	t.Run("an error should stop the rest being started", func(t *testing.T) {
+		var mu sync.Mutex
+		var started []string
+		var warnings []sup.SupervisionWarning
+		tasks := make([]sup.Task, 10)
+		for i := range tasks {
+			name := fmt.Sprintf("t%d", i)
+			tasks[i] = namedFunc{name, func(ctx context.Context) error {
+				mu.Lock()
+				started = append(started, name)
+				mu.Unlock()
+				if name == "t1" {
+					return errors.New("fail")
+				}
+				<-ctx.Done()
+				return ctx.Err()
+			}}
+		}
+		svr := sup.SuperviseForkJoinLimited("limited", 2, tasks,
+			sup.SetWarningHandler(func(w sup.SupervisionWarning) {
+				mu.Lock()
+				defer mu.Unlock()
+				warnings = append(warnings, w)
+			}))
+		err := sup.SuperviseRoot(context.Background(), svr)
+		shouldEqual(t, fmt.Sprint(err), "fail")
+		sort.Strings(started)
+		shouldEqual(t, fmt.Sprint(started), "[t0 t1]")
+		mustEqual(t, len(warnings), 1)
+		shouldEqual(t, warnings[0].Kind, sup.WarningKind_unlaunched)
+		shouldEqual(t, warnings[0].Message, "8 tasks were never launched because the supervisor is winding down")
+	})
+	t.Run("a limit which isn't positive should panic", func(t *testing.T) {
+		defer func() {
+			shouldEqual(t, recover(), "usage: a fork-join supervisor's limit must be positive")
+		}()
+		sup.SuperviseForkJoinLimited("limited", 0, nil)
+	})
+}
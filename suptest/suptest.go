@@ -0,0 +1,56 @@
+// Package suptest contains helpers for testing code that uses go-sup.
+//
+// They're mostly about promises: asserting that something resolves in
+// reasonable time, or that it doesn't -- which otherwise ends up being
+// a hand-rolled select-with-timeout in every test suite.
+package suptest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/warpfork/go-sup"
+)
+
+// RequireResolved waits up to the given duration for the promise to be
+// resolved, and returns its value (which may be nil, if that's what it was
+// resolved with).  If the promise isn't resolved in time, or is cancelled,
+// the test is failed immediately (with t.Fatalf).
+//
+// If the promise is an InstrumentedPromise, the failure message includes
+// its age, which is often a helpful hint about where it came from.
+func RequireResolved(t testing.TB, p sup.Promise, within time.Duration) interface{} {
+	t.Helper()
+	if !p.AwaitTimeout(within) {
+		t.Fatalf("promise not resolved within %v%s", within, age(p))
+	}
+	// (Not GetNow: it can't tell a nil value from no value yet.)
+	v, err := p.AwaitValue(context.Background())
+	if err != nil {
+		t.Fatalf("promise settled without a value: %v%s", err, age(p))
+	}
+	return v
+}
+
+// RequireNotResolved waits for the given duration, and fails the test
+// immediately (with t.Fatalf) if the promise is resolved (or cancelled)
+// before that time is up.
+//
+// Naturally, this always takes the whole duration when it succeeds,
+// so keep it short.
+func RequireNotResolved(t testing.TB, p sup.Promise, within time.Duration) {
+	t.Helper()
+	if p.AwaitTimeout(within) {
+		v, err := p.AwaitValue(context.Background())
+		t.Fatalf("promise should not have been resolved, but was (value: %v, error: %v)%s", v, err, age(p))
+	}
+}
+
+func age(p sup.Promise) string {
+	ip, ok := p.(sup.InstrumentedPromise)
+	if !ok {
+		return ""
+	}
+	return " (promise age: " + ip.Age().String() + ")"
+}
@@ -0,0 +1,87 @@
+package suptest_test
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/warpfork/go-sup"
+	"github.com/warpfork/go-sup/suptest"
+)
+
+// fakeT records a fatal failure instead of failing the real test.
+// (Embedding testing.TB satisfies its unexported method; only the
+// methods the helpers use are implemented.)
+type fakeT struct {
+	testing.TB
+	failed string
+}
+
+func (t *fakeT) Helper() {}
+func (t *fakeT) Fatalf(format string, args ...interface{}) {
+	t.failed = fmt.Sprintf(format, args...)
+	runtime.Goexit()
+}
+
+// run calls fn with a fakeT on a fresh goroutine (so Goexit is safe),
+// and returns the failure message, if any.
+func run(fn func(t testing.TB)) string {
+	ft := &fakeT{}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		fn(ft)
+	}()
+	<-done
+	return ft.failed
+}
+
+func TestRequireResolved(t *testing.T) {
+	t.Run("resolved", func(t *testing.T) {
+		p := sup.NewPromise()
+		go p.Resolve(4)
+		if v := suptest.RequireResolved(t, p, time.Second); v != 4 {
+			t.Errorf("got %v, want 4", v)
+		}
+	})
+	t.Run("resolved with nil", func(t *testing.T) {
+		p := sup.NewPromise()
+		p.Resolve(nil)
+		var v interface{} = "unset"
+		msg := run(func(t testing.TB) { v = suptest.RequireResolved(t, p, time.Second) })
+		if msg != "" || v != nil {
+			t.Errorf("got %v (failure message %q), want nil", v, msg)
+		}
+	})
+	t.Run("timeout", func(t *testing.T) {
+		p := sup.NewInstrumentedPromise()
+		msg := run(func(t testing.TB) { suptest.RequireResolved(t, p, time.Millisecond) })
+		if !strings.HasPrefix(msg, "promise not resolved within 1ms") || !strings.Contains(msg, "promise age:") {
+			t.Errorf("unexpected failure message: %q", msg)
+		}
+	})
+	t.Run("cancelled", func(t *testing.T) {
+		p := sup.NewPromise()
+		p.Cancel()
+		msg := run(func(t testing.TB) { suptest.RequireResolved(t, p, time.Second) })
+		if msg != "promise settled without a value: context canceled" {
+			t.Errorf("unexpected failure message: %q", msg)
+		}
+	})
+}
+
+func TestRequireNotResolved(t *testing.T) {
+	t.Run("unresolved", func(t *testing.T) {
+		suptest.RequireNotResolved(t, sup.NewPromise(), time.Millisecond)
+	})
+	t.Run("resolved", func(t *testing.T) {
+		p := sup.NewPromise()
+		p.Resolve(4)
+		msg := run(func(t testing.TB) { suptest.RequireNotResolved(t, p, time.Second) })
+		if msg != "promise should not have been resolved, but was (value: 4, error: <nil>)" {
+			t.Errorf("unexpected failure message: %q", msg)
+		}
+	})
+}
@@ -0,0 +1,92 @@
+package sup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// WithTaskDeadline is context.WithTimeout, for a deadline on the whole of
+// the current task, with the supervisor keeping an eye on it.  If the
+// deadline passes, and the task still hasn't returned after the
+// supervisor's runaway threshold (see RunawayThreshold; two seconds, by
+// default), a deadline warning naming the task goes to the supervisor's
+// warning handler.  So a task which mishandles its deadline (by ignoring
+// the context, say) is noticed, rather than just running late.
+//
+// If the context doesn't belong to a supervised task, or the runaway
+// threshold is zero, it's just context.WithTimeout.
+func WithTaskDeadline(ctx Context, d time.Duration) (Context, context.CancelFunc) {
+	deadlineCtx, cancel := context.WithTimeout(ctx, d)
+	info, _ := ctx.Value(ctxKey{}).(ctxInfo)
+	if info.exited == nil || info.cfg.runawayThreshold <= 0 {
+		return deadlineCtx, cancel
+	}
+	grace := info.cfg.runawayThreshold
+	stop := context.AfterFunc(deadlineCtx, func() {
+		if !errors.Is(deadlineCtx.Err(), context.DeadlineExceeded) {
+			return // cancelled, not expired.
+		}
+		timer := time.NewTimer(grace)
+		defer timer.Stop()
+		select {
+		case <-info.exited:
+		case <-timer.C:
+			info.warn(WarningKind_deadline, fmt.Sprintf("task has not returned %v after its deadline of %v passed", grace, d))
+		}
+	})
+	return deadlineCtx, func() {
+		stop()
+		cancel()
+	}
+}
+
+// WithinTime wraps a task so that each Run has a deadline, the given
+// duration after it starts (set with WithTaskDeadline, so a task which
+// overruns it gets a deadline warning from its supervisor).  If the task
+// returns context.DeadlineExceeded because its deadline passed, the wrapper
+// returns an ErrTaskTimeout instead, so it can be told apart from the
+// task's supervisor cancelling it, or a deadline of the parent's.
+//
+// The wrapper always waits for the task to return, even if it ignores the
+// deadline: returning early would leave it running unsupervised.
+//
+// If the task is a NamedTask, the wrapper is too, with the same name.
+func WithinTime(d time.Duration, t Task) Task {
+	return keepName(t, withinTimeTask{d, t})
+}
+
+// ErrTaskTimeout is the error returned by a task wrapped by WithinTime
+// which didn't finish in time.  It unwraps to the task's own error.
+type ErrTaskTimeout struct {
+	TaskName string        // the task's name, as seen by CtxTaskName, if it's supervised.
+	Budget   time.Duration // the time the task had.
+	Err      error         // the error the task returned.
+}
+
+func (e ErrTaskTimeout) Error() string {
+	if e.TaskName == "" {
+		return fmt.Sprintf("task timed out after %v", e.Budget)
+	}
+	return fmt.Sprintf("task %q timed out after %v", e.TaskName, e.Budget)
+}
+
+func (e ErrTaskTimeout) Unwrap() error {
+	return e.Err
+}
+
+type withinTimeTask struct {
+	budget time.Duration
+	task   Task
+}
+
+func (t withinTimeTask) Run(ctx context.Context) error {
+	deadlineCtx, cancel := WithTaskDeadline(ctx, t.budget)
+	defer cancel()
+	err := t.task.Run(deadlineCtx)
+	if errors.Is(err, context.DeadlineExceeded) && errors.Is(deadlineCtx.Err(), context.DeadlineExceeded) && ctx.Err() == nil {
+		return ErrTaskTimeout{CtxTaskName(ctx), t.budget, err}
+	}
+	return err
+}
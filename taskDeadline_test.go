@@ -0,0 +1,114 @@
+package sup_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/warpfork/go-sup"
+)
+
+func TestWithTaskDeadline(t *testing.T) {
+	run := func(fn func(ctx context.Context) error) []sup.SupervisionWarning {
+		// The warning comes from a timer goroutine, hence the channel.
+		warnings := make(chan sup.SupervisionWarning, 10)
+		sup.SuperviseRoot(context.Background(),
+			sup.SuperviseForkJoin("main", []sup.Task{namedFunc{"worker", fn}},
+				sup.RunawayThreshold(5*time.Millisecond),
+				sup.SetWarningHandler(func(w sup.SupervisionWarning) { warnings <- w }),
+			),
+		)
+		var ws []sup.SupervisionWarning
+		for {
+			select {
+			case w := <-warnings:
+				ws = append(ws, w)
+			default:
+				return ws
+			}
+		}
+	}
+	t.Run("a task that ignores its deadline should be warned about", func(t *testing.T) {
+		warnings := run(func(ctx context.Context) error {
+			_, cancel := sup.WithTaskDeadline(ctx, time.Millisecond)
+			defer cancel()
+			time.Sleep(30 * time.Millisecond)
+			return nil
+		})
+		mustEqual(t, len(warnings), 1)
+		shouldEqual(t, warnings[0].Kind, sup.WarningKind_deadline)
+		shouldEqual(t, warnings[0].TaskPath, "main/worker")
+		shouldEqual(t, warnings[0].Message, "task has not returned 5ms after its deadline of 1ms passed")
+	})
+	t.Run("a task that minds its deadline should not", func(t *testing.T) {
+		warnings := run(func(ctx context.Context) error {
+			ctx, cancel := sup.WithTaskDeadline(ctx, time.Millisecond)
+			defer cancel()
+			<-ctx.Done()
+			return nil
+		})
+		shouldEqual(t, len(warnings), 0)
+	})
+	t.Run("a task that finishes early should not", func(t *testing.T) {
+		warnings := run(func(ctx context.Context) error {
+			_, cancel := sup.WithTaskDeadline(ctx, time.Millisecond)
+			cancel()
+			time.Sleep(30 * time.Millisecond)
+			return nil
+		})
+		shouldEqual(t, len(warnings), 0)
+	})
+}
+
+func TestWithinTime(t *testing.T) {
+	run := func(task sup.Task) (error, []sup.SupervisionWarning) {
+		warnings := make(chan sup.SupervisionWarning, 10)
+		err := sup.SuperviseRoot(context.Background(),
+			sup.SuperviseForkJoin("main", []sup.Task{task},
+				sup.RunawayThreshold(5*time.Millisecond),
+				sup.SetWarningHandler(func(w sup.SupervisionWarning) { warnings <- w }),
+			),
+		)
+		var ws []sup.SupervisionWarning
+		for {
+			select {
+			case w := <-warnings:
+				ws = append(ws, w)
+			default:
+				return err, ws
+			}
+		}
+	}
+	t.Run("a task which minds its deadline should time out", func(t *testing.T) {
+		err, warnings := run(sup.WithinTime(time.Millisecond, namedFunc{"worker", func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		}}))
+		shouldEqual(t, fmt.Sprint(err), `task "worker" timed out after 1ms`)
+		var timeout sup.ErrTaskTimeout
+		shouldEqual(t, errors.As(unwrapChild(err), &timeout), true)
+		shouldEqual(t, errors.Is(unwrapChild(err), context.DeadlineExceeded), true)
+		shouldEqual(t, len(warnings), 0)
+	})
+	t.Run("a task which finishes in time should be unaffected", func(t *testing.T) {
+		err, warnings := run(sup.WithinTime(time.Hour, namedFunc{"worker", func(ctx context.Context) error {
+			return nil
+		}}))
+		shouldEqual(t, err, nil)
+		shouldEqual(t, len(warnings), 0)
+	})
+	t.Run("a task which ignores its deadline should be waited for, and warned about", func(t *testing.T) {
+		start := time.Now()
+		err, warnings := run(sup.WithinTime(time.Millisecond, namedFunc{"worker", func(ctx context.Context) error {
+			time.Sleep(30 * time.Millisecond)
+			return nil
+		}}))
+		shouldEqual(t, err, nil)
+		shouldEqual(t, time.Since(start) >= 30*time.Millisecond, true)
+		mustEqual(t, len(warnings), 1)
+		shouldEqual(t, warnings[0].Kind, sup.WarningKind_deadline)
+		shouldEqual(t, warnings[0].TaskPath, "main/worker")
+	})
+}
@@ -2,13 +2,55 @@ package sup
 
 import (
 	"context"
+	"fmt"
+	"iter"
 	"reflect"
+	"runtime"
+	"strconv"
+	"strings"
 )
 
+// TaskFromFunc returns a task which calls fn, in a slice of one, for
+// passing straight to SuperviseForkJoin.
+//
+// The task is anonymous, and so named for its address, unless fn is a
+// method value (like myServer.serve), in which case it's named for the
+// method and its receiver's type: "myServer.serve".  Use NamedFunc to give
+// it a name of your choosing.
 func TaskFromFunc(fn func(ctx context.Context) error) []Task {
+	if name := methodValueName(fn); name != "" {
+		return []Task{namedFnTask{name, fn}}
+	}
 	return []Task{fnTask{fn}}
 }
 
+// NamedFunc returns a task which calls fn, and has the given name, wherever
+// it's run.
+func NamedFunc(name string, fn func(ctx Context) error) NamedTask {
+	return namedFnTask{name, fn}
+}
+
+// methodValueName returns "Type.Method" if fn is a method value, or the
+// empty string if it isn't (or if we can't tell).
+func methodValueName(fn any) string {
+	f := runtime.FuncForPC(reflect.ValueOf(fn).Pointer())
+	if f == nil {
+		return ""
+	}
+	// Method values are compiled to wrappers named like "path/to/pkg.(*Type).Method-fm".
+	name, ok := strings.CutSuffix(f.Name(), "-fm")
+	if !ok {
+		return ""
+	}
+	parts := strings.Split(name[strings.LastIndexByte(name, '/')+1:], ".")
+	if len(parts) < 3 {
+		return ""
+	}
+	// The last two are the type and the method; anything before them is the
+	// package name (which may have dots in it, like "yaml.v3").
+	return strings.NewReplacer("(*", "", ")", "").Replace(strings.Join(parts[len(parts)-2:], "."))
+}
+
 type fnTask struct {
 	fn func(ctx context.Context) error
 }
@@ -54,6 +96,45 @@ func (t mapEntryTask) Run(ctx context.Context) error {
 	return t.fn(ctx, t.k, t.v)
 }
 
+// TasksFor returns a task for each of the items, which calls fn with it.
+// Each task is named for its item's index in the slice: "0", "1", and so on.
+func TasksFor[T any](items []T, fn func(Context, T) error) []Task {
+	tasks := make([]Task, len(items))
+	for i, item := range items {
+		tasks[i] = namedFnTask{strconv.Itoa(i), func(ctx context.Context) error {
+			return fn(ctx, item)
+		}}
+	}
+	return tasks
+}
+
+// TasksForMap returns a task for each of the entries in the map, which calls
+// fn with the entry's key and value.  Each task is named for its key, as
+// formatted by fmt.Sprint.  (A "%" in a key is taken as a placeholder, as in
+// any task name; see NameStrategy.)
+func TasksForMap[K comparable, V any](m map[K]V, fn func(Context, K, V) error) []Task {
+	tasks := make([]Task, 0, len(m))
+	for k, v := range m {
+		tasks = append(tasks, namedFnTask{fmt.Sprint(k), func(ctx context.Context) error {
+			return fn(ctx, k, v)
+		}})
+	}
+	return tasks
+}
+
+type namedFnTask struct {
+	name string
+	fn   func(ctx context.Context) error
+}
+
+func (t namedFnTask) Name() string {
+	return t.name
+}
+
+func (t namedFnTask) Run(ctx context.Context) error {
+	return t.fn(ctx)
+}
+
 // TaskGen is a channel which yields tasks until closed.
 // A TaskGen channel is used to feed work into a supervisor that runs
 // unbounded numbers of tasks (it's not useful for SupervisorForkJoin,
@@ -75,3 +156,54 @@ func TaskGenFromTasks(tasks []Task) TaskGen {
 	close(ch)
 	return ch
 }
+
+// TaskGenFromSeq returns a TaskGen with a task for each of the items the
+// sequence yields, which calls fn with it.  Each task is named for its
+// item's position in the sequence: "0", "1", and so on.
+//
+// Items are pulled from the sequence only as the supervisor takes tasks,
+// one at a time, so a sequence reading from a database cursor (say) isn't
+// read into memory all at once.  The TaskGen is closed when the sequence
+// ends, or when ctx is done (the sequence is stopped then, too): so cancel
+// ctx if the supervisor stops before the sequence ends, or the goroutine
+// pulling from it waits forever.
+func TaskGenFromSeq[T any](ctx Context, seq iter.Seq[T], fn func(Context, T) error) TaskGen {
+	ch := make(chan Task)
+	go func() {
+		defer close(ch)
+		i := 0
+		for item := range seq {
+			task := namedFnTask{strconv.Itoa(i), func(ctx context.Context) error {
+				return fn(ctx, item)
+			}}
+			select {
+			case ch <- task:
+			case <-ctx.Done():
+				return
+			}
+			i++
+		}
+	}()
+	return ch
+}
+
+// TaskGenFromSeq2 is TaskGenFromSeq, for a sequence of pairs, like the keys
+// and values of a map.  Each task is named for its key, as formatted by
+// fmt.Sprint, just as with TasksForMap.
+func TaskGenFromSeq2[K, V any](ctx Context, seq iter.Seq2[K, V], fn func(Context, K, V) error) TaskGen {
+	ch := make(chan Task)
+	go func() {
+		defer close(ch)
+		for k, v := range seq {
+			task := namedFnTask{fmt.Sprint(k), func(ctx context.Context) error {
+				return fn(ctx, k, v)
+			}}
+			select {
+			case ch <- task:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return ch
+}
@@ -0,0 +1,132 @@
+package sup_test
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/warpfork/go-sup"
+)
+
+func TestTasksFor(t *testing.T) {
+	var mu sync.Mutex
+	got := map[string]string{}
+	svr := sup.SuperviseForkJoin("main", sup.TasksFor([]string{"a", "b", "c"}, func(ctx context.Context, item string) error {
+		mu.Lock()
+		defer mu.Unlock()
+		got[sup.CtxTaskName(ctx)] = item
+		return nil
+	}))
+	mustEqual(t, sup.SuperviseRoot(context.Background(), svr), nil)
+	shouldEqual(t, fmt.Sprint(got), "map[0:a 1:b 2:c]")
+}
+
+func TestTasksForMap(t *testing.T) {
+	var mu sync.Mutex
+	var got []string
+	svr := sup.SuperviseForkJoin("main", sup.TasksForMap(map[int]bool{1: true, 2: false}, func(ctx context.Context, k int, v bool) error {
+		mu.Lock()
+		defer mu.Unlock()
+		got = append(got, fmt.Sprintf("%s=%d:%v", sup.CtxTaskName(ctx), k, v))
+		return nil
+	}))
+	mustEqual(t, sup.SuperviseRoot(context.Background(), svr), nil)
+	sort.Strings(got)
+	shouldEqual(t, fmt.Sprint(got), "[1=1:true 2=2:false]")
+}
+
+func TestTaskGenFromSeq(t *testing.T) {
+	t.Run("all items should become tasks", func(t *testing.T) {
+		var mu sync.Mutex
+		var got []string
+		ctx := context.Background()
+		taskGen := sup.TaskGenFromSeq(ctx, slices.Values([]string{"a", "b", "c"}), func(ctx context.Context, item string) error {
+			mu.Lock()
+			defer mu.Unlock()
+			got = append(got, sup.CtxTaskName(ctx)+"="+item)
+			return nil
+		})
+		mustEqual(t, sup.SuperviseRoot(ctx, sup.SuperviseStream("main", taskGen)), nil)
+		sort.Strings(got)
+		shouldEqual(t, fmt.Sprint(got), "[0=a 1=b 2=c]")
+	})
+	t.Run("items should be pulled lazily", func(t *testing.T) {
+		pulled := 0
+		seq := func(yield func(int) bool) {
+			for i := 0; ; i++ {
+				pulled++
+				if !yield(i) {
+					return
+				}
+			}
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		taskGen := sup.TaskGenFromSeq(ctx, seq, func(context.Context, int) error { return nil })
+		for i := 0; i < 3; i++ {
+			<-taskGen
+		}
+		cancel()
+		for range taskGen {
+			// Drain, so we know the pulling goroutine has finished.
+		}
+		shouldEqual(t, pulled <= 4, true)
+	})
+	t.Run("pairs should become tasks named by key", func(t *testing.T) {
+		var mu sync.Mutex
+		var got []string
+		ctx := context.Background()
+		taskGen := sup.TaskGenFromSeq2(ctx, maps.All(map[string]int{"x": 1, "y": 2}), func(ctx context.Context, k string, v int) error {
+			mu.Lock()
+			defer mu.Unlock()
+			got = append(got, fmt.Sprintf("%s=%s:%d", sup.CtxTaskName(ctx), k, v))
+			return nil
+		})
+		mustEqual(t, sup.SuperviseRoot(ctx, sup.SuperviseStream("main", taskGen)), nil)
+		sort.Strings(got)
+		shouldEqual(t, fmt.Sprint(got), "[x=x:1 y=y:2]")
+	})
+}
+
+func TestNamedFunc(t *testing.T) {
+	record := func(names chan<- string) func(context.Context) error {
+		return func(ctx context.Context) error { names <- sup.CtxTaskName(ctx); return nil }
+	}
+	t.Run("fork-join", func(t *testing.T) {
+		names := make(chan string, 1)
+		svr := sup.SuperviseForkJoin("main", []sup.Task{sup.NamedFunc("fetcher", record(names))})
+		mustEqual(t, sup.SuperviseRoot(context.Background(), svr), nil)
+		shouldEqual(t, <-names, "fetcher")
+	})
+	t.Run("stream", func(t *testing.T) {
+		names := make(chan string, 1)
+		svr := sup.SuperviseStream("main", sup.TaskGenFromTasks([]sup.Task{sup.NamedFunc("fetcher", record(names))}))
+		mustEqual(t, sup.SuperviseRoot(context.Background(), svr), nil)
+		shouldEqual(t, <-names, "fetcher")
+	})
+}
+
+type methodHaver struct {
+	names chan string
+}
+
+func (m *methodHaver) serve(ctx context.Context) error {
+	m.names <- sup.CtxTaskName(ctx)
+	return nil
+}
+
+func TestTaskFromFuncNaming(t *testing.T) {
+	t.Run("method values should be named for their method", func(t *testing.T) {
+		m := &methodHaver{make(chan string, 1)}
+		svr := sup.SuperviseForkJoin("main", sup.TaskFromFunc(m.serve))
+		mustEqual(t, sup.SuperviseRoot(context.Background(), svr), nil)
+		shouldEqual(t, <-m.names, "methodHaver.serve")
+	})
+	t.Run("other funcs should stay anonymous", func(t *testing.T) {
+		_, named := sup.TaskFromFunc(func(context.Context) error { return nil })[0].(sup.NamedTask)
+		shouldEqual(t, named, false)
+	})
+}
@@ -8,12 +8,15 @@ import (
 // supplied to a supervisor is converted into a boundTask immediately,
 // and properties like name will be determined at this time and kept in
 // the boundTask struct (so that we have no question as to their immutability).
+// (The one exception: a name with "%" placeholders is filled in by the
+// supervisor when it launches the task, before the task's goroutine starts.)
 //
 // boundTask should always be seen as a pointer.  We use the uniqueness of the
 // address as a key for many internal bookkeeping operations.
 type boundTask struct {
-	original Task
-	name     string
+	original  Task
+	name      string
+	requested string // the name as it was asked for, before placeholders were filled in.
 }
 
 func bindTask(original Task) *boundTask {
@@ -26,10 +29,16 @@ func bindTask(original Task) *boundTask {
 	default:
 		t.name = fmt.Sprintf("%p", t)
 	}
+	t.requested = t.name
 
 	return t
 }
 
+// info describes the task, as a child of the supervisor with the given path.
+func (t *boundTask) info(supervisorPath string) TaskInfo {
+	return TaskInfo{t.name, t.requested, joinTaskPath(supervisorPath, t.name), t.original}
+}
+
 func bindTasks(original []Task) []*boundTask {
 	v := make([]*boundTask, len(original))
 	for i, o := range original {
@@ -0,0 +1,90 @@
+package sup
+
+import (
+	"strings"
+)
+
+// TaskPathSeparator separates the names of tasks in a task path (see
+// CtxTaskPath), as in "root/pool/worker-7".
+//
+// A task path is a logical name, not a file path: it's always a slash,
+// whatever the platform.  If a task's name itself contains a slash, it's
+// escaped with a backslash in the path ("\/"), as are backslashes ("\\"),
+// so the path can still be split back into the names it was made from.
+// Use SplitTaskPath, ParentPath, and TaskLeafName to take paths apart,
+// rather than splitting them on slashes yourself.
+const TaskPathSeparator = "/"
+
+// joinTaskPath returns the path of a task with the given name, under the
+// given parent path.
+func joinTaskPath(parent, name string) string {
+	if parent == "" {
+		return escapeTaskName(name)
+	}
+	return parent + TaskPathSeparator + escapeTaskName(name)
+}
+
+var taskNameEscaper = strings.NewReplacer(`\`, `\\`, TaskPathSeparator, `\`+TaskPathSeparator)
+
+func escapeTaskName(name string) string {
+	return taskNameEscaper.Replace(name)
+}
+
+// SplitTaskPath splits a task path into the names of the tasks along it,
+// from the root down, undoing any escaping (see TaskPathSeparator).  An
+// empty path gives no names at all.
+func SplitTaskPath(path string) []string {
+	if path == "" {
+		return nil
+	}
+	var names []string
+	var sb strings.Builder
+	for i := 0; i < len(path); i++ {
+		switch {
+		case path[i] == '\\' && i+1 < len(path):
+			i++
+			sb.WriteByte(path[i])
+		case path[i] == TaskPathSeparator[0]:
+			names = append(names, sb.String())
+			sb.Reset()
+		default:
+			sb.WriteByte(path[i])
+		}
+	}
+	return append(names, sb.String())
+}
+
+// ParentPath returns the path of the parent of the task with the given
+// path: the path of the supervisor which launched it.  For a task at the
+// root, with no parent, it returns the empty string.
+func ParentPath(path string) string {
+	if i := lastTaskPathSeparator(path); i >= 0 {
+		return path[:i]
+	}
+	return ""
+}
+
+// TaskLeafName returns the name of the task with the given path: the last
+// name in the path, with any escaping undone.
+func TaskLeafName(path string) string {
+	leaf := path[lastTaskPathSeparator(path)+1:]
+	if names := SplitTaskPath(leaf); len(names) == 1 {
+		return names[0]
+	}
+	return ""
+}
+
+// lastTaskPathSeparator returns the index of the last separator in the
+// path which isn't escaped, or -1 if there's none.
+func lastTaskPathSeparator(path string) int {
+	last := -1
+	for i := 0; i < len(path); i++ {
+		switch path[i] {
+		case '\\':
+			i++
+		case TaskPathSeparator[0]:
+			last = i
+		}
+	}
+	return last
+}
@@ -0,0 +1,48 @@
+package sup_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/warpfork/go-sup"
+)
+
+func TestTaskPathParsing(t *testing.T) {
+	shouldEqual(t, fmt.Sprintf("%q", sup.SplitTaskPath("")), "[]")
+	shouldEqual(t, fmt.Sprintf("%q", sup.SplitTaskPath("root/pool/worker-7")), `["root" "pool" "worker-7"]`)
+	shouldEqual(t, sup.ParentPath("root/pool/worker-7"), "root/pool")
+	shouldEqual(t, sup.ParentPath("root"), "")
+	shouldEqual(t, sup.TaskLeafName("root/pool/worker-7"), "worker-7")
+	shouldEqual(t, sup.TaskLeafName("root"), "root")
+	shouldEqual(t, sup.TaskLeafName(""), "")
+}
+
+func TestTaskPathRoundTrip(t *testing.T) {
+	nasty := []string{
+		"a/b",
+		`back\slash`,
+		`trailing\`,
+		`\/`,
+		"/",
+		"//",
+		"",
+		"..",
+		"café/☕",
+	}
+	for _, name := range nasty {
+		t.Run(fmt.Sprintf("%q", name), func(t *testing.T) {
+			var path string
+			task := sup.SuperviseForkJoin("outer", []sup.Task{
+				namedFunc{name, func(ctx context.Context) error {
+					path = sup.CtxTaskPath(ctx)
+					return nil
+				}},
+			})
+			mustEqual(t, sup.SuperviseRoot(context.Background(), task), nil)
+			shouldEqual(t, fmt.Sprintf("%q", sup.SplitTaskPath(path)), fmt.Sprintf("%q", []string{"outer", name}))
+			shouldEqual(t, sup.ParentPath(path), "outer")
+			shouldEqual(t, sup.TaskLeafName(path), name)
+		})
+	}
+}
@@ -28,3 +28,24 @@ type NamedTask interface {
 	Task
 	Name() string
 }
+
+// keepName is for task wrappers: it returns the wrapper, made a NamedTask
+// with the inner task's name if the inner task is one, so that wrapping a
+// task doesn't lose its name.
+func keepName(inner, wrapper Task) Task {
+	if nt, ok := inner.(NamedTask); ok {
+		return namedWrapperTask{wrapper, nt.Name}
+	}
+	return wrapper
+}
+
+// namedWrapperTask is a Task named by something else: by the task it wraps
+// (see keepName), or by whatever it adapts.
+type namedWrapperTask struct {
+	Task
+	name func() string
+}
+
+func (t namedWrapperTask) Name() string {
+	return t.name()
+}
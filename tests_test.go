@@ -9,7 +9,7 @@ import (
 )
 
 func TestPanicCalming(t *testing.T) {
-	err := superviseStream{name: "groupname"}.init(TaskGenFromTasks(TaskFromFunc(func(_ context.Context) error {
+	err := superviseStream{superviseCommon: superviseCommon{name: "groupname"}}.init(TaskGenFromTasks(TaskFromFunc(func(_ context.Context) error {
 		panic(fmt.Errorf("foo"))
 	}))).Run(context.Background())
 	//Wish(t, err, ShouldEqual, &ErrChild{fmt.Errorf("foo"), true})
@@ -0,0 +1,148 @@
+package sup
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// SupervisionWarning describes something odd that a supervisor noticed
+// while running -- not an error (the supervisor keeps going), but the sort
+// of thing that usually points at a bug, and that you'll want in your logs.
+//
+// Warnings are delivered to the supervisor's warning handler.  By default
+// that's SlogWarningHandler using the logger set by SetLogger (or
+// slog.Default, if none was set); use SetWarningHandler to route them
+// elsewhere.
+type SupervisionWarning struct {
+	Kind           WarningKind
+	SupervisorPath string // path of the supervisor that noticed the problem.
+	TaskPath       string // path of the task concerned (may be empty, if the warning is about the supervisor as a whole).
+	Message        string // human-readable detail.
+	Stack          string // goroutine stack sample, for warnings where that's useful (otherwise empty).
+}
+
+type WarningKind uint8
+
+const (
+	WarningKind_slowCancel    = WarningKind(1)  // a child hasn't returned in a reasonable time after being cancelled.
+	WarningKind_nameCollision = WarningKind(2)  // two children of one supervisor are running under the same name, or a name strategy kept giving names in use.
+	WarningKind_unlaunched    = WarningKind(3)  // a supervisor wound down while there were still tasks waiting to be launched.
+	WarningKind_stuckCallback = WarningKind(4)  // a user-supplied callback didn't return before the callback watchdog expired.
+	WarningKind_overdue       = WarningKind(5)  // a Select has been blocked past the overdue deadline set on one of its cases.
+	WarningKind_dropped       = WarningKind(6)  // a message was dropped: in flight between channels at cancellation, or by an overflow policy.
+	WarningKind_detached      = WarningKind(7)  // a context made by Detach was still live when the supervisor of the task that made it finished.
+	WarningKind_deadline      = WarningKind(8)  // a task hasn't returned in a reasonable time after a deadline set with WithTaskDeadline passed.
+	WarningKind_unsupervised  = WarningKind(9)  // a context that doesn't belong to a supervised task was given to go-sup (only checked under StrictSupervision).
+	WarningKind_renamed       = WarningKind(10) // a task's name had unprintable characters, or was too long, and was changed (see SetMaxTaskNameLen).
+)
+
+func (k WarningKind) String() string {
+	switch k {
+	case WarningKind_slowCancel:
+		return "slow-cancel"
+	case WarningKind_nameCollision:
+		return "name-collision"
+	case WarningKind_unlaunched:
+		return "unlaunched"
+	case WarningKind_stuckCallback:
+		return "stuck-callback"
+	case WarningKind_overdue:
+		return "overdue"
+	case WarningKind_dropped:
+		return "dropped"
+	case WarningKind_detached:
+		return "detached"
+	case WarningKind_deadline:
+		return "deadline"
+	case WarningKind_unsupervised:
+		return "unsupervised"
+	case WarningKind_renamed:
+		return "renamed"
+	default:
+		return "unknown"
+	}
+}
+
+// level returns the slog level SlogWarningHandler uses for this kind.
+func (k WarningKind) level() slog.Level {
+	switch k {
+	case WarningKind_nameCollision, WarningKind_renamed:
+		return slog.LevelInfo
+	case WarningKind_unlaunched, WarningKind_stuckCallback:
+		return slog.LevelError
+	default:
+		return slog.LevelWarn
+	}
+}
+
+// SlogWarningHandler returns a warning handler which emits each warning as
+// a structured log record to the given logger.  A nil logger means
+// slog.Default (looked up each time a warning is emitted).
+//
+// The record's message is the warning's Message, and the kind, task path,
+// and supervisor path are attached as attributes (as is the stack sample,
+// if the warning has one).  Slow-cancel warnings are logged at warn level,
+// name collisions and renamed tasks at info, and tasks left unlaunched at winddown and stuck
+// callbacks at error level.
+//
+// This is the default warning handler.  It's exported so that you can
+// wrap it, e.g. to filter some warnings before passing the rest on.
+func SlogWarningHandler(logger *slog.Logger) func(SupervisionWarning) {
+	return func(w SupervisionWarning) {
+		l := logger
+		if l == nil {
+			l = slog.Default()
+		}
+		attrs := []slog.Attr{
+			slog.String("kind", w.Kind.String()),
+			slog.String("task", w.TaskPath),
+			slog.String("supervisor", w.SupervisorPath),
+		}
+		if w.Stack != "" {
+			attrs = append(attrs, slog.String("stack", w.Stack))
+		}
+		l.LogAttrs(context.Background(), w.Kind.level(), w.Message, attrs...)
+	}
+}
+
+// SetLogger sets the logger used by the default warning handler.
+// A nil logger means slog.Default.
+func SetLogger(logger *slog.Logger) SupervisionOptions {
+	return func(cfg *supervision) {
+		cfg.logger = logger
+	}
+}
+
+// SetWarningHandler replaces the supervisor's warning handler.
+// A nil handler restores the default (SlogWarningHandler).
+//
+// The handler is called on the supervisor's own goroutine,
+// so it should return promptly.  (If you can't be sure of that,
+// see CallbackWatchdog.)  Overdue warnings (see SetOverdueReaction) are the
+// exception: they come from the Select that's overdue, on a timer goroutine,
+// so if you use those, the handler must be safe for concurrent use.
+func SetWarningHandler(fn func(SupervisionWarning)) SupervisionOptions {
+	return func(cfg *supervision) {
+		cfg.warningHandler = fn
+	}
+}
+
+// CallbackWatchdog limits how long a supervisor will wait on callbacks you
+// gave it, such as the warning handler.  A callback that blocks stalls the
+// supervisor's whole run loop -- no child results are collected and no
+// cancellations are propagated while it's stuck.
+//
+// With a watchdog set, callbacks are run on their own goroutine, and if one
+// hasn't returned within the given duration, the supervisor logs a
+// stuck-callback warning (with a sample of all goroutine stacks) via
+// SlogWarningHandler and the supervisor's logger, then carries on without
+// waiting any longer.  The callback itself is not interrupted.
+//
+// The default is zero, which means callbacks are trusted, and called
+// directly on the supervisor's goroutine.
+func CallbackWatchdog(d time.Duration) SupervisionOptions {
+	return func(cfg *supervision) {
+		cfg.callbackWatchdog = d
+	}
+}
@@ -0,0 +1,153 @@
+package sup_test
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/warpfork/go-sup"
+)
+
+// captureHandler is a slog.Handler which remembers every record it's given.
+type captureHandler struct {
+	mu      sync.Mutex
+	records []slog.Record
+}
+
+func (h *captureHandler) Enabled(context.Context, slog.Level) bool { return true }
+func (h *captureHandler) WithAttrs([]slog.Attr) slog.Handler       { return h }
+func (h *captureHandler) WithGroup(string) slog.Handler            { return h }
+func (h *captureHandler) Handle(_ context.Context, r slog.Record) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.records = append(h.records, r)
+	return nil
+}
+
+func (h *captureHandler) Records() []slog.Record {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append([]slog.Record(nil), h.records...)
+}
+
+func recordAttrs(r slog.Record) map[string]string {
+	m := map[string]string{}
+	r.Attrs(func(a slog.Attr) bool {
+		m[a.Key] = a.Value.String()
+		return true
+	})
+	return m
+}
+
+func TestSlogWarningHandler(t *testing.T) {
+	for _, tr := range []struct {
+		kind  sup.WarningKind
+		level slog.Level
+	}{
+		{sup.WarningKind_slowCancel, slog.LevelWarn},
+		{sup.WarningKind_nameCollision, slog.LevelInfo},
+		{sup.WarningKind_unlaunched, slog.LevelError},
+	} {
+		t.Run(tr.kind.String(), func(t *testing.T) {
+			h := &captureHandler{}
+			sup.SlogWarningHandler(slog.New(h))(sup.SupervisionWarning{
+				Kind:           tr.kind,
+				SupervisorPath: "main",
+				TaskPath:       "main/one",
+				Message:        "hello",
+			})
+			records := h.Records()
+			mustEqual(t, len(records), 1)
+			shouldEqual(t, records[0].Level, tr.level)
+			shouldEqual(t, records[0].Message, "hello")
+			attrs := recordAttrs(records[0])
+			shouldEqual(t, attrs["kind"], tr.kind.String())
+			shouldEqual(t, attrs["task"], "main/one")
+			shouldEqual(t, attrs["supervisor"], "main")
+		})
+	}
+}
+
+func TestWarnings(t *testing.T) {
+	t.Run("slow cancel should be logged to the supervisor's logger", func(t *testing.T) {
+		h := &captureHandler{}
+		sup.SuperviseRoot(context.Background(),
+			sup.SuperviseForkJoin("main",
+				[]sup.Task{
+					namedFunc{"sluggard", func(ctx context.Context) error {
+						<-ctx.Done()
+						time.Sleep(50 * time.Millisecond)
+						return nil
+					}},
+					namedFunc{"fails", func(ctx context.Context) error {
+						return context.DeadlineExceeded
+					}},
+				},
+				sup.SetLogger(slog.New(h)),
+				sup.RunawayThreshold(time.Millisecond),
+			),
+		)
+		records := h.Records()
+		mustEqual(t, len(records), 1)
+		shouldEqual(t, records[0].Level, slog.LevelWarn)
+		shouldEqual(t, recordAttrs(records[0])["task"], "main/sluggard")
+	})
+	t.Run("name collisions should go to a custom handler", func(t *testing.T) {
+		var warnings []sup.SupervisionWarning
+		sup.SuperviseRoot(context.Background(),
+			sup.SuperviseForkJoin("main",
+				[]sup.Task{
+					namedFunc{"twin", func(ctx context.Context) error { <-ctx.Done(); return nil }},
+					namedFunc{"twin", func(ctx context.Context) error { <-ctx.Done(); return nil }},
+					namedFunc{"quitter", func(ctx context.Context) error { return context.Canceled }},
+				},
+				sup.SetWarningHandler(func(w sup.SupervisionWarning) {
+					warnings = append(warnings, w)
+				}),
+			),
+		)
+		mustEqual(t, len(warnings), 1)
+		shouldEqual(t, warnings[0].Kind, sup.WarningKind_nameCollision)
+		shouldEqual(t, warnings[0].TaskPath, "main/twin")
+	})
+	t.Run("a stuck warning handler should be abandoned by the watchdog", func(t *testing.T) {
+		h := &captureHandler{}
+		release := make(chan struct{})
+		defer close(release)
+		start := time.Now()
+		sup.SuperviseRoot(context.Background(),
+			sup.SuperviseForkJoin("main",
+				[]sup.Task{
+					namedFunc{"twin", func(ctx context.Context) error { return nil }},
+					namedFunc{"twin", func(ctx context.Context) error { return nil }},
+				},
+				sup.SetLogger(slog.New(h)),
+				sup.SetWarningHandler(func(sup.SupervisionWarning) { <-release }),
+				sup.CallbackWatchdog(10*time.Millisecond),
+			),
+		)
+		if elapsed := time.Since(start); elapsed > time.Second {
+			t.Errorf("supervisor took %v to return; should have abandoned the handler", elapsed)
+		}
+		records := h.Records()
+		mustEqual(t, len(records), 1)
+		shouldEqual(t, records[0].Level, slog.LevelError)
+		attrs := recordAttrs(records[0])
+		shouldEqual(t, attrs["kind"], "stuck-callback")
+		shouldEqual(t, attrs["supervisor"], "main")
+		if attrs["stack"] == "" {
+			t.Errorf("stuck-callback warning should carry a stack sample")
+		}
+	})
+}
+
+// namedFunc is a NamedTask made from a function, for tests.
+type namedFunc struct {
+	name string
+	fn   func(context.Context) error
+}
+
+func (t namedFunc) Name() string                  { return t.name }
+func (t namedFunc) Run(ctx context.Context) error { return t.fn(ctx) }
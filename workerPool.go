@@ -0,0 +1,112 @@
+package sup
+
+import (
+	"context"
+)
+
+// WorkerPool is a task which runs a fixed number of workers, each handling
+// items submitted to the pool, one at a time.
+//
+// The pool is a NamedTask: run it under a supervisor, and Submit items to
+// it from other tasks.  When it runs, it starts a fork-join supervisor of
+// its own, with a worker for each of the pool's workers, named "worker-1",
+// "worker-2", and so on (so their paths are under the pool's).  Each worker
+// is a loop (see RunSteps), taking an item from the pool's inbox and
+// calling handle with it.
+//
+// Close the pool to finish up: the workers handle whatever was already
+// submitted, and then return, and so does the pool's Run.  An error from
+// handle (or a panic) ends that worker, which makes the pool's supervisor
+// cancel the rest, and the pool returns the error, just as with any other
+// supervisor.  A soft stop ends the workers after the items they're on.
+type WorkerPool[T any] struct {
+	name    string
+	workers int
+	handle  func(Context, T) error
+	inboxTx SenderChannel[T]
+	inboxRx ReceiverChannel[T]
+	doneTx  SenderChannel[struct{}] // closed when Run returns, to turn Submit away (see Run).
+	doneRx  ReceiverChannel[struct{}]
+}
+
+// NewWorkerPool returns a WorkerPool with the given name and number of
+// workers, each of which calls handle for each item it takes.
+//
+// It panics if the number of workers isn't positive.
+func NewWorkerPool[T any](name string, workers int, handle func(Context, T) error) *WorkerPool[T] {
+	tx, rx := NewChannel[T](name+".inbox", 0)
+	return newWorkerPool(name, workers, tx, rx, handle)
+}
+
+// newWorkerPool is NewWorkerPool over a channel which already exists (as
+// with the pipes between a Pipeline's stages).
+func newWorkerPool[T any](name string, workers int, tx SenderChannel[T], rx ReceiverChannel[T], handle func(Context, T) error) *WorkerPool[T] {
+	if workers <= 0 {
+		panic("usage: a worker pool needs at least one worker")
+	}
+	doneTx, doneRx := NewChannel[struct{}](name+".done", 0)
+	return &WorkerPool[T]{name, workers, handle, tx, rx, doneTx, doneRx}
+}
+
+func (p *WorkerPool[T]) Name() string {
+	return p.name
+}
+
+// Submit hands an item to the pool.  It blocks until one of the workers has
+// taken it (so a busy pool pushes back on whoever is submitting), or the
+// context is done (then, it returns the context's error).  If the pool has
+// been closed, or its Run has returned, it returns an ErrChannelClosed.
+func (p *WorkerPool[T]) Submit(ctx Context, item T) error {
+	if StrictSupervision != StrictMode_off {
+		checkSupervised(ctx, "sup.WorkerPool.Submit")
+	}
+	var plan selectPlan
+	plan.init([]Selectable{
+		p.inboxTx.SendAndThen(item, nil),
+		p.doneRx.RecvAndThen(func(struct{}) error {
+			return ErrChannelClosed{p.inboxTx.Name()}
+		}),
+	})
+	return plan.run(ctx)
+}
+
+// Close stops the pool taking new items.  Its workers return once they've
+// handled the items already submitted.  Close may be called more than
+// once, from any goroutine.
+func (p *WorkerPool[T]) Close() {
+	p.inboxTx.Close()
+}
+
+// Run runs the pool's workers, under a supervisor of its own, until the
+// pool is closed and they've finished, or one of them fails, or the
+// context is done.  Once it's returned, however it did, Submit returns an
+// ErrChannelClosed rather than waiting for a worker which isn't coming.
+//
+// Unlike an Actor, the pool doesn't close its inbox for that.  Everything
+// sent to the pool goes through Submit, so Submit can watch for Run
+// returning instead, and a Submit blocked at that moment is woken cleanly.
+// Closing the inbox would wake it too (the send fails, and comes back as
+// an ErrChannelClosed, so that's safe), but the race detector reports such
+// a send racing the close, and the inbox may also be a Pipeline's pipe,
+// which belongs to the stage before.
+func (p *WorkerPool[T]) Run(ctx context.Context) error {
+	defer p.doneTx.Close()
+	tasks := make([]Task, p.workers)
+	for i := range tasks {
+		tasks[i] = NamedFunc("worker-%", p.work)
+	}
+	return SuperviseForkJoin(p.name, tasks, SetNameStrategy(SequentialNameStrategy())).Run(ctx)
+}
+
+func (p *WorkerPool[T]) work(ctx Context) error {
+	take := p.inboxRx.RecvOrClosed(func(item T, ok bool) error {
+		if !ok {
+			return ErrLoopDone
+		}
+		return p.handle(ctx, item)
+	})
+	stop := softStopCase(ctx)
+	return RunSteps(ctx, func(ctx Context) error {
+		return Select(ctx, take, stop)
+	})
+}
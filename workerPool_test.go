@@ -0,0 +1,102 @@
+package sup_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/warpfork/go-sup"
+)
+
+func TestWorkerPool(t *testing.T) {
+	t.Run("all submitted items should be handled, by all the workers", func(t *testing.T) {
+		var mu sync.Mutex
+		handled := map[int]bool{}
+		workers := map[string]bool{}
+		var ready sync.WaitGroup
+		ready.Add(3)
+		pool := sup.NewWorkerPool("pool", 3, func(ctx context.Context, n int) error {
+			mu.Lock()
+			handled[n] = true
+			first := !workers[sup.CtxTaskPath(ctx)]
+			workers[sup.CtxTaskPath(ctx)] = true
+			mu.Unlock()
+			if first {
+				ready.Done()
+				ready.Wait() // so that every worker gets an item.
+			}
+			return nil
+		})
+		svr := sup.SuperviseForkJoin("main", []sup.Task{
+			pool,
+			namedFunc{"producer", func(ctx context.Context) error {
+				defer pool.Close()
+				for i := 0; i < 20; i++ {
+					if err := pool.Submit(ctx, i); err != nil {
+						return err
+					}
+				}
+				return nil
+			}},
+		})
+		mustEqual(t, sup.SuperviseRoot(context.Background(), svr), nil)
+		shouldEqual(t, len(handled), 20)
+		var paths []string
+		for path := range workers {
+			paths = append(paths, path)
+		}
+		sort.Strings(paths)
+		shouldEqual(t, fmt.Sprint(paths), "[main/pool/worker-1 main/pool/worker-2 main/pool/worker-3]")
+	})
+	t.Run("submitting to a closed pool should fail", func(t *testing.T) {
+		pool := sup.NewWorkerPool("pool", 1, func(context.Context, int) error { return nil })
+		pool.Close()
+		pool.Close()
+		var closed sup.ErrChannelClosed
+		shouldEqual(t, errors.As(pool.Submit(context.Background(), 1), &closed), true)
+		shouldEqual(t, pool.Run(context.Background()), nil)
+	})
+	t.Run("submitting should honor the context", func(t *testing.T) {
+		pool := sup.NewWorkerPool("pool", 1, func(context.Context, int) error { return nil })
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		shouldEqual(t, errors.Is(pool.Submit(ctx, 1), context.Canceled), true)
+	})
+	t.Run("a soft stop should end idle workers", func(t *testing.T) {
+		pool := sup.NewWorkerPool("pool", 2, func(context.Context, int) error { return nil })
+		svr := sup.SuperviseForkJoin("main", []sup.Task{pool})
+		go func() {
+			for svr.Phase() != sup.Phase_collecting {
+				time.Sleep(time.Millisecond)
+			}
+			svr.SoftStop()
+		}()
+		shouldEqual(t, sup.SuperviseRoot(context.Background(), svr), nil)
+	})
+	t.Run("a handler's error should end the pool", func(t *testing.T) {
+		pool := sup.NewWorkerPool("pool", 2, func(ctx context.Context, n int) error {
+			if n == 3 {
+				return errors.New("bad item")
+			}
+			return nil
+		})
+		svr := sup.SuperviseForkJoin("main", []sup.Task{
+			pool,
+			namedFunc{"producer", func(ctx context.Context) error {
+				for i := 0; ; i++ {
+					if err := pool.Submit(ctx, i); err != nil {
+						return nil // the pool's gone; that's its error to report.
+					}
+				}
+			}},
+		})
+		err := sup.SuperviseRoot(context.Background(), svr)
+		shouldEqual(t, fmt.Sprint(err), "bad item")
+		var closed sup.ErrChannelClosed
+		shouldEqual(t, errors.As(pool.Submit(context.Background(), 9), &closed), true)
+	})
+}